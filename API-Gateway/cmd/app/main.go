@@ -3,8 +3,13 @@ package main
 import (
 	"apigateway/internal/app"
 	usersgrpcstorage "apigateway/internal/storage/users/grpc"
+	usersmemorystorage "apigateway/internal/storage/users/memory"
 	"apigateway/pkg/config"
+	"apigateway/pkg/lib/grpc/breaker"
+	grpccreds "apigateway/pkg/lib/grpc/creds"
 	"apigateway/pkg/lib/logger"
+	"apigateway/pkg/lib/logger/sl"
+	"context"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -18,9 +23,20 @@ func main() {
 
 	log.Info("application config", slog.Any("config", cfg))
 
-	storage := usersgrpcstorage.New(log, cfg.UsersStorageHost, cfg.UsersStoragePort)
+	var storage app.IUserStorage
+	switch cfg.UsersStorageType {
+	case config.StorageMemory:
+		log.Warn("Users are kept in memory and lost on restart")
+		storage = usersmemorystorage.New(log)
+	case config.StorageGRPC:
+		grpcStorage := newGRPCStorage(log, cfg)
+		defer grpcStorage.Close()
+		storage = grpcStorage
+	default:
+		panic("unknown users storage type: " + cfg.UsersStorageType)
+	}
 
-	application := app.New(log, cfg.Port, storage)
+	application := app.New(log, cfg, storage)
 
 	go func() {
 		application.MustRun()
@@ -30,6 +46,33 @@ func main() {
 	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
 	<-stop
 
-	storage.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownDelay+cfg.ShutdownTimeout)
+	defer cancel()
+	if err := application.Shutdown(ctx, cfg.ShutdownDelay); err != nil {
+		log.Error("Failed to shut down gracefully", sl.Err(err))
+	}
+}
+
+// newGRPCStorage connects to UsersManager. Panics if a blocking connect fails.
+func newGRPCStorage(log *slog.Logger, cfg *config.Config) *usersgrpcstorage.GRPCUsersStorage {
+	cb := breaker.New(cfg.UsersStorageBreakerThreshold, cfg.UsersStorageBreakerOpenTimeout)
+
+	var opts []usersgrpcstorage.Option
+	if cfg.UsersStorageTLS {
+		creds, err := grpccreds.ClientTLS(cfg.UsersStorageTLSCAFile, cfg.UsersStorageTLSCertFile, cfg.UsersStorageTLSKeyFile, cfg.UsersStorageTLSServerName)
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts, usersgrpcstorage.WithTransportCredentials(creds))
+	}
+
+	if cfg.UsersStorageBlockingConnect {
+		storage, err := usersgrpcstorage.Connect(log, cfg.UsersStorageHost, cfg.UsersStoragePort, cfg.StrictUsersDecoding, cb, cfg.UsersStorageDialTimeout, opts...)
+		if err != nil {
+			panic(err)
+		}
+		return storage
+	}
 
+	return usersgrpcstorage.New(log, cfg.UsersStorageHost, cfg.UsersStoragePort, cfg.StrictUsersDecoding, cb, opts...)
 }
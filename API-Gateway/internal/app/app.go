@@ -2,12 +2,23 @@ package app
 
 import (
 	"apigateway/internal/domain/models"
+	"apigateway/internal/handlers"
 	usershandlers "apigateway/internal/handlers/users"
+	"apigateway/internal/middleware"
 	usersservice "apigateway/internal/service/users"
+	"apigateway/pkg/config"
+	"apigateway/pkg/lib/logger/sl"
+	"apigateway/pkg/lib/panicreport"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -16,23 +27,47 @@ import (
 type IUserStorage interface {
 	GetUsers(ctx context.Context) ([]models.User, error)
 	GetUserById(ctx context.Context, uid uuid.UUID) (models.User, error)
+	GetUserByLogin(ctx context.Context, login string) (models.User, error)
+	GetUsersByRole(ctx context.Context, role string) ([]models.User, error)
 	Insert(ctx context.Context, user models.User) (models.User, error)
+	InsertBatch(ctx context.Context, users []models.User) ([]models.User, error)
 	Update(ctx context.Context, uid uuid.UUID, user models.User) (models.User, error)
 	Delete(ctx context.Context, uid uuid.UUID) (models.User, error)
 }
 
+// statusReporter is implemented by storages that can describe their backend.
+type statusReporter interface {
+	Status(ctx context.Context) (models.BackendStatus, error)
+}
+
+// backendStatusTimeout bounds the backend lookup of /status.
+const backendStatusTimeout = 2 * time.Second
+
+// backendWaiter is implemented by storages that need a remote backend to come up first.
+type backendWaiter interface {
+	WaitForReady(ctx context.Context) error
+}
+
 type App struct {
-	log     *slog.Logger
-	port    int
-	storage IUserStorage
+	log      *slog.Logger
+	cfg      *config.Config
+	storage  IUserStorage
+	server   *http.Server
+	reporter panicreport.PanicReporter
+	ready    atomic.Bool
+	draining atomic.Bool
 }
 
-func New(log *slog.Logger, port int, storage IUserStorage) *App {
-	return &App{
-		log:     log,
-		port:    port,
-		storage: storage,
+func New(log *slog.Logger, cfg *config.Config, storage IUserStorage) *App {
+	a := &App{
+		log:      log,
+		cfg:      cfg,
+		storage:  storage,
+		reporter: panicreport.New(cfg.PanicReportURL, "apigateway", cfg.PanicReportTimeout),
 	}
+	a.server = a.Server()
+
+	return a
 }
 
 func (a *App) MustRun() {
@@ -42,28 +77,275 @@ func (a *App) MustRun() {
 }
 
 func (a *App) Run() error {
-	r := mux.NewRouter()
+	a.log.Info("Feature flags", slog.Any("flags", a.cfg.FeatureFlags))
+
+	if PprofEnabled(a.cfg.Env) {
+		go a.runPprof()
+	}
+
+	if waiter, ok := a.storage.(backendWaiter); ok {
+		go a.awaitBackend(waiter)
+	} else {
+		a.MarkReady()
+	}
+
+	l, err := net.Listen("tcp", a.server.Addr)
+	if err != nil {
+		return err
+	}
+
+	return a.Serve(l)
+}
+
+// Serve serves the public HTTP server on l until it is shut down.
+func (a *App) Serve(l net.Listener) error {
+	if err := a.server.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Shutdown drains the app: /readyz reports not ready at once while requests are still
+// served for delay, so load balancers stop routing here. Then the server stops accepting
+// connections and waits for in-flight requests until ctx is done.
+func (a *App) Shutdown(ctx context.Context, delay time.Duration) error {
+	const op = "app.Shutdown"
+	log := a.log.With("op", op)
+
+	a.draining.Store(true)
+	log.Info("Draining", slog.Duration("delay", delay))
+
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+	}
+
+	if err := a.server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Server builds the public HTTP server. Requests whose header block exceeds
+// MaxHeaderBytes are rejected by net/http with 431.
+func (a *App) Server() *http.Server {
+	return &http.Server{
+		Addr:           fmt.Sprintf(":%d", a.cfg.Port),
+		Handler:        a.Router(),
+		MaxHeaderBytes: a.cfg.MaxHeaderBytes,
+	}
+}
+
+// MarkReady lets API routes through. Until it is called they answer 503.
+func (a *App) MarkReady() {
+	a.ready.Store(true)
+}
+
+// IsReady reports whether the backend is ready to serve API requests.
+func (a *App) IsReady() bool {
+	return a.ready.Load()
+}
+
+// readyz reports whether the gateway should receive traffic: the backend is ready
+// and the app is not draining for shutdown.
+func (a *App) readyz(w http.ResponseWriter, r *http.Request) {
+	status, code := "ready", http.StatusOK
+	if !a.IsReady() || a.draining.Load() {
+		status, code = "not ready", http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": status})
+}
+
+// statusResponse is the /status body.
+type statusResponse struct {
+	Version      string              `json:"version"`
+	Ready        bool                `json:"ready"`
+	UsersBackend *usersBackendStatus `json:"users_backend,omitempty"`
+}
+
+type usersBackendStatus struct {
+	Reachable     bool   `json:"reachable"`
+	Version       string `json:"version,omitempty"`
+	SchemaVersion int64  `json:"schema_version,omitempty"`
+	// Database is "ok" or "unavailable" as reported by the backend, or "unknown"
+	// if the backend cannot be reached.
+	Database string `json:"database"`
+}
+
+// status reports the versions the gateway and its backend run, the backend's
+// migration version and database connectivity. It is informational and always
+// answers 200; /readyz decides about traffic.
+func (a *App) status(w http.ResponseWriter, r *http.Request) {
+	resp := statusResponse{
+		Version: buildVersion(),
+		Ready:   a.IsReady() && !a.draining.Load(),
+	}
+
+	if reporter, ok := a.storage.(statusReporter); ok {
+		ctx, cancel := context.WithTimeout(r.Context(), backendStatusTimeout)
+		defer cancel()
+
+		backend := &usersBackendStatus{Database: "unknown"}
+		if st, err := reporter.Status(ctx); err != nil {
+			a.log.Warn("Users backend status unavailable", sl.Err(err))
+		} else {
+			backend.Reachable = true
+			backend.Version = st.Version
+			backend.SchemaVersion = st.SchemaVersion
+			backend.Database = "unavailable"
+			if st.Serving {
+				backend.Database = "ok"
+			}
+		}
+		resp.UsersBackend = backend
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// buildVersion returns the VCS revision the gateway was built from, or "devel".
+func buildVersion() string {
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			if setting.Key == "vcs.revision" && setting.Value != "" {
+				return setting.Value
+			}
+		}
+	}
+
+	return "devel"
+}
+
+// Router builds the public HTTP router with all enabled API routes registered.
+// API routes answer 503 until the app is marked ready; /healthz is always served,
+// /readyz reports readiness for traffic and /status reports versions.
+// Paths are canonical without a trailing slash; slashed paths are redirected.
+func (a *App) Router() http.Handler {
+	root := mux.NewRouter()
+	root.NotFoundHandler = unrouted(root)
+	root.MethodNotAllowedHandler = root.NotFoundHandler
+	root.Use(middleware.RequestIDHeader(a.cfg.RequestIDHeader))
+	root.Use(middleware.Recover(a.log, a.reporter))
+	root.HandleFunc("/healthz", healthz).Methods(http.MethodGet)
+	root.HandleFunc("/readyz", a.readyz).Methods(http.MethodGet)
+	root.HandleFunc("/status", a.status).Methods(http.MethodGet)
+
+	api := root.PathPrefix("/api").Subrouter()
+	api.MethodNotAllowedHandler = root.MethodNotAllowedHandler
+	api.Use(middleware.ErrorLog(a.log))
+	api.Use(middleware.SecureHeaders(a.securityHeaders()))
+	api.Use(middleware.Gzip(a.cfg.GzipMinSize, a.cfg.GzipContentTypes))
+	api.Use(middleware.Ready(a.IsReady))
+	api.Use(middleware.MaxInFlight(a.cfg.MaxInFlight))
+	api.Use(middleware.BodyReadTimeout(a.cfg.BodyReadTimeout, a.cfg.MaxBodyBytes))
 
 	usersService := usersservice.New(a.log, a.storage)
-	usersHandler := usershandlers.New(a.log, usersService)
+	usersHandler := usershandlers.New(
+		a.log,
+		usersService,
+		usershandlers.WithListEnvelope(a.cfg.ListEnvelope),
+		usershandlers.WithUserMaxAge(a.cfg.UserCacheMaxAge),
+		usershandlers.WithPageLimits(a.cfg.ListDefaultLimit, a.cfg.ListMaxLimit),
+		usershandlers.WithDefaultSort(a.cfg.ListDefaultSort),
+		usershandlers.WithMaxBatchSize(a.cfg.MaxBatchSize),
+		usershandlers.WithBasePath("/api/v1"),
+	)
+
+	// The availability and validate checks are open to anonymous clients, reveal which
+	// logins exist and each fetch the whole users list from the backend, so they share
+	// one per-client limit to slow down enumeration and protect the backend.
+	loginLookupLimit := middleware.ClientRateLimit(a.cfg.LoginAvailabilityLimit, a.cfg.LoginAvailabilityWindow)
 
-	r.HandleFunc("/api/v1/login", nil).Methods(http.MethodPost)
-	r.HandleFunc("/api/v1/register", nil).Methods(http.MethodPost)
-	r.HandleFunc("/api/v1/refresh", nil).Methods(http.MethodPost)
-	r.HandleFunc("/api/v1/logout", nil).Methods(http.MethodPost)
+	routes := []route{
+		{RouteLogin, http.MethodPost, "/v1/login", notImplemented},
+		{RouteRegister, http.MethodPost, "/v1/register", notImplemented},
+		{RouteRefresh, http.MethodPost, "/v1/refresh", notImplemented},
+		{RouteLogout, http.MethodPost, "/v1/logout", notImplemented},
 
-	r.HandleFunc("/api/v1/users", usersHandler.GetUsersHandler).Methods(http.MethodGet)
-	r.HandleFunc("/api/v1/users/{id}", usersHandler.GetUserByIdHandler).Methods(http.MethodGet)
-	r.HandleFunc("/api/v1/users", usersHandler.InsertHandler).Methods(http.MethodPost)
-	r.HandleFunc("/api/v1/users/{id}", usersHandler.UpdateHandler).Methods(http.MethodPut)
-	r.HandleFunc("/api/v1/users/{id}", usersHandler.DeleteHandler).Methods(http.MethodDelete)
+		{RouteUsersValidate, http.MethodPost, "/v1/users/validate", loginLookupLimit(http.HandlerFunc(usersHandler.ValidateHandler)).ServeHTTP},
+		{RouteUsersImport, http.MethodPost, "/v1/users/import", usersHandler.ImportHandler},
+		{RouteUsersAvailable, http.MethodGet, "/v1/users/available", loginLookupLimit(http.HandlerFunc(usersHandler.AvailableHandler)).ServeHTTP},
+		{RouteUsersList, http.MethodGet, "/v1/users", usersHandler.GetUsersHandler},
+		{RouteUsersByRole, http.MethodGet, "/v1/users/by-role/{role}", usersHandler.GetUsersByRoleHandler},
+		{RouteUsersGet, http.MethodGet, "/v1/users/{id}", usersHandler.GetUserByIdHandler},
+		{RouteUsersInsert, http.MethodPost, "/v1/users", usersHandler.InsertHandler},
+		{RouteUsersUpdate, http.MethodPut, "/v1/users/{id}", usersHandler.UpdateHandler},
+		{RouteUsersDelete, http.MethodDelete, "/v1/users/{id}", usersHandler.DeleteHandler},
 
+		{RouteErrors, http.MethodGet, "/v1/errors", usersHandler.ErrorCatalogHandler},
+	}
+
+	for _, rt := range routes {
+		if !a.routeEnabled(rt.name) {
+			a.log.Info("Route disabled", slog.String("route", rt.name))
+			continue
+		}
+
+		if !a.featureEnabled(rt.name) {
+			// Registered anyway so the path answers 404 instead of 405 from a sibling route.
+			a.log.Debug("Experimental route switched off", slog.String("route", rt.name))
+			api.HandleFunc(rt.path, http.NotFound).Methods(rt.method)
+			continue
+		}
+
+		api.HandleFunc(rt.path, rt.handler).Methods(rt.method).Name(rt.name)
+	}
+
+	return middleware.MaxURILength(a.cfg.MaxURILength, a.cfg.MaxQueryParamLength)(middleware.TrimTrailingSlash(root))
+}
+
+// securityHeaders returns the configured security headers, or the defaults if none are set.
+func (a *App) securityHeaders() map[string]string {
+	if len(a.cfg.SecurityHeaders) == 0 {
+		return middleware.DefaultSecurityHeaders
+	}
+
+	return a.cfg.SecurityHeaders
+}
+
+// awaitBackend marks the app ready once the storage backend is reachable.
+func (a *App) awaitBackend(waiter backendWaiter) {
+	const op = "app.awaitBackend"
+	log := a.log.With("op", op)
+
+	log.Info("Waiting for users backend")
+	if err := waiter.WaitForReady(context.Background()); err != nil {
+		log.Error("Users backend did not become ready", sl.Err(err))
+		return
+	}
+
+	a.MarkReady()
+	log.Info("Users backend is ready")
+}
+
+// healthz reports that the gateway process is up, regardless of backend readiness.
+func healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// notImplemented is a placeholder for routes whose handlers are not built yet.
+func notImplemented(w http.ResponseWriter, r *http.Request) {
+	handlers.JSONError(w, r, "not implemented", http.StatusNotImplemented)
+}
+
+// runPprof serves the profiling endpoints on the internal pprof port.
+// The listener is kept off the public router so profiling data is never exposed there.
+func (a *App) runPprof() {
+	const op = "app.runPprof"
+	log := a.log.With("op", op)
+
+	log.Info("Starting pprof server", slog.Int("port", a.cfg.PprofPort))
 	if err := http.ListenAndServe(
-		fmt.Sprintf(":%d", a.port),
-		r,
+		fmt.Sprintf(":%d", a.cfg.PprofPort),
+		PprofHandler(a.cfg.Env),
 	); err != nil {
-		panic(err)
+		log.Error("Pprof server stopped", sl.Err(err))
 	}
-
-	return nil
 }
@@ -0,0 +1,607 @@
+package app_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"apigateway/internal/app"
+	"apigateway/internal/domain/models"
+	storageerrors "apigateway/internal/storage"
+	usersgrpcstorage "apigateway/internal/storage/users/grpc"
+	usersmemorystorage "apigateway/internal/storage/users/memory"
+	"apigateway/pkg/config"
+	"apigateway/pkg/lib/logger/handler/slogdiscard"
+	"apigateway/pkg/lib/requestid"
+
+	umv1 "github.com/chas3air/protos/gen/go/usersManager"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type mockUserStorage struct {
+	mock.Mock
+}
+
+func (m *mockUserStorage) GetUsers(ctx context.Context) ([]models.User, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *mockUserStorage) GetUserById(ctx context.Context, uid uuid.UUID) (models.User, error) {
+	args := m.Called(ctx, uid)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
+func (m *mockUserStorage) GetUserByLogin(ctx context.Context, login string) (models.User, error) {
+	args := m.Called(ctx, login)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
+func (m *mockUserStorage) GetUsersByRole(ctx context.Context, role string) ([]models.User, error) {
+	args := m.Called(ctx, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *mockUserStorage) Insert(ctx context.Context, user models.User) (models.User, error) {
+	args := m.Called(ctx, user)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
+func (m *mockUserStorage) InsertBatch(ctx context.Context, users []models.User) ([]models.User, error) {
+	args := m.Called(ctx, users)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *mockUserStorage) Update(ctx context.Context, uid uuid.UUID, user models.User) (models.User, error) {
+	args := m.Called(ctx, uid, user)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
+func (m *mockUserStorage) Delete(ctx context.Context, uid uuid.UUID) (models.User, error) {
+	args := m.Called(ctx, uid)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
+// statusStorage reports a fixed backend status.
+type statusStorage struct {
+	*mockUserStorage
+	status models.BackendStatus
+	err    error
+}
+
+func (s *statusStorage) Status(context.Context) (models.BackendStatus, error) {
+	return s.status, s.err
+}
+
+func newTestApp(t *testing.T) (*app.App, *mockUserStorage) {
+	storage := new(mockUserStorage)
+	cfg := &config.Config{Env: config.EnvProd}
+	application := app.New(slogdiscard.NewDiscardLogger(), cfg, storage)
+	application.MarkReady()
+	return application, storage
+}
+
+func TestRouter_NotImplementedRoutes(t *testing.T) {
+	application, _ := newTestApp(t)
+	router := application.Router()
+
+	for _, path := range []string{"/api/v1/login", "/api/v1/register", "/api/v1/refresh", "/api/v1/logout"} {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, path, nil)
+			w := httptest.NewRecorder()
+
+			assert.NotPanics(t, func() { router.ServeHTTP(w, req) })
+			assert.Equal(t, http.StatusNotImplemented, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+			var body map[string]string
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+			assert.Equal(t, "not implemented", body["error"])
+		})
+	}
+}
+
+// failingBackend is a UsersManager backend that records the incoming request id and fails.
+type failingBackend struct {
+	umv1.UnimplementedUsersManagerServer
+	requestID string
+}
+
+func (b *failingBackend) GetUserById(ctx context.Context, req *umv1.GetUserByIdRequest) (*umv1.GetUserByIdResponse, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(requestid.MetadataKey); len(ids) > 0 {
+			b.requestID = ids[0]
+		}
+	}
+	return nil, status.Error(codes.Internal, "failed to fetch user by id")
+}
+
+func newBufconnStorage(t *testing.T, log *slog.Logger, backend umv1.UsersManagerServer) *usersgrpcstorage.GRPCUsersStorage {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	umv1.RegisterUsersManagerServer(srv, backend)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(requestid.UnaryClientInterceptor()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return &usersgrpcstorage.GRPCUsersStorage{Log: log, Conn: conn}
+}
+
+func TestRouter_RequestIdCorrelation(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	backend := &failingBackend{}
+	storage := newBufconnStorage(t, log, backend)
+	application := app.New(log, &config.Config{Env: config.EnvProd}, storage)
+	application.MarkReady()
+	router := application.Router()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/"+uuid.NewString(), nil)
+	req.Header.Set(requestid.Header, "req-123")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "req-123", w.Header().Get(requestid.Header))
+	assert.Equal(t, "req-123", backend.requestID)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.NotEmpty(t, lines)
+	for _, line := range lines {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		assert.Equal(t, "req-123", entry["request_id"], line)
+	}
+}
+
+func TestRouter_ReadyGate(t *testing.T) {
+	storage := new(mockUserStorage)
+	application := app.New(slogdiscard.NewDiscardLogger(), &config.Config{Env: config.EnvProd}, storage)
+	router := application.Router()
+
+	storage.On("GetUsers", mock.Anything).Return([]models.User{}, nil)
+
+	t.Run("api is unavailable before ready", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		storage.AssertNotCalled(t, "GetUsers", mock.Anything)
+	})
+
+	t.Run("healthz is up before ready", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("readyz is not ready before ready", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("api succeeds after ready", func(t *testing.T) {
+		application.MarkReady()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestRouter_NilUserIdRejected(t *testing.T) {
+	application, storage := newTestApp(t)
+	router := application.Router()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/"+uuid.Nil.String(), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	storage.AssertNotCalled(t, "GetUserById", mock.Anything, mock.Anything)
+}
+
+func TestRouter_DisabledRoutes(t *testing.T) {
+	storage := new(mockUserStorage)
+	cfg := &config.Config{
+		Env:            config.EnvProd,
+		DisabledRoutes: []string{app.RouteUsersInsert, app.RouteUsersDelete, app.RouteLogin},
+	}
+	application := app.New(slogdiscard.NewDiscardLogger(), cfg, storage)
+	application.MarkReady()
+	router := application.Router()
+
+	storage.On("GetUsers", mock.Anything).Return([]models.User{}, nil)
+
+	notRouted := []int{http.StatusNotFound, http.StatusMethodNotAllowed}
+
+	tests := []struct {
+		method string
+		path   string
+		want   []int
+	}{
+		{http.MethodPost, "/api/v1/users", notRouted},
+		{http.MethodDelete, "/api/v1/users/" + uuid.NewString(), notRouted},
+		{http.MethodPost, "/api/v1/login", notRouted},
+		{http.MethodGet, "/api/v1/users", []int{http.StatusOK}},
+		{http.MethodPost, "/api/v1/register", []int{http.StatusNotImplemented}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader("{}"))
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Contains(t, tt.want, w.Code)
+		})
+	}
+
+	storage.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	storage.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestRouter_FeatureFlags(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags map[string]bool
+		want  int
+	}{
+		{"off by default", nil, http.StatusNotFound},
+		{"switched off", map[string]bool{app.RouteUsersImport: false}, http.StatusNotFound},
+		{"switched on", map[string]bool{app.RouteUsersImport: true}, http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Env: config.EnvProd, FeatureFlags: tt.flags}
+			application := app.New(slogdiscard.NewDiscardLogger(), cfg, new(mockUserStorage))
+			application.MarkReady()
+
+			// Without a CSV body the import handler answers 415, proving it was routed.
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/users/import", strings.NewReader("{}"))
+			w := httptest.NewRecorder()
+			application.Router().ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestRouter_MethodNotAllowed(t *testing.T) {
+	application, _ := newTestApp(t)
+	router := application.Router()
+
+	tests := []struct {
+		method string
+		path   string
+		allow  string
+	}{
+		{http.MethodPatch, "/api/v1/users/" + uuid.NewString(), "DELETE, GET, PUT"},
+		{http.MethodHead, "/api/v1/users/" + uuid.NewString(), "DELETE, GET, PUT"},
+		{http.MethodDelete, "/api/v1/users", "GET, POST"},
+		{http.MethodPost, "/healthz", "GET"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+			assert.Equal(t, tt.allow, w.Header().Get("Allow"))
+		})
+	}
+
+	t.Run("unknown path", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/nope", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Empty(t, w.Header().Get("Allow"))
+	})
+}
+
+func TestRouter_MemoryStorage(t *testing.T) {
+	cfg := &config.Config{Env: config.EnvProd}
+	application := app.New(slogdiscard.NewDiscardLogger(), cfg, usersmemorystorage.New(slogdiscard.NewDiscardLogger()))
+	application.MarkReady()
+	router := application.Router()
+
+	do := func(t *testing.T, method, path string, body any) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, &buf))
+		return w
+	}
+
+	admin := models.User{Id: uuid.New(), Login: "admin", Password: "secret", Role: models.RoleAdmin}
+	user := models.User{Id: uuid.New(), Login: "user", Password: "secret", Role: models.RoleUser}
+	for _, u := range []models.User{admin, user} {
+		w := do(t, http.MethodPost, "/api/v1/users", u)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	w := do(t, http.MethodPost, "/api/v1/users", models.User{Id: uuid.New(), Login: "USER", Password: "x", Role: models.RoleUser})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = do(t, http.MethodGet, "/api/v1/users", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	// Responses never carry the password.
+	withoutPassword := func(u models.User) models.User {
+		u.Password = ""
+		return u
+	}
+
+	var list []models.User
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	assert.ElementsMatch(t, []models.User{withoutPassword(admin), withoutPassword(user)}, list)
+
+	user.Login = "renamed"
+	w = do(t, http.MethodPut, "/api/v1/users/"+user.Id.String(), user)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = do(t, http.MethodGet, "/api/v1/users/"+user.Id.String(), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var got models.User
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Equal(t, withoutPassword(user), got)
+
+	w = do(t, http.MethodDelete, "/api/v1/users/"+user.Id.String(), nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = do(t, http.MethodGet, "/api/v1/users/"+user.Id.String(), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRouter_Status(t *testing.T) {
+	tests := []struct {
+		name    string
+		storage app.IUserStorage
+		want    string
+	}{
+		{
+			name: "backend serving",
+			storage: &statusStorage{
+				mockUserStorage: new(mockUserStorage),
+				status:          models.BackendStatus{Serving: true, Version: "abc123", SchemaVersion: 20251016140000},
+			},
+			want: `{"reachable":true,"version":"abc123","schema_version":20251016140000,"database":"ok"}`,
+		},
+		{
+			name: "backend without database",
+			storage: &statusStorage{
+				mockUserStorage: new(mockUserStorage),
+				status:          models.BackendStatus{Version: "abc123", SchemaVersion: 20251016140000},
+			},
+			want: `{"reachable":true,"version":"abc123","schema_version":20251016140000,"database":"unavailable"}`,
+		},
+		{
+			name:    "backend unreachable",
+			storage: &statusStorage{mockUserStorage: new(mockUserStorage), err: status.Error(codes.Unavailable, "down")},
+			want:    `{"reachable":false,"database":"unknown"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			application := app.New(slogdiscard.NewDiscardLogger(), &config.Config{Env: config.EnvProd}, tt.storage)
+			application.MarkReady()
+
+			w := httptest.NewRecorder()
+			application.Router().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			var got struct {
+				Version      string          `json:"version"`
+				Ready        bool            `json:"ready"`
+				UsersBackend json.RawMessage `json:"users_backend"`
+			}
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.NotEmpty(t, got.Version)
+			assert.True(t, got.Ready)
+			assert.JSONEq(t, tt.want, string(got.UsersBackend))
+		})
+	}
+}
+
+func TestRouter_TrailingSlash(t *testing.T) {
+	application, storage := newTestApp(t)
+	router := application.Router()
+
+	id := uuid.New()
+	storage.On("GetUsers", mock.Anything).Return([]models.User{}, nil)
+	storage.On("GetUserById", mock.Anything, id).Return(models.User{Id: id}, nil)
+
+	tests := []struct {
+		method   string
+		path     string
+		want     int
+		location string
+	}{
+		{http.MethodGet, "/api/v1/users", http.StatusOK, ""},
+		{http.MethodGet, "/api/v1/users/", http.StatusPermanentRedirect, "/api/v1/users"},
+		{http.MethodGet, "/api/v1/users/" + id.String(), http.StatusOK, ""},
+		{http.MethodGet, "/api/v1/users/" + id.String() + "/", http.StatusPermanentRedirect, "/api/v1/users/" + id.String()},
+		{http.MethodPost, "/api/v1/users/", http.StatusPermanentRedirect, "/api/v1/users"},
+		{http.MethodPost, "/api/v1/register/", http.StatusPermanentRedirect, "/api/v1/register"},
+		{http.MethodPost, "/api/v1/register", http.StatusNotImplemented, ""},
+		{http.MethodGet, "/healthz/", http.StatusPermanentRedirect, "/healthz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader("{}")))
+
+			assert.Equal(t, tt.want, w.Code)
+			assert.Equal(t, tt.location, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestServer_MaxHeaderBytes(t *testing.T) {
+	storage := new(mockUserStorage)
+	cfg := &config.Config{Env: config.EnvProd, MaxHeaderBytes: 1024}
+	application := app.New(slogdiscard.NewDiscardLogger(), cfg, storage)
+	application.MarkReady()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := application.Server()
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(func() { srv.Close() })
+
+	url := "http://" + lis.Addr().String() + "/healthz"
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	// net/http allows some slack above MaxHeaderBytes, so go well past it.
+	req.Header.Set("X-Oversized", strings.Repeat("a", 16*1024))
+
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, resp.StatusCode)
+}
+
+func TestApp_ShutdownDrain(t *testing.T) {
+	application, storage := newTestApp(t)
+	storage.On("GetUsers", mock.Anything).Return([]models.User{}, nil)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	served := make(chan error, 1)
+	go func() { served <- application.Serve(lis) }()
+
+	base := "http://" + lis.Addr().String()
+	get := func(path string) int {
+		resp, err := http.Get(base + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusOK, get("/readyz"))
+
+	shutdownDone := make(chan error, 1)
+	go func() { shutdownDone <- application.Shutdown(context.Background(), 300*time.Millisecond) }()
+
+	// During the drain window readiness fails but requests are still served.
+	assert.Eventually(t, func() bool { return get("/readyz") == http.StatusServiceUnavailable }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, http.StatusOK, get("/api/v1/users"))
+
+	require.NoError(t, <-shutdownDone)
+	require.NoError(t, <-served)
+
+	_, err = http.Get(base + "/healthz")
+	assert.Error(t, err)
+}
+
+func TestRouter_LoginAvailable(t *testing.T) {
+	storage := new(mockUserStorage)
+	cfg := &config.Config{Env: config.EnvProd, LoginAvailabilityLimit: 2, LoginAvailabilityWindow: time.Hour}
+	application := app.New(slogdiscard.NewDiscardLogger(), cfg, storage)
+	application.MarkReady()
+	router := application.Router()
+
+	storage.On("GetUserByLogin", mock.Anything, "alice").Return(models.User{Id: uuid.New(), Login: "Alice"}, nil)
+
+	check := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users/available?login=alice", nil))
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		w := check()
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"available":false}`, w.Body.String())
+	}
+
+	assert.Equal(t, http.StatusTooManyRequests, check().Code)
+}
+
+func TestRouter_ValidateSharesLoginLimit(t *testing.T) {
+	storage := new(mockUserStorage)
+	cfg := &config.Config{Env: config.EnvProd, LoginAvailabilityLimit: 2, LoginAvailabilityWindow: time.Hour}
+	application := app.New(slogdiscard.NewDiscardLogger(), cfg, storage)
+	application.MarkReady()
+	router := application.Router()
+
+	storage.On("GetUserByLogin", mock.Anything, "alice").Return(models.User{}, storageerrors.ErrNotFound)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users/available?login=alice", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	validate := func() int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/users/validate", strings.NewReader(`{"login":"alice","password":"Secret123!"}`)))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, validate())
+	assert.Equal(t, http.StatusTooManyRequests, validate())
+}
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"apigateway/pkg/config"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+)
+
+// PprofEnabled reports whether profiling endpoints may be exposed in the given environment.
+func PprofEnabled(env string) bool {
+	return env == config.EnvLocal || env == config.EnvDev
+}
+
+// PprofHandler returns the handler for the internal pprof port.
+// Outside local and dev environments no routes are registered and every request gets 404.
+func PprofHandler(env string) http.Handler {
+	mux := http.NewServeMux()
+	if !PprofEnabled(env) {
+		return mux
+	}
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	return mux
+}
@@ -0,0 +1,34 @@
+package app_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"apigateway/internal/app"
+	"apigateway/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPprofHandler(t *testing.T) {
+	tests := []struct {
+		env  string
+		want int
+	}{
+		{env: config.EnvLocal, want: http.StatusOK},
+		{env: config.EnvDev, want: http.StatusOK},
+		{env: config.EnvProd, want: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil)
+			w := httptest.NewRecorder()
+
+			app.PprofHandler(tt.env).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"apigateway/internal/handlers"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Route names accepted in the DISABLED_ROUTES config.
+const (
+	RouteLogin          = "auth.login"
+	RouteRegister       = "auth.register"
+	RouteRefresh        = "auth.refresh"
+	RouteLogout         = "auth.logout"
+	RouteUsersValidate  = "users.validate"
+	RouteUsersImport    = "users.import"
+	RouteUsersAvailable = "users.available"
+	RouteUsersList      = "users.list"
+	RouteUsersByRole    = "users.byRole"
+	RouteUsersGet       = "users.get"
+	RouteUsersInsert    = "users.insert"
+	RouteUsersUpdate    = "users.update"
+	RouteUsersDelete    = "users.delete"
+	RouteErrors         = "errors.list"
+)
+
+// experimentalRoutes ship dark: they are only routed when their name is
+// switched on in the FEATURE_FLAGS config.
+var experimentalRoutes = []string{
+	RouteUsersImport,
+}
+
+// route is an API route registered under /api.
+type route struct {
+	name    string
+	method  string
+	path    string
+	handler http.HandlerFunc
+}
+
+// routeEnabled reports whether the route is not listed in the disabled routes config.
+func (a *App) routeEnabled(name string) bool {
+	return !slices.Contains(a.cfg.DisabledRoutes, name)
+}
+
+// featureEnabled reports whether the route is switched on. Only experimental routes can be off.
+func (a *App) featureEnabled(name string) bool {
+	return !slices.Contains(experimentalRoutes, name) || a.cfg.FeatureFlags[name]
+}
+
+// unrouted answers requests no route matched. If other methods are routed for
+// the path it answers 405 with an Allow header listing them, as RFC 9110
+// requires, otherwise 404. It serves as both the not found and the method not
+// allowed handler: mux reports a method mismatch as not found when a later
+// route under the same subrouter prefix fails to match the path.
+func unrouted(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed := allowedMethods(router, r)
+		if len(allowed) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		handlers.Error(w, r, "Method not allowed", http.StatusMethodNotAllowed)
+	})
+}
+
+// allowedMethods returns the sorted methods some route of router serves for the path of r.
+func allowedMethods(router *mux.Router, r *http.Request) []string {
+	var allowed []string
+	_ = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			// Not a method-bound route, e.g. the /api prefix.
+			return nil
+		}
+
+		for _, method := range methods {
+			probe := r.Clone(r.Context())
+			probe.Method = method
+			if !slices.Contains(allowed, method) && route.Match(probe, &mux.RouteMatch{}) {
+				allowed = append(allowed, method)
+			}
+		}
+
+		return nil
+	})
+
+	slices.Sort(allowed)
+	return allowed
+}
@@ -0,0 +1,9 @@
+package models
+
+// BackendStatus is what the users backend reports about itself.
+// Serving is false while the backend cannot reach its database.
+type BackendStatus struct {
+	Serving       bool
+	Version       string
+	SchemaVersion int64
+}
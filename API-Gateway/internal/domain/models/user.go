@@ -8,3 +8,14 @@ type User struct {
 	Password string    `validate:"required"`
 	Role     string    `validate:"required"`
 }
+
+// Roles a user can have.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// IsValidRole reports whether role is one of the known roles.
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin
+}
@@ -0,0 +1,46 @@
+package profiles_test
+
+import (
+	"testing"
+
+	"apigateway/internal/domain/models"
+	"apigateway/internal/domain/profiles"
+
+	umv1 "github.com/chas3air/protos/gen/go/usersManager"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiles_RoundTrip(t *testing.T) {
+	user := models.User{
+		Id:       uuid.New(),
+		Login:    "user1",
+		Password: "secret",
+		Role:     "admin",
+	}
+
+	got, err := profiles.ProtoUsrToUsr(profiles.UsrToProtoUsr(user))
+	require.NoError(t, err)
+	assert.Equal(t, user, got)
+}
+
+func TestProfiles_ProtoUsrToUsr(t *testing.T) {
+	t.Run("nil user", func(t *testing.T) {
+		_, err := profiles.ProtoUsrToUsr(nil)
+		assert.ErrorIs(t, err, profiles.ErrNilUser)
+	})
+
+	t.Run("missing optional fields", func(t *testing.T) {
+		id := uuid.New()
+
+		got, err := profiles.ProtoUsrToUsr(&umv1.User{Id: id.String()})
+		require.NoError(t, err)
+		assert.Equal(t, models.User{Id: id}, got)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		_, err := profiles.ProtoUsrToUsr(&umv1.User{Id: "bad-uuid", Login: "user1"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// JSONAPIMediaType is the Accept media type selecting JSON:API error documents.
+const JSONAPIMediaType = "application/vnd.api+json"
+
+// jsonAPIDocument is a JSON:API error document.
+type jsonAPIDocument struct {
+	Errors []jsonAPIError `json:"errors"`
+}
+
+type jsonAPIError struct {
+	Status string         `json:"status"`
+	Title  string         `json:"title"`
+	Detail string         `json:"detail,omitempty"`
+	Source *jsonAPISource `json:"source,omitempty"`
+}
+
+type jsonAPISource struct {
+	Pointer string `json:"pointer"`
+}
+
+// WantsJSONAPI reports whether the request explicitly accepts JSONAPIMediaType.
+// Wildcards do not count, so clients only get JSON:API errors when they opt in.
+func WantsJSONAPI(r *http.Request) bool {
+	for _, part := range strings.Split(strings.Join(r.Header.Values("Accept"), ","), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || mediaType != JSONAPIMediaType {
+			continue
+		}
+
+		if q, ok := params["q"]; ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				continue
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// Error answers with status and msg. Clients that opted into JSON:API get an error
+// document with msg as the detail; others get msg as plain text like http.Error.
+func Error(w http.ResponseWriter, r *http.Request, msg string, status int) {
+	if WantsJSONAPI(r) {
+		JSONAPIError(w, status, msg, "")
+		return
+	}
+
+	http.Error(w, msg, status)
+}
+
+// JSONError answers with status and msg like Error, but clients that did not opt into
+// JSON:API get a {"error": msg} JSON object instead of plain text.
+func JSONError(w http.ResponseWriter, r *http.Request, msg string, status int) {
+	if WantsJSONAPI(r) {
+		JSONAPIError(w, status, msg, "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// JSONAPIError writes a JSON:API error document with a single error. pointer is the
+// JSON pointer of the offending request field, or empty if there is none.
+func JSONAPIError(w http.ResponseWriter, status int, detail, pointer string) {
+	doc := jsonAPIDocument{Errors: []jsonAPIError{{
+		Status: strconv.Itoa(status),
+		Title:  http.StatusText(status),
+		Detail: detail,
+	}}}
+	if pointer != "" {
+		doc.Errors[0].Source = &jsonAPISource{Pointer: pointer}
+	}
+
+	w.Header().Set("Content-Type", JSONAPIMediaType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(doc)
+}
@@ -0,0 +1,81 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"apigateway/internal/handlers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWantsJSONAPI(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   bool
+	}{
+		{"missing", "", false},
+		{"json", "application/json", false},
+		{"wildcard", "*/*", false},
+		{"json api", "application/vnd.api+json", true},
+		{"json api among others", "application/json, application/vnd.api+json", true},
+		{"json api refused", "application/vnd.api+json;q=0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+
+			assert.Equal(t, tt.want, handlers.WantsJSONAPI(r))
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	t.Run("plain text by default", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handlers.Error(w, httptest.NewRequest(http.MethodGet, "/", nil), "User not found", http.StatusNotFound)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "User not found\n", w.Body.String())
+	})
+
+	t.Run("json api document when accepted", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", handlers.JSONAPIMediaType)
+		w := httptest.NewRecorder()
+		handlers.Error(w, r, "User not found", http.StatusNotFound)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, handlers.JSONAPIMediaType, w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"errors":[{"status":"404","title":"Not Found","detail":"User not found"}]}`, w.Body.String())
+	})
+}
+
+func TestJSONError(t *testing.T) {
+	t.Run("json object by default", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handlers.JSONError(w, httptest.NewRequest(http.MethodGet, "/", nil), "service is starting", http.StatusServiceUnavailable)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"error":"service is starting"}`, w.Body.String())
+	})
+
+	t.Run("json api document when accepted", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", handlers.JSONAPIMediaType)
+		w := httptest.NewRecorder()
+		handlers.JSONError(w, r, "service is starting", http.StatusServiceUnavailable)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, handlers.JSONAPIMediaType, w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"errors":[{"status":"503","title":"Service Unavailable","detail":"service is starting"}]}`, w.Body.String())
+	})
+}
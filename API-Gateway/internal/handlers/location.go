@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/google/uuid"
+)
+
+// SetLocation sets the Location header of a 201 Created response to the new
+// resource at <basePath>/<collection>/<id>.
+func SetLocation(w http.ResponseWriter, basePath, collection string, id uuid.UUID) {
+	w.Header().Set("Location", path.Join("/", basePath, collection, id.String()))
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Accepts reports whether the request's Accept header allows any of the given media types.
+// A missing Accept header, */* and type/* wildcards are accepted; ranges with q=0 are not.
+func Accepts(r *http.Request, mediaTypes ...string) bool {
+	accept := r.Header.Values("Accept")
+	if len(accept) == 0 {
+		return true
+	}
+
+	for _, part := range strings.Split(strings.Join(accept, ","), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		mediaRange, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+
+		if q, ok := params["q"]; ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				continue
+			}
+		}
+
+		for _, mediaType := range mediaTypes {
+			if mediaRangeMatches(mediaRange, mediaType) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func mediaRangeMatches(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+
+	rangeType, rangeSubtype, _ := strings.Cut(mediaRange, "/")
+	typ, _, _ := strings.Cut(mediaType, "/")
+	return rangeSubtype == "*" && rangeType == typ
+}
@@ -0,0 +1,39 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"apigateway/internal/handlers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccepts(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   bool
+	}{
+		{"missing", "", true},
+		{"json", "application/json", true},
+		{"json with profile", `application/json; profile="envelope"`, true},
+		{"wildcard", "*/*", true},
+		{"type wildcard", "application/*", true},
+		{"browser default", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", true},
+		{"xml only", "application/xml", false},
+		{"json refused", "application/json;q=0, application/xml", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			assert.Equal(t, tt.want, handlers.Accepts(req, "application/json"))
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package usershandlers
+
+import (
+	"apigateway/internal/handlers"
+	serviceerrors "apigateway/internal/service"
+	"apigateway/pkg/lib/logger/sl"
+	"apigateway/pkg/lib/requestid"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// availability is the result of a login availability check.
+type availability struct {
+	Available bool `json:"available"`
+}
+
+// AvailableHandler reports whether the login in the `login` query parameter can still
+// be registered. Logins are compared case-insensitively, like the unique login index.
+// The route should be rate limited per client since it reveals which logins exist.
+func (u *UsersHandler) AvailableHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.users.AvailableHandler"
+	log := u.log.With("op", op, "request_id", requestid.FromContext(r.Context()))
+	w.Header().Set("Cache-Control", "no-store")
+
+	select {
+	case <-r.Context().Done():
+		log.Info("Request cancelled", sl.Err(r.Context().Err()))
+		handlers.Error(w, r, "Request timeout", http.StatusRequestTimeout)
+		return
+	default:
+	}
+
+	login := strings.TrimSpace(r.URL.Query().Get("login"))
+	if login == "" {
+		log.Warn("Missing login")
+		handlers.Error(w, r, "login is required", http.StatusBadRequest)
+		return
+	}
+
+	available := false
+	_, err := u.service.GetUserByLogin(r.Context(), login)
+	switch {
+	case err == nil:
+	case errors.Is(err, serviceerrors.ErrNotFound):
+		available = true
+	default:
+		writeError(w, r, log, err, "Failed to check login availability")
+		return
+	}
+
+	log.Info("Login availability checked", slog.Bool("available", available))
+
+	writeJSON(w, r, log, http.StatusOK, availability{Available: available})
+}
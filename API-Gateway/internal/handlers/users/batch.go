@@ -0,0 +1,17 @@
+package usershandlers
+
+import (
+	"apigateway/internal/handlers"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// DefaultMaxBatchSize is the most items a bulk endpoint accepts unless set with WithMaxBatchSize.
+const DefaultMaxBatchSize = 500
+
+var errBatchTooLarge = errors.New("batch too large")
+
+func writeBatchTooLarge(w http.ResponseWriter, r *http.Request, maxBatchSize int) {
+	handlers.Error(w, r, fmt.Sprintf("Batch must have at most %d items", maxBatchSize), http.StatusRequestEntityTooLarge)
+}
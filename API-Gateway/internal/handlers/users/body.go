@@ -0,0 +1,25 @@
+package usershandlers
+
+import (
+	"apigateway/internal/domain/models"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+var errNotObject = errors.New("request body must be a JSON object")
+
+// decodeUser reads a user from a JSON object body. A literal null decodes without
+// error into a zero user that would only fail validation, so it is rejected with
+// errNotObject instead.
+func decodeUser(body io.Reader) (models.User, error) {
+	var user *models.User
+	if err := json.NewDecoder(body).Decode(&user); err != nil {
+		return models.User{}, err
+	}
+	if user == nil {
+		return models.User{}, errNotObject
+	}
+
+	return *user, nil
+}
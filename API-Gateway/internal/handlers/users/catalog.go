@@ -0,0 +1,62 @@
+package usershandlers
+
+import (
+	serviceerrors "apigateway/internal/service"
+	"apigateway/pkg/lib/requestid"
+	"net/http"
+)
+
+// errorCodes is the registry of domain errors clients can be told about. Codes are
+// stable so frontends can key localized messages on them; add new sentinels here.
+var errorCodes = []struct {
+	err         error
+	code        string
+	description string
+}{
+	{serviceerrors.ErrNotFound, "not_found", "The user does not exist."},
+	{serviceerrors.ErrAlreadyExists, "already_exists", "A user with the same id or login already exists."},
+	{serviceerrors.ErrDuplicateId, "duplicate_id", "A user with the same id already exists."},
+	{serviceerrors.ErrDuplicateLogin, "duplicate_login", "A user with the same login already exists."},
+	{serviceerrors.ErrInvalidArgument, "invalid_argument", "The request has a missing or malformed field."},
+	{serviceerrors.ErrForbidden, "forbidden", "The caller is not allowed to perform the operation."},
+	{serviceerrors.ErrDeadlineExeeced, "deadline_exceeded", "The users backend did not answer in time."},
+	{serviceerrors.ErrContextCanceled, "canceled", "The request was canceled before it completed."},
+	{serviceerrors.ErrResourceExhausted, "resource_exhausted", "The result is too large; use pagination."},
+	{serviceerrors.ErrUnavailable, "unavailable", "The users backend is temporarily unavailable; retry later."},
+	{serviceerrors.ErrInternal, "internal", "An unexpected error occurred."},
+}
+
+// errorCatalogEntry describes one error code and the HTTP status it is answered with.
+type errorCatalogEntry struct {
+	Code        string `json:"code"`
+	Status      int    `json:"status"`
+	Description string `json:"description"`
+}
+
+type errorCatalog struct {
+	Errors []errorCatalogEntry `json:"errors"`
+}
+
+// catalog returns the error codes of the registry in order.
+func catalog() errorCatalog {
+	entries := make([]errorCatalogEntry, 0, len(errorCodes))
+	for _, e := range errorCodes {
+		entries = append(entries, errorCatalogEntry{
+			Code:        e.code,
+			Status:      statusForError(e.err),
+			Description: e.description,
+		})
+	}
+
+	return errorCatalog{Errors: entries}
+}
+
+// ErrorCatalogHandler lists the domain error codes with their statuses and descriptions.
+// The catalog only changes with a release, so responses may be cached.
+func (u *UsersHandler) ErrorCatalogHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.users.ErrorCatalogHandler"
+	log := u.log.With("op", op, "request_id", requestid.FromContext(r.Context()))
+
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	writeJSON(w, r, log, http.StatusOK, catalog())
+}
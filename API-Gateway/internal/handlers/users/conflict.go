@@ -0,0 +1,37 @@
+package usershandlers
+
+import (
+	"apigateway/internal/handlers"
+	serviceerrors "apigateway/internal/service"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// conflictResponse is the 409 body. Field names the duplicated field when known.
+type conflictResponse struct {
+	Error string `json:"error"`
+	Field string `json:"field,omitempty"`
+}
+
+// writeConflict answers 409 Conflict for a duplicate user, naming the field that clashed.
+func writeConflict(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	resp := conflictResponse{Error: "User already exists"}
+	switch {
+	case errors.Is(err, serviceerrors.ErrDuplicateLogin):
+		resp.Field = "login"
+	case errors.Is(err, serviceerrors.ErrDuplicateId):
+		resp.Field = "id"
+	}
+
+	if handlers.WantsJSONAPI(r) {
+		pointer := ""
+		if resp.Field != "" {
+			pointer = "/" + resp.Field
+		}
+		handlers.JSONAPIError(w, http.StatusConflict, resp.Error, pointer)
+		return
+	}
+
+	writeJSON(w, r, log, http.StatusConflict, resp)
+}
@@ -0,0 +1,62 @@
+package usershandlers
+
+import (
+	"apigateway/internal/domain/models"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// EnvelopeProfile is the Accept media type profile selecting the list envelope,
+// e.g. `Accept: application/json; profile="envelope"`.
+const EnvelopeProfile = "envelope"
+
+// WithStatsParam is the query parameter adding summary counts to the users list,
+// e.g. `?withStats=true`. The response is then always enveloped; NDJSON
+// responses carry no stats.
+const WithStatsParam = "withStats"
+
+// listEnvelope wraps list responses so metadata can be added without breaking clients.
+type listEnvelope struct {
+	Data  any        `json:"data"`
+	Meta  listMeta   `json:"meta"`
+	Stats *listStats `json:"stats,omitempty"`
+}
+
+type listMeta struct {
+	Total  int `json:"total"`
+	Limit  int `json:"limit,omitempty"`
+	Offset int `json:"offset,omitempty"`
+}
+
+// listStats counts the whole list, not just the returned page.
+type listStats struct {
+	Total  int            `json:"total"`
+	ByRole map[string]int `json:"by_role"`
+}
+
+// statsFor counts users in total and per role.
+func statsFor(users []models.User) *listStats {
+	stats := &listStats{Total: len(users), ByRole: make(map[string]int)}
+	for _, user := range users {
+		stats.ByRole[user.Role]++
+	}
+
+	return stats
+}
+
+// wantsEnvelope reports whether the request asks for the envelope via the Accept profile.
+func wantsEnvelope(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		if mediaType == "application/json" && params["profile"] == EnvelopeProfile {
+			return true
+		}
+	}
+
+	return false
+}
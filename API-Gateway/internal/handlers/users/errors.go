@@ -0,0 +1,70 @@
+package usershandlers
+
+import (
+	"apigateway/internal/handlers"
+	serviceerrors "apigateway/internal/service"
+	"apigateway/pkg/lib/logger/sl"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// errorStatuses maps service errors to HTTP statuses. The first match wins, so an
+// error wrapping several sentinels gets the status of the earliest one.
+var errorStatuses = []struct {
+	err    error
+	status int
+}{
+	{serviceerrors.ErrContextCanceled, http.StatusRequestTimeout},
+	{serviceerrors.ErrDeadlineExeeced, http.StatusRequestTimeout},
+	{serviceerrors.ErrUnavailable, http.StatusServiceUnavailable},
+	{serviceerrors.ErrResourceExhausted, http.StatusBadGateway},
+	{serviceerrors.ErrInvalidArgument, http.StatusBadRequest},
+	{serviceerrors.ErrForbidden, http.StatusForbidden},
+	{serviceerrors.ErrNotFound, http.StatusNotFound},
+	{serviceerrors.ErrAlreadyExists, http.StatusConflict},
+	{serviceerrors.ErrInternal, http.StatusInternalServerError},
+}
+
+// errorMessages are the response bodies of mapped statuses.
+var errorMessages = map[int]string{
+	http.StatusRequestTimeout: "Request timeout",
+	http.StatusBadGateway:     "Users list is too large, use pagination",
+	http.StatusBadRequest:     "Invalid argument",
+	http.StatusForbidden:      "Forbidden",
+	http.StatusNotFound:       "User not found",
+}
+
+// statusForError returns the HTTP status for a service error, 500 if it matches no sentinel.
+func statusForError(err error) int {
+	for _, e := range errorStatuses {
+		if errors.Is(err, e.err) {
+			return e.status
+		}
+	}
+
+	return http.StatusInternalServerError
+}
+
+// writeError logs err and answers with its status. failMsg is the body of a 500 and
+// the message it is logged with.
+func writeError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error, failMsg string) {
+	status := statusForError(err)
+	switch status {
+	case http.StatusInternalServerError:
+		log.Error(failMsg, sl.Err(err))
+		handlers.Error(w, r, failMsg, status)
+	case http.StatusBadGateway:
+		log.Error(errorMessages[status], sl.Err(err))
+		handlers.Error(w, r, errorMessages[status], status)
+	case http.StatusServiceUnavailable:
+		log.Warn("Users backend unavailable", sl.Err(err))
+		writeUnavailable(w, r, err)
+	case http.StatusConflict:
+		log.Warn("Conflict", sl.Err(err))
+		writeConflict(w, r, log, err)
+	default:
+		log.Warn(errorMessages[status], sl.Err(err))
+		handlers.Error(w, r, errorMessages[status], status)
+	}
+}
@@ -0,0 +1,122 @@
+package usershandlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	serviceerrors "apigateway/internal/service"
+	"apigateway/pkg/lib/logger/handler/slogdiscard"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusForError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{serviceerrors.ErrNotFound, http.StatusNotFound},
+		{serviceerrors.ErrAlreadyExists, http.StatusConflict},
+		{serviceerrors.ErrDuplicateId, http.StatusConflict},
+		{serviceerrors.ErrDuplicateLogin, http.StatusConflict},
+		{serviceerrors.ErrInvalidArgument, http.StatusBadRequest},
+		{serviceerrors.ErrDeadlineExeeced, http.StatusRequestTimeout},
+		{serviceerrors.ErrContextCanceled, http.StatusRequestTimeout},
+		{serviceerrors.ErrInternal, http.StatusInternalServerError},
+		{serviceerrors.ErrForbidden, http.StatusForbidden},
+		{serviceerrors.ErrResourceExhausted, http.StatusBadGateway},
+		{serviceerrors.ErrUnavailable, http.StatusServiceUnavailable},
+		{&serviceerrors.UnavailableError{RetryAfter: time.Second}, http.StatusServiceUnavailable},
+		{errors.New("unknown"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			assert.Equal(t, tt.want, statusForError(tt.err))
+			assert.Equal(t, tt.want, statusForError(fmt.Errorf("service.users.Op: %w", tt.err)))
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	t.Run("fallback message on 500", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		writeError(w, httptest.NewRequest(http.MethodGet, "/users", nil), slogdiscard.NewDiscardLogger(), errors.New("boom"), "Failed to fetch users")
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "Failed to fetch users\n", w.Body.String())
+	})
+
+	t.Run("retry after on 503", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		writeError(w, httptest.NewRequest(http.MethodGet, "/users", nil), slogdiscard.NewDiscardLogger(), &serviceerrors.UnavailableError{RetryAfter: 2 * time.Second}, "Failed")
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("conflict names the field", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		writeError(w, httptest.NewRequest(http.MethodGet, "/users", nil), slogdiscard.NewDiscardLogger(), serviceerrors.ErrDuplicateLogin, "Failed")
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.JSONEq(t, `{"error":"User already exists","field":"login"}`, w.Body.String())
+	})
+}
+
+func TestErrorCatalog(t *testing.T) {
+	sentinels := []error{
+		serviceerrors.ErrNotFound,
+		serviceerrors.ErrAlreadyExists,
+		serviceerrors.ErrDuplicateId,
+		serviceerrors.ErrDuplicateLogin,
+		serviceerrors.ErrInvalidArgument,
+		serviceerrors.ErrDeadlineExeeced,
+		serviceerrors.ErrContextCanceled,
+		serviceerrors.ErrInternal,
+		serviceerrors.ErrForbidden,
+		serviceerrors.ErrResourceExhausted,
+		serviceerrors.ErrUnavailable,
+	}
+
+	t.Run("every sentinel has a code", func(t *testing.T) {
+		for _, err := range sentinels {
+			found := false
+			for _, e := range errorCodes {
+				if e.err == err {
+					found = true
+					assert.NotEmpty(t, e.code)
+					assert.NotEmpty(t, e.description)
+				}
+			}
+			assert.True(t, found, "no catalog entry for %q", err)
+		}
+	})
+
+	t.Run("codes are unique", func(t *testing.T) {
+		seen := map[string]bool{}
+		for _, e := range errorCodes {
+			assert.False(t, seen[e.code], "duplicate code %q", e.code)
+			seen[e.code] = true
+		}
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		u := New(slogdiscard.NewDiscardLogger(), nil)
+		w := httptest.NewRecorder()
+		u.ErrorCatalogHandler(w, httptest.NewRequest(http.MethodGet, "/api/v1/errors", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var got errorCatalog
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		assert.Len(t, got.Errors, len(sentinels))
+		assert.Contains(t, got.Errors, errorCatalogEntry{Code: "forbidden", Status: http.StatusForbidden, Description: "The caller is not allowed to perform the operation."})
+		assert.Contains(t, got.Errors, errorCatalogEntry{Code: "unavailable", Status: http.StatusServiceUnavailable, Description: "The users backend is temporarily unavailable; retry later."})
+	})
+}
@@ -0,0 +1,63 @@
+package usershandlers
+
+import (
+	"apigateway/internal/domain/models"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// FieldsParam is the query parameter selecting which user fields are returned,
+// e.g. `?fields=id,login`.
+const FieldsParam = "fields"
+
+// projectableFields maps the names accepted in FieldsParam to the response key
+// and value of a user. Sensitive fields are left out on purpose.
+var projectableFields = map[string]struct {
+	key   string
+	value func(models.User) any
+}{
+	"id":    {"Id", func(u models.User) any { return u.Id }},
+	"login": {"Login", func(u models.User) any { return u.Login }},
+	"role":  {"Role", func(u models.User) any { return u.Role }},
+}
+
+// parseFields returns the fields requested with FieldsParam, or nil if the
+// parameter is absent. An empty or unknown field name is an error.
+func parseFields(r *http.Request) ([]string, error) {
+	raw, ok := r.URL.Query()[FieldsParam]
+	if !ok {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, name := range strings.Split(strings.Join(raw, ","), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := projectableFields[name]; !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		fields = append(fields, name)
+	}
+
+	return fields, nil
+}
+
+// projectUser returns the requested fields of user keyed like the full user response.
+func projectUser(user models.User, fields []string) map[string]any {
+	projected := make(map[string]any, len(fields))
+	for _, name := range fields {
+		field := projectableFields[name]
+		projected[field.key] = field.value(user)
+	}
+
+	return projected
+}
+
+func projectUsers(users []models.User, fields []string) []map[string]any {
+	projected := make([]map[string]any, len(users))
+	for i, user := range users {
+		projected[i] = projectUser(user, fields)
+	}
+
+	return projected
+}
@@ -0,0 +1,241 @@
+package usershandlers
+
+import (
+	"apigateway/internal/domain/models"
+	"apigateway/internal/handlers"
+	serviceerrors "apigateway/internal/service"
+	"apigateway/pkg/lib/logger/sl"
+	"apigateway/pkg/lib/requestid"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// importColumns are the CSV columns every import must have. Other columns are rejected,
+// as users have no field to store them in.
+var importColumns = []string{"login", "password", "role"}
+
+// importRowResult reports the outcome of one CSV row; Row counts data rows from 1.
+type importRowResult struct {
+	Row   int    `json:"row"`
+	Id    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type importReport struct {
+	Imported int               `json:"imported"`
+	Failed   int               `json:"failed"`
+	Rows     []importRowResult `json:"rows"`
+}
+
+// ImportHandler creates users from a text/csv body with a header row.
+// By default every row is validated first and all users are then inserted in one
+// transaction, so a failing row leaves nothing inserted; the report names that row.
+// With ?continueOnError=true bad rows are reported and skipped and every other row is
+// inserted on its own, so rows inserted before a failure stay inserted.
+// A file with more data rows than the batch size limit is refused with 413.
+func (u *UsersHandler) ImportHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.users.ImportHandler"
+	log := u.log.With("op", op, "request_id", requestid.FromContext(r.Context()))
+	w.Header().Set("Cache-Control", "no-store")
+
+	select {
+	case <-r.Context().Done():
+		log.Info("Request cancelled", sl.Err(r.Context().Err()))
+		handlers.Error(w, r, "Request timeout", http.StatusRequestTimeout)
+		return
+	default:
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "text/csv" {
+		log.Warn("Unsupported content type", slog.String("content_type", r.Header.Get("Content-Type")))
+		handlers.Error(w, r, "Content-Type must be text/csv", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	continueOnError, _ := strconv.ParseBool(r.URL.Query().Get("continueOnError"))
+
+	users, report, err := parseImport(r.Body, u.maxBatchSize)
+	if errors.Is(err, errBatchTooLarge) {
+		log.Warn("Batch too large", sl.Err(err))
+		writeBatchTooLarge(w, r, u.maxBatchSize)
+		return
+	}
+	if err != nil {
+		log.Error("Failed to read CSV", sl.Err(err))
+		handlers.Error(w, r, "Failed to read CSV: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if report.Failed > 0 && !continueOnError {
+		log.Warn("Import rejected", slog.Int("failed", report.Failed))
+		writeImportReport(w, r, log, http.StatusBadRequest, report)
+		return
+	}
+
+	if continueOnError {
+		u.importRows(w, r, log, users, report)
+		return
+	}
+
+	batch := make([]models.User, len(report.Rows))
+	for i, row := range report.Rows {
+		batch[i] = users[row.Row]
+	}
+
+	insertedUsers, err := u.service.InsertBatch(r.Context(), batch)
+	if err != nil {
+		var batchErr *serviceerrors.BatchError
+		if !errors.As(err, &batchErr) || batchErr.Index < 0 || batchErr.Index >= len(report.Rows) {
+			writeError(w, r, log, err, "Failed to import users")
+			return
+		}
+
+		log.Warn("Import rolled back", sl.Err(err), slog.Int("row", report.Rows[batchErr.Index].Row))
+		report.Rows[batchErr.Index].Error = importInsertError(err)
+		report.Failed++
+		writeImportReport(w, r, log, http.StatusBadRequest, report)
+		return
+	}
+
+	for i := range report.Rows {
+		if i < len(insertedUsers) {
+			report.Rows[i].Id = insertedUsers[i].Id.String()
+		}
+	}
+	report.Imported = len(insertedUsers)
+
+	log.Info("Users imported", slog.Int("imported", report.Imported))
+	writeImportReport(w, r, log, http.StatusOK, report)
+}
+
+// importRows inserts the valid users one by one, reporting rows that fail and going on
+// with the next one. It stops only when the request times out.
+func (u *UsersHandler) importRows(w http.ResponseWriter, r *http.Request, log *slog.Logger, users map[int]models.User, report importReport) {
+	for i := range report.Rows {
+		row := &report.Rows[i]
+		user, ok := users[row.Row]
+		if !ok {
+			continue
+		}
+
+		insertedUser, err := u.service.Insert(r.Context(), user)
+		if err != nil {
+			if statusForError(err) == http.StatusRequestTimeout {
+				writeError(w, r, log, err, "Failed to import users")
+				return
+			}
+
+			log.Warn("Failed to import row", sl.Err(err), slog.Int("row", row.Row))
+			row.Error = importInsertError(err)
+			report.Failed++
+			continue
+		}
+
+		row.Id = insertedUser.Id.String()
+		report.Imported++
+	}
+
+	log.Info("Users imported", slog.Int("imported", report.Imported), slog.Int("failed", report.Failed))
+	writeImportReport(w, r, log, http.StatusOK, report)
+}
+
+// parseImport reads and validates every CSV row. It returns the valid users keyed by row
+// number and a report with an entry per row, carrying the error for invalid ones.
+// It stops with errBatchTooLarge at the first row past maxRows, unless maxRows is zero,
+// and with the read error if the body cannot be read.
+func parseImport(body io.Reader, maxRows int) (map[int]models.User, importReport, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, importReport{}, fmt.Errorf("header: %w", err)
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		column := strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(importColumns, column) {
+			return nil, importReport{}, fmt.Errorf("unsupported column %q", column)
+		}
+		index[column] = i
+	}
+	for _, column := range importColumns {
+		if _, ok := index[column]; !ok {
+			return nil, importReport{}, fmt.Errorf("missing column %q", column)
+		}
+	}
+
+	validate := validator.New()
+	users := make(map[int]models.User)
+	report := importReport{Rows: []importRowResult{}}
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		// Only malformed rows are reported per row. Any other error comes from the
+		// body and would be returned again by every further Read.
+		var parseErr *csv.ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			return nil, importReport{}, fmt.Errorf("row %d: %w", row, err)
+		}
+		if maxRows > 0 && row > maxRows {
+			return nil, importReport{}, fmt.Errorf("more than %d rows: %w", maxRows, errBatchTooLarge)
+		}
+
+		result := importRowResult{Row: row}
+		switch {
+		case err != nil:
+			result.Error = err.Error()
+		case len(record) != len(header):
+			result.Error = fmt.Sprintf("expected %d fields, got %d", len(header), len(record))
+		default:
+			user := models.User{
+				Id:       uuid.New(),
+				Login:    record[index["login"]],
+				Password: record[index["password"]],
+				Role:     record[index["role"]],
+			}
+			if err := validate.Struct(user); err != nil {
+				result.Error = "invalid user"
+			} else {
+				users[row] = user
+			}
+		}
+
+		if result.Error != "" {
+			report.Failed++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	return users, report, nil
+}
+
+func importInsertError(err error) string {
+	switch {
+	case errors.Is(err, serviceerrors.ErrAlreadyExists):
+		return "user already exists"
+	case errors.Is(err, serviceerrors.ErrInvalidArgument):
+		return "invalid user"
+	default:
+		return "failed to insert user"
+	}
+}
+
+func writeImportReport(w http.ResponseWriter, r *http.Request, log *slog.Logger, status int, report importReport) {
+	writeJSON(w, r, log, status, report)
+}
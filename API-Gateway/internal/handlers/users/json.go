@@ -0,0 +1,36 @@
+package usershandlers
+
+import (
+	"apigateway/pkg/lib/logger/sl"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// PrettyParam is the query parameter indenting JSON responses for reading by hand,
+// e.g. `?pretty=true`. Responses are compact by default.
+const PrettyParam = "pretty"
+
+// writeJSON encodes body before touching the response, so an encoding
+// failure can still be reported as a clean 500 instead of a truncated body
+// behind an already-sent success status.
+func writeJSON(w http.ResponseWriter, r *http.Request, log *slog.Logger, status int, body any) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if pretty, _ := strconv.ParseBool(r.URL.Query().Get(PrettyParam)); pretty {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(body); err != nil {
+		log.Error("Failed to encode response", sl.Err(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Error("Failed to write response", sl.Err(err))
+	}
+}
@@ -0,0 +1,43 @@
+package usershandlers
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"apigateway/pkg/lib/logger/handler/slogdiscard"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteJSON(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		writeJSON(rr, req, slogdiscard.NewDiscardLogger(), http.StatusCreated, map[string]string{"login": "alice"})
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		assert.Equal(t, `{"login":"alice"}`+"\n", rr.Body.String())
+	})
+
+	t.Run("Pretty", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/?pretty=true", nil)
+		writeJSON(rr, req, slogdiscard.NewDiscardLogger(), http.StatusOK, map[string]any{"login": "alice", "roles": []string{"user"}})
+
+		assert.Equal(t, "{\n  \"login\": \"alice\",\n  \"roles\": [\n    \"user\"\n  ]\n}\n", rr.Body.String())
+	})
+
+	t.Run("EncodeFailure", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		writeJSON(rr, req, slogdiscard.NewDiscardLogger(), http.StatusOK, map[string]float64{"value": math.Inf(1)})
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.NotEqual(t, "application/json", rr.Header().Get("Content-Type"))
+		assert.Equal(t, "Failed to encode response", strings.TrimSpace(rr.Body.String()))
+	})
+}
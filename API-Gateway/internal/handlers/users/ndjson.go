@@ -0,0 +1,36 @@
+package usershandlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// FormatNDJSON is the `format` query value selecting newline-delimited JSON output.
+const FormatNDJSON = "ndjson"
+
+// writeNDJSON writes one JSON item per line, flushing after each one.
+// It stops early if the request context is done and returns the context error.
+func writeNDJSON[T any](w http.ResponseWriter, r *http.Request, items []T) error {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	for _, item := range items {
+		select {
+		case <-r.Context().Done():
+			return r.Context().Err()
+		default:
+		}
+
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	return nil
+}
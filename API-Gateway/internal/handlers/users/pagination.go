@@ -0,0 +1,74 @@
+package usershandlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Query parameters selecting a page of a list, e.g. `?limit=50&offset=100`.
+const (
+	LimitParam  = "limit"
+	OffsetParam = "offset"
+)
+
+// page is the part of a list a request asked for, after applying the handler limits.
+type page struct {
+	limit   int
+	offset  int
+	clamped bool
+}
+
+// parsePage reads LimitParam and OffsetParam. A missing limit falls back to
+// defaultLimit; a limit above maxLimit is clamped to it rather than rejected.
+// Zero for either bound means no limit.
+func parsePage(r *http.Request, defaultLimit, maxLimit int) (page, error) {
+	query := r.URL.Query()
+
+	p := page{limit: defaultLimit}
+	if raw := query.Get(LimitParam); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return page{}, fmt.Errorf("%s must be a positive integer", LimitParam)
+		}
+		p.limit = limit
+	}
+
+	if raw := query.Get(OffsetParam); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return page{}, fmt.Errorf("%s must be a non-negative integer", OffsetParam)
+		}
+		p.offset = offset
+	}
+
+	if maxLimit > 0 && (p.limit == 0 || p.limit > maxLimit) {
+		p.clamped = p.limit > maxLimit
+		p.limit = maxLimit
+	}
+
+	return p, nil
+}
+
+// applyPage returns the items of items on page p.
+func applyPage[T any](items []T, p page) []T {
+	if p.offset >= len(items) {
+		return items[:0]
+	}
+	items = items[p.offset:]
+
+	if p.limit > 0 && p.limit < len(items) {
+		items = items[:p.limit]
+	}
+
+	return items
+}
+
+// setClampedWarning tells the client it got fewer items than it asked for.
+func setClampedWarning(w http.ResponseWriter, p page) {
+	if !p.clamped {
+		return
+	}
+
+	w.Header().Set("Warning", fmt.Sprintf(`299 - "%s clamped to %d"`, LimitParam, p.limit))
+}
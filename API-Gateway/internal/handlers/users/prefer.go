@@ -0,0 +1,23 @@
+package usershandlers
+
+import (
+	"net/http"
+	"strings"
+)
+
+// PreferReturnMinimal is the RFC 7240 preference asking writes not to echo the resource back.
+const PreferReturnMinimal = "return=minimal"
+
+// wantsMinimal reports whether the request carries `Prefer: return=minimal`.
+func wantsMinimal(r *http.Request) bool {
+	for _, header := range r.Header.Values("Prefer") {
+		for _, pref := range strings.Split(header, ",") {
+			token, _, _ := strings.Cut(strings.TrimSpace(pref), ";")
+			if strings.EqualFold(strings.ReplaceAll(token, " ", ""), PreferReturnMinimal) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,32 @@
+package usershandlers
+
+import (
+	"apigateway/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+// userResponse is a user as sent to clients. It has the keys of projectableFields
+// and never the password.
+type userResponse struct {
+	Id    uuid.UUID
+	Login string
+	Role  string
+}
+
+func toUserResponse(user models.User) userResponse {
+	return userResponse{
+		Id:    user.Id,
+		Login: user.Login,
+		Role:  user.Role,
+	}
+}
+
+func toUserResponses(users []models.User) []userResponse {
+	responses := make([]userResponse, len(users))
+	for i, user := range users {
+		responses[i] = toUserResponse(user)
+	}
+
+	return responses
+}
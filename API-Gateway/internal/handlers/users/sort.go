@@ -0,0 +1,71 @@
+package usershandlers
+
+import (
+	"apigateway/internal/domain/models"
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SortParam is the query parameter ordering list responses, e.g. `?sort=role,-login`.
+// A leading "-" sorts that field in descending order.
+const SortParam = "sort"
+
+// sortableFields compares users by the fields accepted in SortParam.
+var sortableFields = map[string]func(a, b models.User) int{
+	"id":    func(a, b models.User) int { return bytes.Compare(a.Id[:], b.Id[:]) },
+	"login": func(a, b models.User) int { return strings.Compare(a.Login, b.Login) },
+	"role":  func(a, b models.User) int { return strings.Compare(a.Role, b.Role) },
+}
+
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+// parseSort parses a sort spec. The id is always appended as the final key, so
+// users tied on every requested field still have a total order and pages do
+// not skip or repeat rows. An empty spec sorts by id only.
+func parseSort(spec string) ([]sortKey, error) {
+	var keys []sortKey
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" && spec == "" {
+			break
+		}
+
+		key := sortKey{field: strings.ToLower(part)}
+		if rest, ok := strings.CutPrefix(key.field, "-"); ok {
+			key.field, key.desc = rest, true
+		}
+		if _, ok := sortableFields[key.field]; !ok {
+			return nil, fmt.Errorf("unknown sort field %q", part)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if !slices.ContainsFunc(keys, func(k sortKey) bool { return k.field == "id" }) {
+		keys = append(keys, sortKey{field: "id"})
+	}
+
+	return keys, nil
+}
+
+// sortUsers orders users in place by keys.
+func sortUsers(users []models.User, keys []sortKey) {
+	slices.SortFunc(users, func(a, b models.User) int {
+		for _, key := range keys {
+			c := sortableFields[key.field](a, b)
+			if key.desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+
+		return 0
+	})
+}
@@ -0,0 +1,21 @@
+package usershandlers
+
+import (
+	"apigateway/internal/handlers"
+	serviceerrors "apigateway/internal/service"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// writeUnavailable answers 503 when the users backend is unavailable, with a
+// Retry-After header if the backend suggested when to retry.
+func writeUnavailable(w http.ResponseWriter, r *http.Request, err error) {
+	var unavailable *serviceerrors.UnavailableError
+	if errors.As(err, &unavailable) && unavailable.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(unavailable.RetryAfter.Seconds()))))
+	}
+
+	handlers.Error(w, r, "Users backend is unavailable", http.StatusServiceUnavailable)
+}
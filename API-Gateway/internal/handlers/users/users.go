@@ -2,13 +2,16 @@ package usershandlers
 
 import (
 	"apigateway/internal/domain/models"
-	serviceerrors "apigateway/internal/service"
+	"apigateway/internal/handlers"
 	"apigateway/pkg/lib/logger/sl"
+	"apigateway/pkg/lib/requestid"
 	"context"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
@@ -18,7 +21,10 @@ import (
 type IUsersService interface {
 	GetUsers(ctx context.Context) ([]models.User, error)
 	GetUserById(ctx context.Context, uid uuid.UUID) (models.User, error)
+	GetUserByLogin(ctx context.Context, login string) (models.User, error)
+	GetUsersByRole(ctx context.Context, role string) ([]models.User, error)
 	Insert(ctx context.Context, user models.User) (models.User, error)
+	InsertBatch(ctx context.Context, users []models.User) ([]models.User, error)
 	Update(ctx context.Context, uid uuid.UUID, user models.User) (models.User, error)
 	Delete(ctx context.Context, uid uuid.UUID) (models.User, error)
 }
@@ -26,59 +32,273 @@ type IUsersService interface {
 type UsersHandler struct {
 	log     *slog.Logger
 	service IUsersService
+
+	listEnvelope bool
+	userMaxAge   time.Duration
+	basePath     string
+	defaultLimit int
+	maxLimit     int
+	defaultSort  []sortKey
+	maxBatchSize int
+}
+
+// Option configures optional UsersHandler behavior.
+type Option func(*UsersHandler)
+
+// WithListEnvelope makes list endpoints wrap results in a {"data":...,"meta":...} envelope by default.
+// Clients can also opt in per request with the envelope Accept profile.
+func WithListEnvelope(enabled bool) Option {
+	return func(u *UsersHandler) {
+		u.listEnvelope = enabled
+	}
+}
+
+// WithUserMaxAge sets the max-age of the private Cache-Control header on successful user fetches.
+// Zero disables caching of user fetches.
+func WithUserMaxAge(maxAge time.Duration) Option {
+	return func(u *UsersHandler) {
+		u.userMaxAge = maxAge
+	}
+}
+
+// WithBasePath sets the path prefix the users routes are mounted under, used to build Location headers.
+func WithBasePath(basePath string) Option {
+	return func(u *UsersHandler) {
+		u.basePath = basePath
+	}
+}
+
+// WithPageLimits sets the page size of list endpoints when the client sends no limit,
+// and the hard cap on the limit a client may ask for. Zero disables either.
+func WithPageLimits(defaultLimit, maxLimit int) Option {
+	return func(u *UsersHandler) {
+		u.defaultLimit = defaultLimit
+		u.maxLimit = maxLimit
+	}
+}
+
+// WithDefaultSort sets the order of list responses when the client sends no sort.
+// Panics if spec is not a valid sort spec.
+func WithDefaultSort(spec string) Option {
+	keys, err := parseSort(spec)
+	if err != nil {
+		panic(fmt.Sprintf("default sort: %s", err))
+	}
+
+	return func(u *UsersHandler) {
+		u.defaultSort = keys
+	}
+}
+
+// WithMaxBatchSize sets the most items a bulk endpoint accepts in one request.
+// Zero disables the limit.
+func WithMaxBatchSize(n int) Option {
+	return func(u *UsersHandler) {
+		u.maxBatchSize = n
+	}
+}
+
+func New(log *slog.Logger, service IUsersService, opts ...Option) *UsersHandler {
+	u := &UsersHandler{
+		log:          log,
+		service:      service,
+		maxBatchSize: DefaultMaxBatchSize,
+	}
+
+	for _, opt := range opts {
+		opt(u)
+	}
+
+	return u
 }
 
-func New(log *slog.Logger, service IUsersService) *UsersHandler {
-	return &UsersHandler{
-		log:     log,
-		service: service,
+// sortKeys returns the order requested with SortParam, or the default order.
+func (u *UsersHandler) sortKeys(r *http.Request) ([]sortKey, error) {
+	spec := r.URL.Query().Get(SortParam)
+	if spec == "" && u.defaultSort != nil {
+		return u.defaultSort, nil
 	}
+
+	return parseSort(spec)
 }
 
 func (u *UsersHandler) GetUsersHandler(w http.ResponseWriter, r *http.Request) {
 	const op = "handlers.users.GetUsersHandler"
-	log := u.log.With("op", op)
+	log := u.log.With("op", op, "request_id", requestid.FromContext(r.Context()))
 
 	select {
 	case <-r.Context().Done():
 		log.Info("Request cancelled", sl.Err(r.Context().Err()))
-		http.Error(w, "Request timeout", http.StatusRequestTimeout)
+		handlers.Error(w, r, "Request timeout", http.StatusRequestTimeout)
 		return
 	default:
 	}
 
+	produces := "application/json"
+	if r.URL.Query().Get("format") == FormatNDJSON {
+		produces = "application/x-ndjson"
+	}
+	if !handlers.Accepts(r, produces) {
+		log.Warn("Not acceptable", slog.String("accept", r.Header.Get("Accept")))
+		handlers.Error(w, r, "Not acceptable", http.StatusNotAcceptable)
+		return
+	}
+
+	fields, err := parseFields(r)
+	if err != nil {
+		log.Warn("Invalid fields", sl.Err(err))
+		handlers.Error(w, r, "Invalid fields: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pg, err := parsePage(r, u.defaultLimit, u.maxLimit)
+	if err != nil {
+		log.Warn("Invalid page", sl.Err(err))
+		handlers.Error(w, r, "Invalid page: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	order, err := u.sortKeys(r)
+	if err != nil {
+		log.Warn("Invalid sort", sl.Err(err))
+		handlers.Error(w, r, "Invalid sort: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	users, err := u.service.GetUsers(r.Context())
 	if err != nil {
-		switch {
-		case errors.Is(err, serviceerrors.ErrContextCanceled):
-			log.Warn("Context cancelled", sl.Err(err))
-			http.Error(w, "Request timeout", http.StatusRequestTimeout)
-			return
-		default:
-			log.Error("Failed to fetch users", sl.Err(err))
-			http.Error(w, "Failed to fetch users", http.StatusInternalServerError)
-			return
-		}
+		writeError(w, r, log, err, "Failed to fetch users")
+		return
 	}
 
 	log.Info("Users fetched successfully", slog.Int("count", len(users)))
 
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(users); err != nil {
-		log.Error("Failed to encode users", sl.Err(err))
-		http.Error(w, "Failed to encode users", http.StatusInternalServerError)
+	// Clients expect an empty array, never null, when there are no users.
+	if users == nil {
+		users = []models.User{}
+	}
+
+	sortUsers(users, order)
+	paged := applyPage(users, pg)
+	setClampedWarning(w, pg)
+
+	if r.URL.Query().Get("format") == FormatNDJSON {
+		if fields != nil {
+			err = writeNDJSON(w, r, projectUsers(paged, fields))
+		} else {
+			err = writeNDJSON(w, r, toUserResponses(paged))
+		}
+		if err != nil {
+			log.Warn("Stopped streaming users", sl.Err(err))
+		}
+		return
+	}
+
+	var data any = toUserResponses(paged)
+	if fields != nil {
+		data = projectUsers(paged, fields)
+	}
+
+	// Stats need an object to sit in, so asking for them implies the envelope.
+	withStats, _ := strconv.ParseBool(r.URL.Query().Get(WithStatsParam))
+
+	var body any = data
+	if u.listEnvelope || wantsEnvelope(r) || withStats {
+		envelope := listEnvelope{
+			Data: data,
+			Meta: listMeta{Total: len(users), Limit: pg.limit, Offset: pg.offset},
+		}
+		if withStats {
+			envelope.Stats = statsFor(users)
+		}
+		body = envelope
+	}
+
+	writeJSON(w, r, log, http.StatusOK, body)
+}
+
+func (u *UsersHandler) GetUsersByRoleHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.users.GetUsersByRoleHandler"
+	log := u.log.With("op", op, "request_id", requestid.FromContext(r.Context()))
+
+	select {
+	case <-r.Context().Done():
+		log.Info("Request cancelled", sl.Err(r.Context().Err()))
+		handlers.Error(w, r, "Request timeout", http.StatusRequestTimeout)
+		return
+	default:
+	}
+
+	if !handlers.Accepts(r, "application/json") {
+		log.Warn("Not acceptable", slog.String("accept", r.Header.Get("Accept")))
+		handlers.Error(w, r, "Not acceptable", http.StatusNotAcceptable)
+		return
+	}
+
+	fields, err := parseFields(r)
+	if err != nil {
+		log.Warn("Invalid fields", sl.Err(err))
+		handlers.Error(w, r, "Invalid fields: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pg, err := parsePage(r, u.defaultLimit, u.maxLimit)
+	if err != nil {
+		log.Warn("Invalid page", sl.Err(err))
+		handlers.Error(w, r, "Invalid page: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	order, err := u.sortKeys(r)
+	if err != nil {
+		log.Warn("Invalid sort", sl.Err(err))
+		handlers.Error(w, r, "Invalid sort: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	role := mux.Vars(r)["role"]
+	users, err := u.service.GetUsersByRole(r.Context(), role)
+	if err != nil {
+		writeError(w, r, log.With(slog.String("role", role)), err, "Failed to fetch users")
 		return
 	}
+
+	log.Info("Users fetched successfully", slog.String("role", role), slog.Int("count", len(users)))
+
+	// Clients expect an empty array, never null, when there are no users.
+	if users == nil {
+		users = []models.User{}
+	}
+
+	sortUsers(users, order)
+	paged := applyPage(users, pg)
+	setClampedWarning(w, pg)
+
+	var data any = toUserResponses(paged)
+	if fields != nil {
+		data = projectUsers(paged, fields)
+	}
+
+	var body any = data
+	if u.listEnvelope || wantsEnvelope(r) {
+		body = listEnvelope{
+			Data: data,
+			Meta: listMeta{Total: len(users), Limit: pg.limit, Offset: pg.offset},
+		}
+	}
+
+	writeJSON(w, r, log, http.StatusOK, body)
 }
 
 func (u *UsersHandler) GetUserByIdHandler(w http.ResponseWriter, r *http.Request) {
 	const op = "handlers.users.GetUserByIdHandler"
-	log := u.log.With("op", op)
+	log := u.log.With("op", op, "request_id", requestid.FromContext(r.Context()))
+	w.Header().Set("Cache-Control", "no-store")
 
 	select {
 	case <-r.Context().Done():
 		log.Info("Request cancelled", sl.Err(r.Context().Err()))
-		http.Error(w, "Request timeout", http.StatusRequestTimeout)
+		handlers.Error(w, r, "Request timeout", http.StatusRequestTimeout)
 		return
 	default:
 	}
@@ -86,108 +306,103 @@ func (u *UsersHandler) GetUserByIdHandler(w http.ResponseWriter, r *http.Request
 	uid, err := uuid.Parse(mux.Vars(r)["id"])
 	if err != nil {
 		log.Error("Invalid user ID", sl.Err(err))
-		http.Error(w, "Invalid id", http.StatusBadRequest)
+		handlers.Error(w, r, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	if !handlers.Accepts(r, "application/json") {
+		log.Warn("Not acceptable", slog.String("accept", r.Header.Get("Accept")))
+		handlers.Error(w, r, "Not acceptable", http.StatusNotAcceptable)
+		return
+	}
+
+	fields, err := parseFields(r)
+	if err != nil {
+		log.Warn("Invalid fields", sl.Err(err))
+		handlers.Error(w, r, "Invalid fields: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	user, err := u.service.GetUserById(r.Context(), uid)
 	if err != nil {
-		switch {
-		case errors.Is(err, serviceerrors.ErrContextCanceled):
-			log.Warn("Request cancelled", sl.Err(err))
-			http.Error(w, "Request timeout", http.StatusRequestTimeout)
-			return
-		case errors.Is(err, serviceerrors.ErrInvalidArgument):
-			log.Warn("Invalid argument", sl.Err(err))
-			http.Error(w, "Invalid argument", http.StatusBadRequest)
-			return
-		case errors.Is(err, serviceerrors.ErrNotFound):
-			log.Warn("User not found", sl.Err(err), slog.String("user_id", uid.String()))
-			http.Error(w, "User not found", http.StatusNotFound)
-			return
-		default:
-			log.Error("Failed to fetch user by id", sl.Err(err), slog.String("user_id", uid.String()))
-			http.Error(w, "Failed to fetch user by id", http.StatusInternalServerError)
-			return
-		}
+		writeError(w, r, log.With(slog.String("user_id", uid.String())), err, "Failed to fetch user by id")
+		return
 	}
 
 	log.Info("User fetched successfully", slog.String("user_id", user.Id.String()))
 
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(user); err != nil {
-		log.Error("Failed to encode user", sl.Err(err))
-		http.Error(w, "Failed to encode user", http.StatusInternalServerError)
+	if u.userMaxAge > 0 {
+		w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(int(u.userMaxAge.Seconds())))
+	}
+
+	if fields != nil {
+		writeJSON(w, r, log, http.StatusOK, projectUser(user, fields))
 		return
 	}
+
+	writeJSON(w, r, log, http.StatusOK, toUserResponse(user))
 }
 
 func (u *UsersHandler) InsertHandler(w http.ResponseWriter, r *http.Request) {
 	const op = "handlers.users.InsertHandler"
-	log := u.log.With("op", op)
+	log := u.log.With("op", op, "request_id", requestid.FromContext(r.Context()))
+	w.Header().Set("Cache-Control", "no-store")
 
 	select {
 	case <-r.Context().Done():
 		log.Info("Request cancelled", sl.Err(r.Context().Err()))
-		http.Error(w, "Request timeout", http.StatusRequestTimeout)
+		handlers.Error(w, r, "Request timeout", http.StatusRequestTimeout)
 		return
 	default:
 	}
 
 	validate := validator.New()
-	var userFromRequest models.User
-	if err := json.NewDecoder(r.Body).Decode(&userFromRequest); err != nil {
+	userFromRequest, err := decodeUser(r.Body)
+	if errors.Is(err, errNotObject) {
+		log.Warn("Request body is not an object", sl.Err(err))
+		handlers.Error(w, r, "Request body must be a JSON object", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
 		log.Error("Failed to read request body", sl.Err(err))
-		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		handlers.Error(w, r, "Failed to read request body", http.StatusBadRequest)
 		return
 	}
 
 	if err := validate.Struct(userFromRequest); err != nil {
 		log.Error("Failed to validate requested user", sl.Err(err))
-		http.Error(w, "Failed to validate user", http.StatusBadRequest)
+		handlers.Error(w, r, "Failed to validate user", http.StatusBadRequest)
 		return
 	}
 
 	insertedUser, err := u.service.Insert(r.Context(), userFromRequest)
 	if err != nil {
-		switch {
-		case errors.Is(err, serviceerrors.ErrContextCanceled):
-			log.Warn("Request cancelled", sl.Err(err))
-			http.Error(w, "Request timeout", http.StatusRequestTimeout)
-			return
-		case errors.Is(err, serviceerrors.ErrInvalidArgument):
-			log.Warn("Invalid argument", sl.Err(err))
-			http.Error(w, "Invalid argument", http.StatusBadRequest)
-			return
-		case errors.Is(err, serviceerrors.ErrAlreadyExists):
-			log.Warn("User already exists", sl.Err(err))
-			http.Error(w, "User already exists", http.StatusConflict)
-			return
-		default:
-			log.Error("Failed to insert user", sl.Err(err))
-			http.Error(w, "Failed to insert user", http.StatusInternalServerError)
-			return
-		}
+		writeError(w, r, log, err, "Failed to insert user")
+		return
 	}
 
 	log.Info("User inserted successfully", slog.String("user_id", insertedUser.Id.String()))
 
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(insertedUser); err != nil {
-		log.Error("Failed to encode user", sl.Err(err))
-		http.Error(w, "Failed to encode user", http.StatusInternalServerError)
+	handlers.SetLocation(w, u.basePath, "users", insertedUser.Id)
+
+	if wantsMinimal(r) {
+		w.Header().Set("Preference-Applied", PreferReturnMinimal)
+		w.WriteHeader(http.StatusCreated)
 		return
 	}
+
+	writeJSON(w, r, log, http.StatusCreated, toUserResponse(insertedUser))
 }
 
 func (u *UsersHandler) UpdateHandler(w http.ResponseWriter, r *http.Request) {
 	const op = "handlers.users.UpdateHandler"
-	log := u.log.With("op", op)
+	log := u.log.With("op", op, "request_id", requestid.FromContext(r.Context()))
+	w.Header().Set("Cache-Control", "no-store")
 
 	select {
 	case <-r.Context().Done():
 		log.Info("Request cancelled", sl.Err(r.Context().Err()))
-		http.Error(w, "Request timeout", http.StatusRequestTimeout)
+		handlers.Error(w, r, "Request timeout", http.StatusRequestTimeout)
 		return
 	default:
 	}
@@ -195,64 +410,55 @@ func (u *UsersHandler) UpdateHandler(w http.ResponseWriter, r *http.Request) {
 	uid, err := uuid.Parse(mux.Vars(r)["id"])
 	if err != nil {
 		log.Error("Invalid user ID", sl.Err(err))
-		http.Error(w, "Invalid id", http.StatusBadRequest)
+		handlers.Error(w, r, "Invalid id", http.StatusBadRequest)
 		return
 	}
 
 	validate := validator.New()
-	var userFromRequest models.User
-	if err := json.NewDecoder(r.Body).Decode(&userFromRequest); err != nil {
+	userFromRequest, err := decodeUser(r.Body)
+	if errors.Is(err, errNotObject) {
+		log.Warn("Request body is not an object", sl.Err(err))
+		handlers.Error(w, r, "Request body must be a JSON object", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
 		log.Error("Failed to read request body", sl.Err(err))
-		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		handlers.Error(w, r, "Failed to read request body", http.StatusBadRequest)
 		return
 	}
 
 	if err := validate.Struct(userFromRequest); err != nil {
 		log.Error("Failed to validate requested user", sl.Err(err))
-		http.Error(w, "Failed to validate user", http.StatusBadRequest)
+		handlers.Error(w, r, "Failed to validate user", http.StatusBadRequest)
 		return
 	}
 
 	updatedUser, err := u.service.Update(r.Context(), uid, userFromRequest)
 	if err != nil {
-		switch {
-		case errors.Is(err, serviceerrors.ErrContextCanceled):
-			log.Warn("Request cancelled", sl.Err(err))
-			http.Error(w, "Request timeout", http.StatusRequestTimeout)
-			return
-		case errors.Is(err, serviceerrors.ErrInvalidArgument):
-			log.Warn("Invalid argument", sl.Err(err))
-			http.Error(w, "Invalid argument", http.StatusBadRequest)
-			return
-		case errors.Is(err, serviceerrors.ErrNotFound):
-			log.Warn("User not found", sl.Err(err), slog.String("user_id", uid.String()))
-			http.Error(w, "User not found", http.StatusNotFound)
-			return
-		default:
-			log.Error("Failed to update user", sl.Err(err), slog.String("user_id", uid.String()))
-			http.Error(w, "Failed to update user", http.StatusInternalServerError)
-			return
-		}
+		writeError(w, r, log.With(slog.String("user_id", uid.String())), err, "Failed to update user")
+		return
 	}
 
 	log.Info("User updated successfully", slog.String("user_id", updatedUser.Id.String()))
 
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(updatedUser); err != nil {
-		log.Error("Failed to encode user", sl.Err(err))
-		http.Error(w, "Failed to encode user", http.StatusInternalServerError)
+	if wantsMinimal(r) {
+		w.Header().Set("Preference-Applied", PreferReturnMinimal)
+		w.WriteHeader(http.StatusNoContent)
 		return
 	}
+
+	writeJSON(w, r, log, http.StatusOK, toUserResponse(updatedUser))
 }
 
 func (u *UsersHandler) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 	const op = "handlers.users.DeleteHandler"
-	log := u.log.With("op", op)
+	log := u.log.With("op", op, "request_id", requestid.FromContext(r.Context()))
+	w.Header().Set("Cache-Control", "no-store")
 
 	select {
 	case <-r.Context().Done():
 		log.Info("Request cancelled", sl.Err(r.Context().Err()))
-		http.Error(w, "Request timeout", http.StatusRequestTimeout)
+		handlers.Error(w, r, "Request timeout", http.StatusRequestTimeout)
 		return
 	default:
 	}
@@ -260,38 +466,17 @@ func (u *UsersHandler) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 	uid, err := uuid.Parse(mux.Vars(r)["id"])
 	if err != nil {
 		log.Error("Invalid user ID", sl.Err(err))
-		http.Error(w, "Invalid id", http.StatusBadRequest)
+		handlers.Error(w, r, "Invalid id", http.StatusBadRequest)
 		return
 	}
 
 	deletedUser, err := u.service.Delete(r.Context(), uid)
 	if err != nil {
-		switch {
-		case errors.Is(err, serviceerrors.ErrContextCanceled):
-			log.Warn("Request cancelled", sl.Err(err))
-			http.Error(w, "Request timeout", http.StatusRequestTimeout)
-			return
-		case errors.Is(err, serviceerrors.ErrInvalidArgument):
-			log.Warn("Invalid argument", sl.Err(err))
-			http.Error(w, "Invalid argument", http.StatusBadRequest)
-			return
-		case errors.Is(err, serviceerrors.ErrNotFound):
-			log.Warn("User not found", sl.Err(err), slog.String("user_id", uid.String()))
-			http.Error(w, "User not found", http.StatusNotFound)
-			return
-		default:
-			log.Error("Failed to delete user", sl.Err(err), slog.String("user_id", uid.String()))
-			http.Error(w, "Failed to delete user", http.StatusInternalServerError)
-			return
-		}
+		writeError(w, r, log.With(slog.String("user_id", uid.String())), err, "Failed to delete user")
+		return
 	}
 
 	log.Info("User deleted successfully", slog.String("user_id", deletedUser.Id.String()))
 
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(deletedUser); err != nil {
-		log.Error("Failed to encode user", sl.Err(err))
-		http.Error(w, "Failed to encode user", http.StatusInternalServerError)
-		return
-	}
+	writeJSON(w, r, log, http.StatusOK, toUserResponse(deletedUser))
 }
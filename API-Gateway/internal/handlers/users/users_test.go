@@ -1,19 +1,27 @@
 package usershandlers_test
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"apigateway/internal/domain/models"
+	"apigateway/internal/handlers"
 	usershandlers "apigateway/internal/handlers/users"
 	serviceerrors "apigateway/internal/service"
+	usersservice "apigateway/internal/service/users"
 	"apigateway/pkg/lib/logger/handler/slogdiscard"
 
 	"github.com/google/uuid"
@@ -40,11 +48,32 @@ func (m *mockUsersService) GetUserById(ctx context.Context, uid uuid.UUID) (mode
 	return args.Get(0).(models.User), args.Error(1)
 }
 
+func (m *mockUsersService) GetUserByLogin(ctx context.Context, login string) (models.User, error) {
+	args := m.Called(ctx, login)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
+func (m *mockUsersService) GetUsersByRole(ctx context.Context, role string) ([]models.User, error) {
+	args := m.Called(ctx, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
 func (m *mockUsersService) Insert(ctx context.Context, user models.User) (models.User, error) {
 	args := m.Called(ctx, user)
 	return args.Get(0).(models.User), args.Error(1)
 }
 
+func (m *mockUsersService) InsertBatch(ctx context.Context, users []models.User) ([]models.User, error) {
+	args := m.Called(ctx, users)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
 func (m *mockUsersService) Update(ctx context.Context, uid uuid.UUID, user models.User) (models.User, error) {
 	args := m.Called(ctx, uid, user)
 	return args.Get(0).(models.User), args.Error(1)
@@ -100,6 +129,19 @@ func TestUsersHandler_GetUsersHandler(t *testing.T) {
 		service.AssertExpectations(t)
 	})
 
+	t.Run("deadline exceeded error", func(t *testing.T) {
+		service.On("GetUsers", mock.Anything).Return(nil, serviceerrors.ErrDeadlineExeeced).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		w := httptest.NewRecorder()
+
+		handler.GetUsersHandler(w, req)
+
+		resp := w.Result()
+		assert.Equal(t, http.StatusRequestTimeout, resp.StatusCode)
+		service.AssertExpectations(t)
+	})
+
 	t.Run("other error", func(t *testing.T) {
 		service.On("GetUsers", mock.Anything).Return(nil, errors.New("some error")).Once()
 
@@ -114,6 +156,100 @@ func TestUsersHandler_GetUsersHandler(t *testing.T) {
 	})
 }
 
+func TestUsersHandler_EmptyList(t *testing.T) {
+	for name, users := range map[string][]models.User{"nil": nil, "empty": {}} {
+		t.Run(name, func(t *testing.T) {
+			handler, service := newTestHandler(t)
+			service.On("GetUsers", mock.Anything).Return(users, nil).Once()
+			service.On("GetUsersByRole", mock.Anything, "admin").Return(users, nil).Once()
+
+			w := httptest.NewRecorder()
+			handler.GetUsersHandler(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.JSONEq(t, `[]`, w.Body.String())
+
+			req := httptest.NewRequest(http.MethodGet, "/users/by-role/admin", nil)
+			req = mux.SetURLVars(req, map[string]string{"role": "admin"})
+			w = httptest.NewRecorder()
+			handler.GetUsersByRoleHandler(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.JSONEq(t, `[]`, w.Body.String())
+		})
+	}
+
+	t.Run("envelope", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		service.On("GetUsers", mock.Anything).Return(nil, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set("Accept", `application/json; profile="envelope"`)
+		w := httptest.NewRecorder()
+		handler.GetUsersHandler(w, req)
+
+		assert.JSONEq(t, `{"data":[],"meta":{"total":0}}`, w.Body.String())
+	})
+}
+
+func TestUsersHandler_GetUsersHandler_Envelope(t *testing.T) {
+	users := []models.User{
+		{Id: uuid.New(), Login: "user1"},
+		{Id: uuid.New(), Login: "user2"},
+	}
+
+	type envelope struct {
+		Data []models.User `json:"data"`
+		Meta struct {
+			Total int `json:"total"`
+		} `json:"meta"`
+	}
+
+	t.Run("bare array by default", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		service.On("GetUsers", mock.Anything).Return(users, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		w := httptest.NewRecorder()
+
+		handler.GetUsersHandler(w, req)
+
+		var got []models.User
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+		assert.Len(t, got, 2)
+	})
+
+	t.Run("enveloped by config", func(t *testing.T) {
+		service := new(mockUsersService)
+		handler := usershandlers.New(slogdiscard.NewDiscardLogger(), service, usershandlers.WithListEnvelope(true))
+		service.On("GetUsers", mock.Anything).Return(users, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		w := httptest.NewRecorder()
+
+		handler.GetUsersHandler(w, req)
+
+		var got envelope
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+		assert.Len(t, got.Data, 2)
+		assert.Equal(t, 2, got.Meta.Total)
+	})
+
+	t.Run("enveloped by accept profile", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		service.On("GetUsers", mock.Anything).Return(users, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set("Accept", `application/json; profile="envelope"`)
+		w := httptest.NewRecorder()
+
+		handler.GetUsersHandler(w, req)
+
+		var got envelope
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+		assert.Len(t, got.Data, 2)
+		assert.Equal(t, 2, got.Meta.Total)
+	})
+}
+
 func TestUsersHandler_GetUserByIdHandler(t *testing.T) {
 	handler, service := newTestHandler(t)
 
@@ -200,6 +336,48 @@ func TestUsersHandler_GetUserByIdHandler(t *testing.T) {
 	})
 }
 
+func TestUsersHandler_ResponsesOmitPassword(t *testing.T) {
+	handler, service := newTestHandler(t)
+
+	user := models.User{Id: uuid.New(), Login: "user1", Password: "secret", Role: "user"}
+	service.On("GetUsers", mock.Anything).Return([]models.User{user}, nil)
+	service.On("GetUserById", mock.Anything, user.Id).Return(user, nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/users", handler.GetUsersHandler)
+	router.HandleFunc("/users/{id}", handler.GetUserByIdHandler)
+
+	t.Run("list", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "secret")
+
+		var got []map[string]any
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+		if assert.Len(t, got, 1) {
+			assert.Equal(t, map[string]any{"Id": user.Id.String(), "Login": "user1", "Role": "user"}, got[0])
+		}
+	})
+
+	t.Run("by id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users/"+user.Id.String(), nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "secret")
+
+		var got map[string]any
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+		assert.Equal(t, map[string]any{"Id": user.Id.String(), "Login": "user1", "Role": "user"}, got)
+	})
+}
+
 func TestUsersHandler_InsertHandler(t *testing.T) {
 	handler, service := newTestHandler(t)
 
@@ -224,6 +402,40 @@ func TestUsersHandler_InsertHandler(t *testing.T) {
 		service.AssertExpectations(t)
 	})
 
+	t.Run("prefer return=minimal", func(t *testing.T) {
+		service.On("Insert", mock.Anything, tUser).Return(tUser, nil).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(bodyBytes))
+		req.Header.Set("Prefer", "return=minimal")
+		w := httptest.NewRecorder()
+
+		handler.InsertHandler(w, req)
+
+		resp := w.Result()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "return=minimal", resp.Header.Get("Preference-Applied"))
+		assert.Zero(t, w.Body.Len())
+		service.AssertExpectations(t)
+	})
+
+	t.Run("prefer return=representation", func(t *testing.T) {
+		service.On("Insert", mock.Anything, tUser).Return(tUser, nil).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(bodyBytes))
+		req.Header.Set("Prefer", "return=representation")
+		w := httptest.NewRecorder()
+
+		handler.InsertHandler(w, req)
+
+		resp := w.Result()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Preference-Applied"))
+
+		var got models.User
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+		assert.Equal(t, tUser.Id, got.Id)
+	})
+
 	t.Run("invalid JSON", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("bad json"))
 		w := httptest.NewRecorder()
@@ -275,6 +487,20 @@ func TestUsersHandler_InsertHandler(t *testing.T) {
 		})
 	*/
 
+	t.Run("backend unavailable", func(t *testing.T) {
+		unavailable := &serviceerrors.UnavailableError{RetryAfter: 1500 * time.Millisecond}
+		service.On("Insert", mock.Anything, mock.Anything).Return(models.User{}, fmt.Errorf("service: %w", unavailable)).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(bodyBytes))
+		w := httptest.NewRecorder()
+
+		handler.InsertHandler(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "2", w.Header().Get("Retry-After"))
+		service.AssertExpectations(t)
+	})
+
 	t.Run("already exists error", func(t *testing.T) {
 		service.On("Insert", mock.Anything, mock.Anything).Return(models.User{}, serviceerrors.ErrAlreadyExists).Once()
 
@@ -288,6 +514,29 @@ func TestUsersHandler_InsertHandler(t *testing.T) {
 		service.AssertExpectations(t)
 	})
 
+	for _, tc := range []struct {
+		err   error
+		field string
+	}{
+		{serviceerrors.ErrDuplicateLogin, "login"},
+		{serviceerrors.ErrDuplicateId, "id"},
+	} {
+		t.Run("duplicate "+tc.field, func(t *testing.T) {
+			service.On("Insert", mock.Anything, mock.Anything).Return(models.User{}, fmt.Errorf("service: %w", tc.err)).Once()
+
+			req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(bodyBytes))
+			w := httptest.NewRecorder()
+
+			handler.InsertHandler(w, req)
+
+			assert.Equal(t, http.StatusConflict, w.Code)
+			var body map[string]string
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+			assert.Equal(t, tc.field, body["field"])
+			service.AssertExpectations(t)
+		})
+	}
+
 	t.Run("other error", func(t *testing.T) {
 		service.On("Insert", mock.Anything, mock.Anything).Return(models.User{}, errors.New("some error")).Once()
 
@@ -330,6 +579,24 @@ func TestUsersHandler_UpdateHandler(t *testing.T) {
 		service.AssertExpectations(t)
 	})
 
+	t.Run("prefer return=minimal", func(t *testing.T) {
+		service.On("Update", mock.Anything, validID, tUser).Return(tUser, nil).Once()
+
+		req := httptest.NewRequest(http.MethodPut, url, bytes.NewReader(bodyBytes))
+		req.Header.Set("Prefer", "handling=lenient, return=minimal")
+		w := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}", handler.UpdateHandler)
+		router.ServeHTTP(w, req)
+
+		resp := w.Result()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, "return=minimal", resp.Header.Get("Preference-Applied"))
+		assert.Zero(t, w.Body.Len())
+		service.AssertExpectations(t)
+	})
+
 	t.Run("invalid UUID", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPut, "/users/not-uuid", bytes.NewReader(bodyBytes))
 		w := httptest.NewRecorder()
@@ -496,3 +763,966 @@ func TestUsersHandler_DeleteHandler(t *testing.T) {
 		service.AssertExpectations(t)
 	})
 }
+
+func TestUsersHandler_ValidateHandler(t *testing.T) {
+	type report struct {
+		Valid          bool     `json:"valid"`
+		LoginAvailable bool     `json:"login_available"`
+		Problems       []string `json:"problems"`
+	}
+
+	validate := func(t *testing.T, handler *usershandlers.UsersHandler, body string) report {
+		req := httptest.NewRequest(http.MethodPost, "/users/validate", strings.NewReader(body))
+		w := httptest.NewRecorder()
+
+		handler.ValidateHandler(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var got report
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+		return got
+	}
+
+	t.Run("available login", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		service.On("GetUserByLogin", mock.Anything, "newuser").Return(models.User{}, serviceerrors.ErrNotFound).Once()
+
+		got := validate(t, handler, `{"Login":"newuser","Password":"s3cretpass"}`)
+
+		assert.True(t, got.Valid)
+		assert.True(t, got.LoginAvailable)
+		assert.Empty(t, got.Problems)
+		service.AssertExpectations(t)
+	})
+
+	t.Run("taken login", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		service.On("GetUserByLogin", mock.Anything, "taken").Return(models.User{Id: uuid.New(), Login: "taken"}, nil).Once()
+
+		got := validate(t, handler, `{"Login":"taken","Password":"s3cretpass"}`)
+
+		assert.False(t, got.Valid)
+		assert.False(t, got.LoginAvailable)
+		assert.Empty(t, got.Problems)
+	})
+
+	t.Run("weak password", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		service.On("GetUserByLogin", mock.Anything, "newuser").Return(models.User{}, serviceerrors.ErrNotFound).Once()
+
+		got := validate(t, handler, `{"Login":"newuser","Password":"abc"}`)
+
+		assert.False(t, got.Valid)
+		assert.True(t, got.LoginAvailable)
+		assert.Len(t, got.Problems, 2)
+	})
+
+	t.Run("backend failure", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		service.On("GetUserByLogin", mock.Anything, "newuser").Return(models.User{}, serviceerrors.ErrInternal).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/users/validate", strings.NewReader(`{"Login":"newuser","Password":"s3cretpass"}`))
+		w := httptest.NewRecorder()
+
+		handler.ValidateHandler(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestUsersHandler_AvailableHandler(t *testing.T) {
+	check := func(t *testing.T, handler *usershandlers.UsersHandler, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.AvailableHandler(w, httptest.NewRequest(http.MethodGet, "/users/available"+query, nil))
+		return w
+	}
+
+	t.Run("available login", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		service.On("GetUserByLogin", mock.Anything, "newuser").Return(models.User{}, serviceerrors.ErrNotFound).Once()
+
+		w := check(t, handler, "?login=newuser")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"available":true}`, w.Body.String())
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		service.AssertExpectations(t)
+	})
+
+	t.Run("taken login", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		service.On("GetUserByLogin", mock.Anything, "Taken").Return(models.User{Id: uuid.New(), Login: "taken"}, nil).Once()
+
+		w := check(t, handler, "?login=Taken")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"available":false}`, w.Body.String())
+		service.AssertExpectations(t)
+	})
+
+	t.Run("missing login", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+
+		w := check(t, handler, "?login=%20")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		service.AssertNotCalled(t, "GetUserByLogin", mock.Anything, mock.Anything)
+	})
+
+	t.Run("backend error", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		service.On("GetUserByLogin", mock.Anything, "user1").Return(models.User{}, errors.New("boom")).Once()
+
+		w := check(t, handler, "?login=user1")
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestUsersHandler_Forbidden(t *testing.T) {
+	handler, service := newTestHandler(t)
+	id := uuid.New()
+	tUser := models.User{Id: id, Login: "user", Password: "pass", Role: "user"}
+	bodyBytes, _ := json.Marshal(tUser)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}", handler.UpdateHandler).Methods(http.MethodPut)
+	router.HandleFunc("/users/{id}", handler.DeleteHandler).Methods(http.MethodDelete)
+
+	service.On("Update", mock.Anything, id, tUser).Return(models.User{}, serviceerrors.ErrForbidden).Once()
+	service.On("Delete", mock.Anything, id).Return(models.User{}, serviceerrors.ErrForbidden).Once()
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPut, "/users/"+id.String(), bytes.NewReader(bodyBytes)),
+		httptest.NewRequest(http.MethodDelete, "/users/"+id.String(), nil),
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code, req.Method)
+	}
+}
+
+func TestUsersHandler_GetUsersHandler_NDJSON(t *testing.T) {
+	users := []models.User{
+		{Id: uuid.New(), Login: "user1"},
+		{Id: uuid.New(), Login: "user2"},
+		{Id: uuid.New(), Login: "user3"},
+	}
+
+	t.Run("one user per line", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		service.On("GetUsers", mock.Anything).Return(users, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/users?format=ndjson", nil)
+		w := httptest.NewRecorder()
+
+		handler.GetUsersHandler(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		assert.True(t, w.Flushed)
+
+		var got []models.User
+		scanner := bufio.NewScanner(w.Body)
+		for scanner.Scan() {
+			var user models.User
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &user))
+			got = append(got, user)
+		}
+		assert.NoError(t, scanner.Err())
+		assert.Equal(t, users, got)
+	})
+
+	t.Run("stops when request is cancelled", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		service.On("GetUsers", mock.Anything).Run(func(mock.Arguments) { cancel() }).Return(users, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/users?format=ndjson", nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+
+		handler.GetUsersHandler(w, req)
+
+		assert.Zero(t, w.Body.Len())
+	})
+}
+
+func TestUsersHandler_CacheControl(t *testing.T) {
+	service := new(mockUsersService)
+	handler := usershandlers.New(slogdiscard.NewDiscardLogger(), service, usershandlers.WithUserMaxAge(30*time.Second))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}", handler.GetUserByIdHandler).Methods(http.MethodGet)
+	router.HandleFunc("/users", handler.InsertHandler).Methods(http.MethodPost)
+
+	t.Run("success is privately cacheable", func(t *testing.T) {
+		id := uuid.New()
+		service.On("GetUserById", mock.Anything, id).Return(models.User{Id: id}, nil).Once()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/"+id.String(), nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "private, max-age=30", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("error is not stored", func(t *testing.T) {
+		id := uuid.New()
+		service.On("GetUserById", mock.Anything, id).Return(models.User{}, serviceerrors.ErrNotFound).Once()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/"+id.String(), nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("write is not stored", func(t *testing.T) {
+		user := models.User{Id: uuid.New(), Login: "user", Password: "pass", Role: "user"}
+		service.On("Insert", mock.Anything, user).Return(user, nil).Once()
+		body, _ := json.Marshal(user)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(body)))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	})
+}
+
+func TestUsersHandler_InsertHandler_Location(t *testing.T) {
+	service := new(mockUsersService)
+	handler := usershandlers.New(slogdiscard.NewDiscardLogger(), service, usershandlers.WithBasePath("/api/v1"))
+
+	user := models.User{Id: uuid.New(), Login: "user1", Password: "pass1", Role: "user"}
+	service.On("Insert", mock.Anything, user).Return(user, nil).Once()
+	body, _ := json.Marshal(user)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.InsertHandler(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/api/v1/users/"+user.Id.String(), w.Header().Get("Location"))
+}
+
+func TestUsersHandler_GetUsersHandler_ResourceExhausted(t *testing.T) {
+	handler, service := newTestHandler(t)
+	service.On("GetUsers", mock.Anything).Return(nil, serviceerrors.ErrResourceExhausted).Once()
+
+	w := httptest.NewRecorder()
+	handler.GetUsersHandler(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Contains(t, w.Body.String(), "pagination")
+}
+
+func TestUsersHandler_ExpiredDeadline(t *testing.T) {
+	// The real service stops before reaching storage once the deadline has passed.
+	handler := usershandlers.New(slogdiscard.NewDiscardLogger(), usersservice.New(slogdiscard.NewDiscardLogger(), nil))
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	id := uuid.New().String()
+	body := `{"login":"user1","password":"Secret123!","role":"user"}`
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		body    string
+		vars    map[string]string
+		handler http.HandlerFunc
+	}{
+		{"get users", http.MethodGet, "/users", "", nil, handler.GetUsersHandler},
+		{"get user by id", http.MethodGet, "/users/" + id, "", map[string]string{"id": id}, handler.GetUserByIdHandler},
+		{"insert", http.MethodPost, "/users", body, nil, handler.InsertHandler},
+		{"update", http.MethodPut, "/users/" + id, body, map[string]string{"id": id}, handler.UpdateHandler},
+		{"delete", http.MethodDelete, "/users/" + id, "", map[string]string{"id": id}, handler.DeleteHandler},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)).WithContext(ctx)
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if tt.vars != nil {
+				req = mux.SetURLVars(req, tt.vars)
+			}
+			w := httptest.NewRecorder()
+
+			tt.handler(w, req)
+
+			assert.Equal(t, http.StatusRequestTimeout, w.Code)
+		})
+	}
+}
+
+func TestUsersHandler_Fields(t *testing.T) {
+	user := models.User{Id: uuid.New(), Login: "user1", Password: "hash", Role: "user"}
+
+	t.Run("projected list", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		service.On("GetUsers", mock.Anything).Return([]models.User{user}, nil).Once()
+
+		w := httptest.NewRecorder()
+		handler.GetUsersHandler(w, httptest.NewRequest(http.MethodGet, "/users?fields=id,login", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var got []map[string]any
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+		assert.Equal(t, []map[string]any{{"Id": user.Id.String(), "Login": "user1"}}, got)
+		service.AssertExpectations(t)
+	})
+
+	t.Run("projected user", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		service.On("GetUserById", mock.Anything, user.Id).Return(user, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/users/"+user.Id.String()+"?fields=role", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": user.Id.String()})
+		w := httptest.NewRecorder()
+		handler.GetUserByIdHandler(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"Role":"user"}`, w.Body.String())
+		service.AssertExpectations(t)
+	})
+
+	for _, fields := range []string{"id,email", "password", "id,"} {
+		t.Run("invalid fields "+fields, func(t *testing.T) {
+			handler, service := newTestHandler(t)
+
+			w := httptest.NewRecorder()
+			handler.GetUsersHandler(w, httptest.NewRequest(http.MethodGet, "/users?fields="+fields, nil))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			service.AssertNotCalled(t, "GetUsers", mock.Anything)
+		})
+	}
+}
+
+func TestUsersHandler_WithStats(t *testing.T) {
+	users := []models.User{
+		{Id: uuid.New(), Login: "alice", Role: models.RoleAdmin},
+		{Id: uuid.New(), Login: "bob", Role: models.RoleUser},
+		{Id: uuid.New(), Login: "carol", Role: models.RoleUser},
+	}
+
+	get := func(t *testing.T, query string) *httptest.ResponseRecorder {
+		service := new(mockUsersService)
+		service.On("GetUsers", mock.Anything).Return(slices.Clone(users), nil)
+		handler := usershandlers.New(slogdiscard.NewDiscardLogger(), service)
+
+		w := httptest.NewRecorder()
+		handler.GetUsersHandler(w, httptest.NewRequest(http.MethodGet, "/users"+query, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w
+	}
+
+	t.Run("with stats", func(t *testing.T) {
+		w := get(t, "?withStats=true&limit=1")
+
+		var got struct {
+			Data  []models.User `json:"data"`
+			Stats struct {
+				Total  int            `json:"total"`
+				ByRole map[string]int `json:"by_role"`
+			} `json:"stats"`
+		}
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+		assert.Len(t, got.Data, 1)
+		// Stats count the whole list, not the page.
+		assert.Equal(t, 3, got.Stats.Total)
+		assert.Equal(t, map[string]int{models.RoleAdmin: 1, models.RoleUser: 2}, got.Stats.ByRole)
+	})
+
+	t.Run("without stats", func(t *testing.T) {
+		for _, query := range []string{"", "?withStats=false"} {
+			w := get(t, query)
+
+			var got []models.User
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&got), "query %q", query)
+			assert.Len(t, got, 3)
+		}
+	})
+
+	t.Run("enveloped without stats", func(t *testing.T) {
+		service := new(mockUsersService)
+		service.On("GetUsers", mock.Anything).Return(slices.Clone(users), nil)
+		handler := usershandlers.New(slogdiscard.NewDiscardLogger(), service, usershandlers.WithListEnvelope(true))
+
+		w := httptest.NewRecorder()
+		handler.GetUsersHandler(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+		var got map[string]json.RawMessage
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+		assert.Contains(t, got, "data")
+		assert.NotContains(t, got, "stats")
+	})
+}
+
+func TestUsersHandler_Sort(t *testing.T) {
+	id := func(last byte) uuid.UUID { return uuid.UUID{15: last} }
+
+	// Unsorted, with ties on role and login that only the id breaks.
+	backend := func() []models.User {
+		return []models.User{
+			{Id: id(3), Login: "carol", Role: "user"},
+			{Id: id(1), Login: "alice", Role: "admin"},
+			{Id: id(4), Login: "bob", Role: "user"},
+			{Id: id(2), Login: "bob", Role: "admin"},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		opts    []usershandlers.Option
+		query   string
+		wantIds []uuid.UUID
+	}{
+		{"id when no sort is configured", nil, "", []uuid.UUID{id(1), id(2), id(3), id(4)}},
+		{"configured default", []usershandlers.Option{usershandlers.WithDefaultSort("login")}, "", []uuid.UUID{id(1), id(2), id(4), id(3)}},
+		{"role ties broken by id", nil, "?sort=role", []uuid.UUID{id(1), id(2), id(3), id(4)}},
+		{"descending role ties broken by id", nil, "?sort=-role", []uuid.UUID{id(3), id(4), id(1), id(2)}},
+		{"request overrides default", []usershandlers.Option{usershandlers.WithDefaultSort("login")}, "?sort=-id", []uuid.UUID{id(4), id(3), id(2), id(1)}},
+		{"several fields", nil, "?sort=login,-role", []uuid.UUID{id(1), id(4), id(2), id(3)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := new(mockUsersService)
+			service.On("GetUsers", mock.Anything).Return(backend(), nil)
+			handler := usershandlers.New(slogdiscard.NewDiscardLogger(), service, tt.opts...)
+
+			w := httptest.NewRecorder()
+			handler.GetUsersHandler(w, httptest.NewRequest(http.MethodGet, "/users"+tt.query, nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var got []models.User
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+
+			gotIds := make([]uuid.UUID, len(got))
+			for i, user := range got {
+				gotIds[i] = user.Id
+			}
+			assert.Equal(t, tt.wantIds, gotIds)
+		})
+	}
+
+	t.Run("pages do not repeat or skip tied rows", func(t *testing.T) {
+		// The backend makes no order promise, so each page may see the users shuffled.
+		reversed := backend()
+		slices.Reverse(reversed)
+
+		service := new(mockUsersService)
+		service.On("GetUsers", mock.Anything).Return(backend(), nil).Once()
+		service.On("GetUsers", mock.Anything).Return(reversed, nil).Once()
+		handler := usershandlers.New(slogdiscard.NewDiscardLogger(), service)
+
+		var gotIds []uuid.UUID
+		for _, query := range []string{"?sort=role&limit=2", "?sort=role&limit=2&offset=2"} {
+			w := httptest.NewRecorder()
+			handler.GetUsersHandler(w, httptest.NewRequest(http.MethodGet, "/users"+query, nil))
+
+			var got []models.User
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			for _, user := range got {
+				gotIds = append(gotIds, user.Id)
+			}
+		}
+
+		assert.Equal(t, []uuid.UUID{id(1), id(2), id(3), id(4)}, gotIds)
+		service.AssertExpectations(t)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		service := new(mockUsersService)
+		handler := usershandlers.New(slogdiscard.NewDiscardLogger(), service)
+
+		w := httptest.NewRecorder()
+		handler.GetUsersHandler(w, httptest.NewRequest(http.MethodGet, "/users?sort=password", nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `unknown sort field "password"`)
+		service.AssertNotCalled(t, "GetUsers", mock.Anything)
+	})
+
+	t.Run("invalid default panics", func(t *testing.T) {
+		assert.Panics(t, func() { usershandlers.WithDefaultSort("password") })
+	})
+}
+
+func TestUsersHandler_Pagination(t *testing.T) {
+	users := make([]models.User, 5)
+	for i := range users {
+		users[i] = models.User{Id: uuid.New(), Login: "user" + strconv.Itoa(i)}
+	}
+
+	newHandler := func() (*usershandlers.UsersHandler, *mockUsersService) {
+		service := new(mockUsersService)
+		service.On("GetUsers", mock.Anything).Return(users, nil)
+		return usershandlers.New(slogdiscard.NewDiscardLogger(), service, usershandlers.WithPageLimits(2, 3)), service
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		want    []models.User
+		warning string
+	}{
+		{"default limit", "", users[:2], ""},
+		{"limit and offset", "?limit=2&offset=3", users[3:5], ""},
+		{"limit at cap", "?limit=3", users[:3], ""},
+		{"over-cap limit is clamped", "?limit=1000000", users[:3], `299 - "limit clamped to 3"`},
+		{"offset past the end", "?offset=10", []models.User{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _ := newHandler()
+
+			w := httptest.NewRecorder()
+			handler.GetUsersHandler(w, httptest.NewRequest(http.MethodGet, "/users"+tt.query, nil))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.warning, w.Header().Get("Warning"))
+
+			var got []models.User
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("envelope reports the applied page", func(t *testing.T) {
+		handler, _ := newHandler()
+
+		req := httptest.NewRequest(http.MethodGet, "/users?limit=50&offset=1", nil)
+		req.Header.Set("Accept", `application/json; profile="envelope"`)
+		w := httptest.NewRecorder()
+		handler.GetUsersHandler(w, req)
+
+		var got struct {
+			Data []models.User `json:"data"`
+			Meta struct {
+				Total  int `json:"total"`
+				Limit  int `json:"limit"`
+				Offset int `json:"offset"`
+			} `json:"meta"`
+		}
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+		assert.Equal(t, users[1:4], got.Data)
+		assert.Equal(t, 5, got.Meta.Total)
+		assert.Equal(t, 3, got.Meta.Limit)
+		assert.Equal(t, 1, got.Meta.Offset)
+		assert.Equal(t, `299 - "limit clamped to 3"`, w.Header().Get("Warning"))
+	})
+
+	for _, query := range []string{"?limit=0", "?limit=-1", "?limit=abc", "?offset=-1"} {
+		t.Run("invalid "+query, func(t *testing.T) {
+			handler, service := newHandler()
+
+			w := httptest.NewRecorder()
+			handler.GetUsersHandler(w, httptest.NewRequest(http.MethodGet, "/users"+query, nil))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			service.AssertNotCalled(t, "GetUsers", mock.Anything)
+		})
+	}
+}
+
+func TestUsersHandler_JSONAPIErrors(t *testing.T) {
+	uid := uuid.New()
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"plain text by default", "", "text/plain; charset=utf-8", "User not found\n"},
+		{"json api", handlers.JSONAPIMediaType, handlers.JSONAPIMediaType, `{"errors":[{"status":"404","title":"Not Found","detail":"User not found"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, service := newTestHandler(t)
+			service.On("Delete", mock.Anything, uid).Return(models.User{}, serviceerrors.ErrNotFound).Once()
+
+			req := httptest.NewRequest(http.MethodDelete, "/users/"+uid.String(), nil)
+			req = mux.SetURLVars(req, map[string]string{"id": uid.String()})
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			handler.DeleteHandler(w, req)
+
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			if tt.accept == "" {
+				assert.Equal(t, tt.body, w.Body.String())
+			} else {
+				assert.JSONEq(t, tt.body, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("json api conflict points at the field", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		user := models.User{Id: uuid.New(), Login: "alice", Password: "secret123", Role: "user"}
+		service.On("Insert", mock.Anything, mock.Anything).Return(models.User{}, serviceerrors.ErrDuplicateLogin).Once()
+
+		body, _ := json.Marshal(user)
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(body))
+		req.Header.Set("Accept", handlers.JSONAPIMediaType)
+		w := httptest.NewRecorder()
+		handler.InsertHandler(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.JSONEq(t, `{"errors":[{"status":"409","title":"Conflict","detail":"User already exists","source":{"pointer":"/login"}}]}`, w.Body.String())
+	})
+}
+
+func TestUsersHandler_NullBody(t *testing.T) {
+	id := uuid.NewString()
+	router := func(handler *usershandlers.UsersHandler) *mux.Router {
+		r := mux.NewRouter()
+		r.HandleFunc("/users", handler.InsertHandler).Methods(http.MethodPost)
+		r.HandleFunc("/users/{id}", handler.UpdateHandler).Methods(http.MethodPut)
+		return r
+	}
+
+	requests := map[string]func(body string) *http.Request{
+		"insert": func(body string) *http.Request {
+			return httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+		},
+		"update": func(body string) *http.Request {
+			return httptest.NewRequest(http.MethodPut, "/users/"+id, strings.NewReader(body))
+		},
+	}
+
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"null", "Request body must be a JSON object"},
+		{"{}", "Failed to validate user"},
+	}
+
+	for name, newRequest := range requests {
+		for _, tt := range tests {
+			t.Run(name+" "+tt.body, func(t *testing.T) {
+				handler, service := newTestHandler(t)
+
+				w := httptest.NewRecorder()
+				router(handler).ServeHTTP(w, newRequest(tt.body))
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, tt.want, strings.TrimSpace(w.Body.String()))
+				service.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+				service.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+			})
+		}
+	}
+}
+
+func TestUsersHandler_MaxBatchSize(t *testing.T) {
+	newHandler := func() (*usershandlers.UsersHandler, *mockUsersService) {
+		service := new(mockUsersService)
+		return usershandlers.New(slogdiscard.NewDiscardLogger(), service, usershandlers.WithMaxBatchSize(2)), service
+	}
+
+	importCSV := func(handler *usershandlers.UsersHandler, rows int) *httptest.ResponseRecorder {
+		body := "login,password,role\n"
+		for i := range rows {
+			body += "user" + strconv.Itoa(i) + ",pass,user\n"
+		}
+		req := httptest.NewRequest(http.MethodPost, "/users/import", strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/csv")
+		w := httptest.NewRecorder()
+		handler.ImportHandler(w, req)
+		return w
+	}
+
+	t.Run("import at the limit", func(t *testing.T) {
+		handler, service := newHandler()
+		service.On("InsertBatch", mock.Anything, mock.Anything).Return([]models.User{{Id: uuid.New()}, {Id: uuid.New()}}, nil).Once()
+
+		w := importCSV(handler, 2)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		service.AssertExpectations(t)
+	})
+
+	t.Run("import over the limit", func(t *testing.T) {
+		handler, service := newHandler()
+
+		w := importCSV(handler, 3)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), "at most 2 items")
+		service.AssertNotCalled(t, "InsertBatch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("zero disables the limit", func(t *testing.T) {
+		service := new(mockUsersService)
+		service.On("InsertBatch", mock.Anything, mock.Anything).Return([]models.User{}, nil)
+		handler := usershandlers.New(slogdiscard.NewDiscardLogger(), service, usershandlers.WithMaxBatchSize(0))
+
+		w := importCSV(handler, usershandlers.DefaultMaxBatchSize+1)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestUsersHandler_ImportHandler(t *testing.T) {
+	type report struct {
+		Imported int `json:"imported"`
+		Failed   int `json:"failed"`
+		Rows     []struct {
+			Row   int    `json:"row"`
+			Id    string `json:"id"`
+			Error string `json:"error"`
+		} `json:"rows"`
+	}
+
+	doImport := func(t *testing.T, handler *usershandlers.UsersHandler, url, body string) (int, report) {
+		req := httptest.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/csv")
+		w := httptest.NewRecorder()
+
+		handler.ImportHandler(w, req)
+
+		var got report
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+		return w.Code, got
+	}
+
+	isLogin := func(login string) any {
+		return mock.MatchedBy(func(u models.User) bool { return u.Login == login })
+	}
+
+	isLogins := func(logins ...string) any {
+		return mock.MatchedBy(func(users []models.User) bool {
+			if len(users) != len(logins) {
+				return false
+			}
+			for i, u := range users {
+				if u.Login != logins[i] {
+					return false
+				}
+			}
+			return true
+		})
+	}
+
+	const clean = "login,password,role\nalice,pass1,user\nbob,pass2,admin\n"
+	const withBadRow = "login,password,role\nalice,pass1,user\n,pass2,user\ncarol,pass3,user\n"
+
+	t.Run("clean import", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		inserted := []models.User{{Id: uuid.New(), Login: "alice"}, {Id: uuid.New(), Login: "bob"}}
+		service.On("InsertBatch", mock.Anything, isLogins("alice", "bob")).Return(inserted, nil).Once()
+
+		code, got := doImport(t, handler, "/users/import", clean)
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 2, got.Imported)
+		assert.Equal(t, 0, got.Failed)
+		assert.Len(t, got.Rows, 2)
+		assert.Equal(t, inserted[0].Id.String(), got.Rows[0].Id)
+		assert.Equal(t, inserted[1].Id.String(), got.Rows[1].Id)
+		service.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+		service.AssertExpectations(t)
+	})
+
+	t.Run("failing row in strict mode rolls back the import", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		service.On("InsertBatch", mock.Anything, isLogins("alice", "bob")).
+			Return(nil, fmt.Errorf("service: %w", &serviceerrors.BatchError{Index: 1, Err: serviceerrors.ErrDuplicateLogin})).Once()
+
+		code, got := doImport(t, handler, "/users/import", clean)
+
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, 0, got.Imported)
+		assert.Equal(t, 1, got.Failed)
+		assert.Empty(t, got.Rows[0].Id)
+		assert.Empty(t, got.Rows[0].Error)
+		assert.Equal(t, "user already exists", got.Rows[1].Error)
+		service.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+		service.AssertExpectations(t)
+	})
+
+	t.Run("backend failure in strict mode", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		service.On("InsertBatch", mock.Anything, mock.Anything).Return(nil, serviceerrors.ErrInternal).Once()
+		req := httptest.NewRequest(http.MethodPost, "/users/import", strings.NewReader(clean))
+		req.Header.Set("Content-Type", "text/csv")
+		w := httptest.NewRecorder()
+
+		handler.ImportHandler(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		service.AssertExpectations(t)
+	})
+
+	t.Run("email column is rejected", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		req := httptest.NewRequest(http.MethodPost, "/users/import", strings.NewReader("login,password,role,email\nalice,pass1,user,a@example.com\n"))
+		req.Header.Set("Content-Type", "text/csv")
+		w := httptest.NewRecorder()
+
+		handler.ImportHandler(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `unsupported column "email"`)
+		service.AssertNotCalled(t, "InsertBatch", mock.Anything, mock.Anything)
+		service.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("body read error stops the import", func(t *testing.T) {
+		service := new(mockUsersService)
+		handler := usershandlers.New(slogdiscard.NewDiscardLogger(), service, usershandlers.WithMaxBatchSize(0))
+		// The failing reader keeps returning its error, like a truncated body.
+		body := io.MultiReader(strings.NewReader("login,password,role\nalice,pass1,user\n"), iotest.ErrReader(errors.New("unexpected EOF")))
+		req := httptest.NewRequest(http.MethodPost, "/users/import", body)
+		req.Header.Set("Content-Type", "text/csv")
+		w := httptest.NewRecorder()
+
+		handler.ImportHandler(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "unexpected EOF")
+		service.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("bad row in strict mode inserts nothing", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+
+		code, got := doImport(t, handler, "/users/import", withBadRow)
+
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, 0, got.Imported)
+		assert.Equal(t, 1, got.Failed)
+		assert.NotEmpty(t, got.Rows[1].Error)
+		service.AssertNotCalled(t, "InsertBatch", mock.Anything, mock.Anything)
+		service.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("bad row in lenient mode is skipped", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		service.On("Insert", mock.Anything, isLogin("alice")).Return(models.User{Id: uuid.New()}, nil).Once()
+		service.On("Insert", mock.Anything, isLogin("carol")).Return(models.User{}, serviceerrors.ErrAlreadyExists).Once()
+
+		code, got := doImport(t, handler, "/users/import?continueOnError=true", withBadRow)
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 1, got.Imported)
+		assert.Equal(t, 2, got.Failed)
+		assert.Empty(t, got.Rows[0].Error)
+		assert.Equal(t, "invalid user", got.Rows[1].Error)
+		assert.Equal(t, "user already exists", got.Rows[2].Error)
+		service.AssertNotCalled(t, "InsertBatch", mock.Anything, mock.Anything)
+		service.AssertExpectations(t)
+	})
+
+	t.Run("lenient mode keeps rows inserted before a failure", func(t *testing.T) {
+		handler, service := newTestHandler(t)
+		aliceId := uuid.New()
+		service.On("Insert", mock.Anything, isLogin("alice")).Return(models.User{Id: aliceId}, nil).Once()
+		service.On("Insert", mock.Anything, isLogin("bob")).Return(models.User{}, serviceerrors.ErrInternal).Once()
+
+		code, got := doImport(t, handler, "/users/import?continueOnError=true", clean)
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 1, got.Imported)
+		assert.Equal(t, 1, got.Failed)
+		assert.Equal(t, aliceId.String(), got.Rows[0].Id)
+		assert.Equal(t, "failed to insert user", got.Rows[1].Error)
+		service.AssertExpectations(t)
+	})
+
+	t.Run("wrong content type", func(t *testing.T) {
+		handler, _ := newTestHandler(t)
+		req := httptest.NewRequest(http.MethodPost, "/users/import", strings.NewReader(clean))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		handler.ImportHandler(w, req)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+
+}
+
+func TestUsersHandler_Accept(t *testing.T) {
+	id := uuid.New()
+
+	tests := []struct {
+		name   string
+		accept string
+		want   int
+	}{
+		{"json", "application/json", http.StatusOK},
+		{"wildcard", "*/*", http.StatusOK},
+		{"xml only", "application/xml", http.StatusNotAcceptable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, service := newTestHandler(t)
+			service.On("GetUsers", mock.Anything).Return([]models.User{}, nil).Maybe()
+			service.On("GetUserById", mock.Anything, id).Return(models.User{Id: id}, nil).Maybe()
+
+			router := mux.NewRouter()
+			router.HandleFunc("/users", handler.GetUsersHandler)
+			router.HandleFunc("/users/{id}", handler.GetUserByIdHandler)
+
+			for _, url := range []string{"/users", "/users/" + id.String()} {
+				req := httptest.NewRequest(http.MethodGet, url, nil)
+				req.Header.Set("Accept", tt.accept)
+				w := httptest.NewRecorder()
+
+				router.ServeHTTP(w, req)
+
+				assert.Equal(t, tt.want, w.Code, url)
+			}
+
+			if tt.want == http.StatusNotAcceptable {
+				service.AssertNotCalled(t, "GetUsers", mock.Anything)
+				service.AssertNotCalled(t, "GetUserById", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestUsersHandler_GetUsersByRoleHandler(t *testing.T) {
+	handler, service := newTestHandler(t)
+	router := mux.NewRouter()
+	router.HandleFunc("/users/by-role/{role}", handler.GetUsersByRoleHandler)
+
+	t.Run("populated role", func(t *testing.T) {
+		admins := []models.User{{Id: uuid.New(), Login: "admin1", Role: "admin"}}
+		service.On("GetUsersByRole", mock.Anything, "admin").Return(admins, nil).Once()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/by-role/admin", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var got []models.User
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+		assert.Equal(t, admins, got)
+	})
+
+	t.Run("invalid role", func(t *testing.T) {
+		service.On("GetUsersByRole", mock.Anything, "root").Return(nil, serviceerrors.ErrInvalidArgument).Once()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/by-role/root", nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,114 @@
+package usershandlers
+
+import (
+	"apigateway/internal/handlers"
+	serviceerrors "apigateway/internal/service"
+	"apigateway/pkg/lib/logger/sl"
+	"apigateway/pkg/lib/requestid"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// MinPasswordLength is the shortest password reported as strong enough.
+const MinPasswordLength = 8
+
+// validateRequest is the part of a user checked before registration.
+type validateRequest struct {
+	Login    string `validate:"required"`
+	Password string `validate:"required"`
+}
+
+// validationReport is the result of a pre-submit check.
+// It only says whether the login is available, never whether a user with it exists.
+type validationReport struct {
+	Valid          bool     `json:"valid"`
+	LoginAvailable bool     `json:"login_available"`
+	Problems       []string `json:"problems,omitempty"`
+}
+
+// ValidateHandler checks a login and password before registration without creating anything.
+func (u *UsersHandler) ValidateHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.users.ValidateHandler"
+	log := u.log.With("op", op, "request_id", requestid.FromContext(r.Context()))
+
+	select {
+	case <-r.Context().Done():
+		log.Info("Request cancelled", sl.Err(r.Context().Err()))
+		handlers.Error(w, r, "Request timeout", http.StatusRequestTimeout)
+		return
+	default:
+	}
+
+	var req validateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error("Failed to read request body", sl.Err(err))
+		handlers.Error(w, r, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	var problems []string
+	if err := validator.New().Struct(req); err != nil {
+		var validationErrs validator.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			for _, fieldErr := range validationErrs {
+				problems = append(problems, fieldErr.Field()+" is required")
+			}
+		}
+	}
+	problems = append(problems, passwordProblems(req.Password)...)
+
+	available := false
+	if req.Login != "" {
+		_, err := u.service.GetUserByLogin(r.Context(), req.Login)
+		switch {
+		case err == nil:
+		case errors.Is(err, serviceerrors.ErrNotFound):
+			available = true
+		default:
+			writeError(w, r, log, err, "Failed to validate user")
+			return
+		}
+	}
+
+	report := validationReport{
+		Valid:          available && len(problems) == 0,
+		LoginAvailable: available,
+		Problems:       problems,
+	}
+
+	log.Info("User validated", slog.Bool("valid", report.Valid))
+
+	writeJSON(w, r, log, http.StatusOK, report)
+}
+
+// passwordProblems lists the reasons a non-empty password is considered weak.
+func passwordProblems(password string) []string {
+	if password == "" {
+		return nil
+	}
+
+	var problems []string
+	if len([]rune(password)) < MinPasswordLength {
+		problems = append(problems, "Password is too short")
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		problems = append(problems, "Password must contain letters and digits")
+	}
+
+	return problems
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"apigateway/internal/handlers"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// BodyReadTimeout bounds how long reading the body of a write request may take.
+// The body is read up front under a read deadline; a stalled body is answered with
+// 408 Request Timeout before the handler runs. Other requests pass through untouched.
+// A body larger than maxBytes is answered with 413 Request Entity Too Large.
+// A non-positive timeout disables the deadline, a non-positive maxBytes the size limit.
+func BodyReadTimeout(timeout time.Duration, maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isWriteMethod(r.Method) || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if maxBytes > 0 {
+				r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			}
+
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rc := http.NewResponseController(w)
+			if err := rc.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+				// The connection does not support deadlines, e.g. in tests with a recorder.
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			_ = rc.SetReadDeadline(time.Time{})
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					w.Header().Set("Connection", "close")
+					handlers.JSONError(w, r, "request body too large", http.StatusRequestEntityTooLarge)
+					return
+				}
+
+				if isTimeout(err) {
+					w.Header().Set("Connection", "close")
+					handlers.JSONError(w, r, "request body read timed out", http.StatusRequestTimeout)
+					return
+				}
+
+				handlers.Error(w, r, "Failed to read request body", http.StatusBadRequest)
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isWriteMethod(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,63 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"apigateway/internal/middleware"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBodyReadTimeout(t *testing.T) {
+	var got string
+	handler := middleware.BodyReadTimeout(50*time.Millisecond, 64)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = string(body)
+	}))
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	t.Run("fast body passes through", func(t *testing.T) {
+		resp, err := http.Post(srv.URL, "application/json", strings.NewReader(`{"login":"u1"}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, `{"login":"u1"}`, got)
+	})
+
+	t.Run("stalled body times out", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		defer pw.Close()
+		go func() {
+			_, _ = pw.Write([]byte(`{"login":`))
+		}()
+
+		req, err := http.NewRequest(http.MethodPost, srv.URL, pr)
+		require.NoError(t, err)
+		req.ContentLength = 100
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusRequestTimeout, resp.StatusCode)
+	})
+
+	t.Run("oversized body is rejected", func(t *testing.T) {
+		got = ""
+		resp, err := http.Post(srv.URL, "application/json", strings.NewReader(strings.Repeat("a", 65)))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+		assert.Empty(t, got)
+	})
+}
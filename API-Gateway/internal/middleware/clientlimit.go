@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"apigateway/internal/handlers"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ClientRateLimit limits how many requests each client IP may make per window, for
+// endpoints that are open to anonymous clients. A non-positive limit disables it.
+func ClientRateLimit(limit int, window time.Duration) func(http.Handler) http.Handler {
+	counters := newWindowCounters(window)
+
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 || window <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			remaining, reset, allowed := counters.allow(clientIP(r), limit, time.Now())
+			setRateLimitHeaders(w, remaining, reset)
+
+			if !allowed {
+				writeTooManyRequests(w, r, "too many requests")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP returns the host part of the request's remote address.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// setRateLimitHeaders reports the requests left and the seconds until the window resets.
+func setRateLimitHeaders(w http.ResponseWriter, remaining int, reset time.Duration) {
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(reset.Seconds()))))
+}
+
+func writeTooManyRequests(w http.ResponseWriter, r *http.Request, msg string) {
+	handlers.JSONError(w, r, msg, http.StatusTooManyRequests)
+}
+
+// windowCounter holds the request counts of the current and previous fixed windows.
+type windowCounter struct {
+	start time.Time
+	curr  int
+	prev  int
+}
+
+// windowCounters counts requests per key with a sliding-window counter kept in memory.
+type windowCounters struct {
+	mu        sync.Mutex
+	window    time.Duration
+	counters  map[string]*windowCounter
+	nextSweep time.Time
+}
+
+func newWindowCounters(window time.Duration) *windowCounters {
+	return &windowCounters{
+		window:   window,
+		counters: make(map[string]*windowCounter),
+	}
+}
+
+// allow counts a request of key at now against limit.
+// Returns the requests left in the window, the time until the window resets and whether the request is allowed.
+func (wc *windowCounters) allow(key string, limit int, now time.Time) (int, time.Duration, bool) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+
+	wc.sweep(now)
+
+	start := now.Truncate(wc.window)
+	c, ok := wc.counters[key]
+	if !ok {
+		c = &windowCounter{start: start}
+		wc.counters[key] = c
+	}
+
+	switch {
+	case start.Sub(c.start) == wc.window:
+		c.prev, c.curr, c.start = c.curr, 0, start
+	case start.After(c.start):
+		c.prev, c.curr, c.start = 0, 0, start
+	}
+
+	elapsed := now.Sub(c.start)
+	weight := 1 - float64(elapsed)/float64(wc.window)
+	used := int(float64(c.prev)*weight) + c.curr
+	reset := wc.window - elapsed
+
+	if used >= limit {
+		return 0, reset, false
+	}
+
+	c.curr++
+	return limit - used - 1, reset, true
+}
+
+// sweep drops counters that no longer affect any window, at most once per window.
+func (wc *windowCounters) sweep(now time.Time) {
+	if now.Before(wc.nextSweep) {
+		return
+	}
+
+	for key, c := range wc.counters {
+		if now.Sub(c.start) >= 2*wc.window {
+			delete(wc.counters, key)
+		}
+	}
+	wc.nextSweep = now.Add(wc.window)
+}
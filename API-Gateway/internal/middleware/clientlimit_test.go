@@ -0,0 +1,33 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"apigateway/internal/middleware"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientRateLimit(t *testing.T) {
+	handler := middleware.ClientRateLimit(2, time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, do("10.0.0.1:1000").Code)
+	assert.Equal(t, http.StatusOK, do("10.0.0.1:1001").Code)
+
+	w := do("10.0.0.1:1002")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "clients are counted by IP, not by port")
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+	assert.Equal(t, http.StatusOK, do("10.0.0.2:1000").Code, "other clients are counted separately")
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"apigateway/pkg/lib/requestid"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// ErrorLog logs responses with an error status together with the matched route
+// template and path variables, so a failing request can be reproduced from the
+// log line alone. 5xx responses are logged as errors, 4xx as warnings. Use it on
+// a mux router, as the route is only known once mux has matched the request.
+func ErrorLog(log *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r)
+
+			if sw.status < http.StatusBadRequest {
+				return
+			}
+
+			attrs := []any{
+				slog.String("request_id", requestid.FromContext(r.Context())),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", sw.status),
+			}
+			if route := mux.CurrentRoute(r); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					attrs = append(attrs, slog.String("route", template))
+				}
+				if name := route.GetName(); name != "" {
+					attrs = append(attrs, slog.String("route_name", name))
+				}
+			}
+			if vars := mux.Vars(r); len(vars) > 0 {
+				attrs = append(attrs, slog.Any("vars", vars))
+			}
+
+			level := slog.LevelWarn
+			if sw.status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			log.Log(r.Context(), level, "Request failed", attrs...)
+		})
+	}
+}
+
+// statusWriter records the status code written through it.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (s *statusWriter) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.wroteHeader = true
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusWriter) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	return s.ResponseWriter.Write(b)
+}
+
+// Flush keeps streaming responses working through the wrapper.
+func (s *statusWriter) Flush() {
+	s.wroteHeader = true
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (s *statusWriter) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
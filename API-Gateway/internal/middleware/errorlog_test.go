@@ -0,0 +1,55 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"apigateway/internal/middleware"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorLog(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	router := mux.NewRouter()
+	router.Use(middleware.ErrorLog(log))
+	router.HandleFunc("/api/v1/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["id"] == "missing" {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}).Methods(http.MethodGet).Name("users.get")
+
+	t.Run("failing request logs the route", func(t *testing.T) {
+		buf.Reset()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users/missing", nil))
+		require.Equal(t, http.StatusNotFound, w.Code)
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "WARN", entry["level"])
+		assert.Equal(t, "/api/v1/users/{id}", entry["route"])
+		assert.Equal(t, "users.get", entry["route_name"])
+		assert.Equal(t, map[string]any{"id": "missing"}, entry["vars"])
+		assert.Equal(t, float64(http.StatusNotFound), entry["status"])
+	})
+
+	t.Run("successful request is not logged", func(t *testing.T) {
+		buf.Reset()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users/42", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, buf.String())
+	})
+}
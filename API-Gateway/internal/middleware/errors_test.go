@@ -0,0 +1,148 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"apigateway/internal/handlers"
+	"apigateway/internal/middleware"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// errorResponse is what a middleware answered a rejected request with.
+type errorResponse struct {
+	status int
+	header http.Header
+	body   string
+}
+
+func recorded(w *httptest.ResponseRecorder) errorResponse {
+	return errorResponse{status: w.Code, header: w.Header(), body: w.Body.String()}
+}
+
+func TestMiddlewareErrors_ContentNegotiation(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name   string
+		status int
+		msg    string
+		header string
+		// reject makes the middleware reject a request carrying accept.
+		reject func(t *testing.T, accept string) errorResponse
+	}{
+		{
+			name:   "uri too long",
+			status: http.StatusRequestURITooLong,
+			msg:    "request URI too long, send the data in the request body",
+			reject: func(t *testing.T, accept string) errorResponse {
+				r := httptest.NewRequest(http.MethodGet, "/?ids="+strings.Repeat("a", 100), nil)
+				r.Header.Set("Accept", accept)
+				w := httptest.NewRecorder()
+				middleware.MaxURILength(0, 64)(ok).ServeHTTP(w, r)
+				return recorded(w)
+			},
+		},
+		{
+			name:   "not ready",
+			status: http.StatusServiceUnavailable,
+			msg:    "service is starting",
+			header: "Retry-After",
+			reject: func(t *testing.T, accept string) errorResponse {
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.Header.Set("Accept", accept)
+				w := httptest.NewRecorder()
+				middleware.Ready(func() bool { return false })(ok).ServeHTTP(w, r)
+				return recorded(w)
+			},
+		},
+		{
+			name:   "too many in flight",
+			status: http.StatusServiceUnavailable,
+			msg:    "too many requests in flight",
+			header: "Retry-After",
+			reject: func(t *testing.T, accept string) errorResponse {
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.Header.Set("Accept", accept)
+				w := httptest.NewRecorder()
+
+				// The handler re-enters the middleware while holding its only slot.
+				var handler http.Handler
+				handler = middleware.MaxInFlight(1)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+					handler.ServeHTTP(w, r)
+				}))
+				handler.ServeHTTP(httptest.NewRecorder(), r)
+				return recorded(w)
+			},
+		},
+		{
+			name:   "client rate limited",
+			status: http.StatusTooManyRequests,
+			msg:    "too many requests",
+			header: "X-RateLimit-Reset",
+			reject: func(t *testing.T, accept string) errorResponse {
+				handler := middleware.ClientRateLimit(1, time.Minute)(ok)
+				handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.Header.Set("Accept", accept)
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				return recorded(w)
+			},
+		},
+		{
+			name:   "body too large",
+			status: http.StatusRequestEntityTooLarge,
+			msg:    "request body too large",
+			reject: func(t *testing.T, accept string) errorResponse {
+				srv := httptest.NewServer(middleware.BodyReadTimeout(time.Second, 4)(ok))
+				t.Cleanup(srv.Close)
+
+				req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("too large"))
+				require.NoError(t, err)
+				req.Header.Set("Accept", accept)
+				resp, err := http.DefaultClient.Do(req)
+				require.NoError(t, err)
+				defer resp.Body.Close()
+
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				return errorResponse{status: resp.StatusCode, header: resp.Header, body: string(body)}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Run("json by default", func(t *testing.T) {
+				got := tt.reject(t, "application/json")
+
+				assert.Equal(t, tt.status, got.status)
+				assert.Equal(t, "application/json", got.header.Get("Content-Type"))
+				assert.JSONEq(t, `{"error":"`+tt.msg+`"}`, got.body)
+				if tt.header != "" {
+					assert.NotEmpty(t, got.header.Get(tt.header))
+				}
+			})
+
+			t.Run("json api when accepted", func(t *testing.T) {
+				got := tt.reject(t, handlers.JSONAPIMediaType)
+
+				assert.Equal(t, tt.status, got.status)
+				assert.Equal(t, handlers.JSONAPIMediaType, got.header.Get("Content-Type"))
+				assert.JSONEq(t, `{"errors":[{"status":"`+strconv.Itoa(tt.status)+`","title":"`+http.StatusText(tt.status)+`","detail":"`+tt.msg+`"}]}`, got.body)
+				if tt.header != "" {
+					assert.NotEmpty(t, got.header.Get(tt.header))
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultCompressibleTypes are the response content types Gzip compresses unless configured otherwise.
+var DefaultCompressibleTypes = []string{
+	"application/json",
+	"application/x-ndjson",
+	"text/*",
+}
+
+// Gzip compresses responses for clients accepting gzip. Bodies smaller than minSize
+// bytes and content types not matching contentTypes are sent as is. contentTypes
+// entries are media types, optionally with a "type/*" wildcard. A negative minSize
+// disables compression.
+func Gzip(minSize int, contentTypes []string) func(http.Handler) http.Handler {
+	if len(contentTypes) == 0 {
+		contentTypes = DefaultCompressibleTypes
+	}
+
+	return func(next http.Handler) http.Handler {
+		if minSize < 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if !acceptsGzip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			gw := &gzipWriter{
+				ResponseWriter: w,
+				minSize:        minSize,
+				contentTypes:   contentTypes,
+				status:         http.StatusOK,
+			}
+			defer gw.close()
+
+			next.ServeHTTP(gw, r)
+		})
+	}
+}
+
+// acceptsGzip reports whether gzip is the best coding Accept-Encoding allows us to
+// send. gzip must have a non-zero q-value, given directly or through "*", and not
+// be ranked below an explicitly listed identity. Codings we do not support, such as br, are ignored, so a
+// client that lists only those gets an uncompressed response.
+func acceptsGzip(r *http.Request) bool {
+	header := r.Header.Get("Accept-Encoding")
+	if header == "" {
+		return false
+	}
+
+	gzipQ, identityQ, wildcardQ := -1.0, -1.0, -1.0
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		q := qValue(params)
+
+		switch coding = strings.ToLower(strings.TrimSpace(coding)); coding {
+		case "gzip", "x-gzip":
+			gzipQ = max(gzipQ, q)
+		case "identity":
+			identityQ = q
+		case "*":
+			wildcardQ = q
+		}
+	}
+
+	// An unlisted gzip gets the wildcard's q-value. An unlisted identity is still
+	// acceptable, but only competes with gzip when the client ranks it explicitly.
+	if gzipQ < 0 {
+		gzipQ = max(wildcardQ, 0)
+	}
+
+	return gzipQ > 0 && gzipQ >= identityQ
+}
+
+// qValue returns the q parameter of an Accept-Encoding element, 1 if it has none
+// and 0 if it is malformed.
+func qValue(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(param, "=")
+		if !strings.EqualFold(strings.TrimSpace(name), "q") {
+			continue
+		}
+
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || q < 0 || q > 1 {
+			return 0
+		}
+		return q
+	}
+
+	return 1
+}
+
+// gzipWriter holds back the status and the first minSize bytes of the body until
+// it knows whether the response is worth compressing.
+type gzipWriter struct {
+	http.ResponseWriter
+	minSize      int
+	contentTypes []string
+
+	status      int
+	wroteHeader bool
+	buf         []byte
+	decided     bool
+	gz          *gzip.Writer
+}
+
+func (g *gzipWriter) WriteHeader(code int) {
+	if g.wroteHeader {
+		return
+	}
+
+	g.wroteHeader = true
+	g.status = code
+}
+
+func (g *gzipWriter) Write(b []byte) (int, error) {
+	if !g.wroteHeader {
+		g.WriteHeader(http.StatusOK)
+	}
+
+	if !g.decided {
+		g.buf = append(g.buf, b...)
+		if len(g.buf) < g.minSize {
+			return len(b), nil
+		}
+
+		if err := g.decide(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if g.gz != nil {
+		return g.gz.Write(b)
+	}
+
+	return g.ResponseWriter.Write(b)
+}
+
+// Flush sends what is buffered so far. A response flushed before reaching minSize
+// is not compressed.
+func (g *gzipWriter) Flush() {
+	if !g.wroteHeader {
+		g.WriteHeader(http.StatusOK)
+	}
+
+	if !g.decided {
+		_ = g.decide()
+	}
+
+	if g.gz != nil {
+		_ = g.gz.Flush()
+	}
+
+	if flusher, ok := g.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (g *gzipWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+// decide writes the held back status and body, compressed if the response qualifies.
+func (g *gzipWriter) decide() error {
+	g.decided = true
+
+	header := g.Header()
+	if header.Get("Content-Type") == "" && len(g.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(g.buf))
+	}
+
+	if len(g.buf) >= g.minSize && len(g.buf) > 0 && header.Get("Content-Encoding") == "" && g.compressible(header.Get("Content-Type")) {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		g.gz = gzip.NewWriter(g.ResponseWriter)
+	}
+
+	g.ResponseWriter.WriteHeader(g.status)
+
+	buf := g.buf
+	g.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+
+	if g.gz != nil {
+		_, err := g.gz.Write(buf)
+		return err
+	}
+
+	_, err := g.ResponseWriter.Write(buf)
+	return err
+}
+
+// compressible reports whether contentType matches one of the configured types.
+func (g *gzipWriter) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range g.contentTypes {
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+			continue
+		}
+
+		if mediaType == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// close finishes the response once the handler returned.
+func (g *gzipWriter) close() {
+	if !g.wroteHeader {
+		return
+	}
+
+	if !g.decided {
+		_ = g.decide()
+	}
+
+	if g.gz != nil {
+		_ = g.gz.Close()
+	}
+}
@@ -0,0 +1,118 @@
+package middleware_test
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"apigateway/internal/middleware"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGzip(t *testing.T) {
+	largeJSON := `{"data":"` + strings.Repeat("a", 4096) + `"}`
+
+	serve := func(mw func(http.Handler) http.Handler, contentType, body, acceptEncoding string) *httptest.ResponseRecorder {
+		inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(body))
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		mw(inner).ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("small json is sent uncompressed", func(t *testing.T) {
+		w := serve(middleware.Gzip(1024, nil), "application/json", `{"id":1}`, "gzip")
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, `{"id":1}`, w.Body.String())
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	})
+
+	t.Run("large json is compressed", func(t *testing.T) {
+		w := serve(middleware.Gzip(1024, nil), "application/json; charset=utf-8", largeJSON, "deflate, gzip")
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Less(t, w.Body.Len(), len(largeJSON))
+
+		zr, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		assert.Equal(t, largeJSON, string(body))
+	})
+
+	t.Run("excluded type is sent uncompressed", func(t *testing.T) {
+		w := serve(middleware.Gzip(1024, nil), "image/png", largeJSON, "gzip")
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, largeJSON, w.Body.String())
+	})
+
+	t.Run("configured types", func(t *testing.T) {
+		w := serve(middleware.Gzip(1024, []string{"text/csv"}), "application/json", largeJSON, "gzip")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+
+		w = serve(middleware.Gzip(1024, []string{"text/csv"}), "text/csv", largeJSON, "gzip")
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("accept-encoding negotiation", func(t *testing.T) {
+		tests := []struct {
+			acceptEncoding string
+			wantGzip       bool
+		}{
+			{"gzip", true},
+			{"GZIP", true},
+			{"br, gzip;q=0.8", true},
+			{"*", true},
+			{"identity", false},
+			{"br", false},
+			{"gzip;q=0", false},
+			{"*;q=0, identity", false},
+			{"gzip;q=0.5, identity", false},
+			{"gzip, identity;q=0.5", true},
+			{"identity;q=0, br", false},
+			{"gzip;q=bad", false},
+		}
+
+		for _, tt := range tests {
+			w := serve(middleware.Gzip(1024, nil), "application/json", largeJSON, tt.acceptEncoding)
+
+			if tt.wantGzip {
+				assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"), tt.acceptEncoding)
+				continue
+			}
+			assert.Empty(t, w.Header().Get("Content-Encoding"), tt.acceptEncoding)
+			assert.Equal(t, largeJSON, w.Body.String(), tt.acceptEncoding)
+		}
+	})
+
+	t.Run("client without gzip", func(t *testing.T) {
+		w := serve(middleware.Gzip(1024, nil), "application/json", largeJSON, "")
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, largeJSON, w.Body.String())
+	})
+
+	t.Run("negative threshold disables compression", func(t *testing.T) {
+		w := serve(middleware.Gzip(-1, nil), "application/json", largeJSON, "gzip")
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, largeJSON, w.Body.String())
+	})
+}
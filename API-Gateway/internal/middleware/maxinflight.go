@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"apigateway/internal/handlers"
+	"expvar"
+	"net/http"
+)
+
+// InFlight is the number of requests currently admitted by MaxInFlight.
+var InFlight = expvar.NewInt("http_in_flight_requests")
+
+// MaxInFlight caps the number of concurrently served requests at limit.
+// Requests over the limit are shed with 503 Service Unavailable and Retry-After
+// instead of queueing. A non-positive limit disables the cap.
+func MaxInFlight(limit int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 {
+			return next
+		}
+
+		sem := make(chan struct{}, limit)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case sem <- struct{}{}:
+			default:
+				w.Header().Set("Retry-After", "1")
+				handlers.JSONError(w, r, "too many requests in flight", http.StatusServiceUnavailable)
+				return
+			}
+
+			InFlight.Add(1)
+			defer func() {
+				InFlight.Add(-1)
+				<-sem
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"apigateway/internal/middleware"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxInFlight(t *testing.T) {
+	const limit = 2
+
+	entered := make(chan struct{}, limit)
+	release := make(chan struct{})
+	handler := middleware.MaxInFlight(limit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	}))
+
+	var wg sync.WaitGroup
+	codes := make([]int, limit)
+	for i := range limit {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			codes[i] = w.Code
+		}()
+	}
+
+	for range limit {
+		<-entered
+	}
+	assert.Equal(t, int64(limit), middleware.InFlight.Value())
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	close(release)
+	wg.Wait()
+
+	for _, code := range codes {
+		assert.Equal(t, http.StatusOK, code)
+	}
+	assert.Equal(t, int64(0), middleware.InFlight.Value())
+}
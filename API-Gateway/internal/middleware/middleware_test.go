@@ -0,0 +1,157 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"apigateway/internal/middleware"
+	"apigateway/pkg/lib/requestid"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	var got string
+	handler := middleware.RequestIDHeader("")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = requestid.FromContext(r.Context())
+	}))
+
+	t.Run("propagates incoming id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(requestid.Header, "req-123")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, "req-123", got)
+		assert.Equal(t, "req-123", w.Header().Get(requestid.Header))
+	})
+
+	t.Run("generates id when missing", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.NotEmpty(t, got)
+		assert.Equal(t, got, w.Header().Get(requestid.Header))
+	})
+
+	for name, invalid := range map[string]string{
+		"too long":      strings.Repeat("a", 129),
+		"control chars": "req\x01123",
+		"non-ascii":     "req-Ünïcode",
+	} {
+		t.Run("regenerates "+name+" id", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(requestid.Header, invalid)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			_, err := uuid.Parse(got)
+			assert.NoError(t, err, "expected a generated id, got %q", got)
+			assert.Equal(t, got, w.Header().Get(requestid.Header))
+		})
+	}
+
+	t.Run("accepts id of maximum length", func(t *testing.T) {
+		id := strings.Repeat("a", 128)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(requestid.Header, id)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, id, got)
+	})
+}
+
+func TestRequestIDHeader(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tests := []struct {
+		name       string
+		header     string
+		reqHeaders map[string]string
+		wantID     string
+		wantEcho   string
+	}{
+		{
+			name:       "custom header",
+			header:     "X-Correlation-Id",
+			reqHeaders: map[string]string{"X-Correlation-Id": "corr-1", requestid.Header: "ignored"},
+			wantID:     "corr-1",
+			wantEcho:   "X-Correlation-Id",
+		},
+		{
+			name:       "trace id from traceparent",
+			header:     "X-Correlation-Id",
+			reqHeaders: map[string]string{middleware.TraceparentHeader: traceparent},
+			wantID:     "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantEcho:   "X-Correlation-Id",
+		},
+		{
+			name:       "configured header wins over traceparent",
+			header:     requestid.Header,
+			reqHeaders: map[string]string{requestid.Header: "req-1", middleware.TraceparentHeader: traceparent},
+			wantID:     "req-1",
+			wantEcho:   requestid.Header,
+		},
+		{
+			name:       "traceparent as the configured header",
+			header:     middleware.TraceparentHeader,
+			reqHeaders: map[string]string{middleware.TraceparentHeader: traceparent},
+			wantID:     "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := middleware.RequestIDHeader(tt.header)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = requestid.FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.reqHeaders {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantID, got)
+			if tt.wantEcho != "" {
+				assert.Equal(t, tt.wantID, w.Header().Get(tt.wantEcho))
+			}
+			assert.Empty(t, w.Header().Get(middleware.TraceparentHeader))
+		})
+	}
+
+	for _, invalid := range []string{
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6-00f067aa0ba902b7-01",
+	} {
+		t.Run("invalid traceparent "+invalid, func(t *testing.T) {
+			var got string
+			handler := middleware.RequestIDHeader("")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = requestid.FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(middleware.TraceparentHeader, invalid)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			_, err := uuid.Parse(got)
+			assert.NoError(t, err, "expected a generated id, got %q", got)
+			assert.Equal(t, got, w.Header().Get(requestid.Header))
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"apigateway/internal/handlers"
+	"net/http"
+)
+
+// Ready rejects requests with 503 Service Unavailable until ready reports true.
+// It lets the gateway accept connections while the backend is still coming up.
+func Ready(ready func() bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !ready() {
+				w.Header().Set("Retry-After", "1")
+				handlers.JSONError(w, r, "service is starting", http.StatusServiceUnavailable)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"apigateway/internal/handlers"
+	"apigateway/pkg/lib/logger/sl"
+	"apigateway/pkg/lib/panicreport"
+	"apigateway/pkg/lib/requestid"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recover turns a panicking handler into a 500 response. The panic is logged with
+// its stack and passed to reporter. http.ErrAbortHandler is re-panicked so net/http
+// can abort the response as intended.
+func Recover(log *slog.Logger, reporter panicreport.PanicReporter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+
+				err := panicreport.ToError(p)
+				stack := debug.Stack()
+				log.Error("Recovered from panic",
+					sl.Err(err),
+					slog.String("request_id", requestid.FromContext(r.Context())),
+					slog.String("stack", string(stack)),
+				)
+				reporter.Report(err, stack)
+
+				handlers.Error(w, r, "Internal server error", http.StatusInternalServerError)
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"apigateway/internal/middleware"
+	"apigateway/pkg/lib/logger/handler/slogdiscard"
+	"apigateway/pkg/lib/panicreport"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecover(t *testing.T) {
+	var (
+		reported error
+		stack    []byte
+	)
+	reporter := panicreport.ReporterFunc(func(err error, s []byte) {
+		reported, stack = err, s
+	})
+
+	t.Run("panic is reported and answered with 500", func(t *testing.T) {
+		sentinel := errors.New("boom")
+		handler := middleware.Recover(slogdiscard.NewDiscardLogger(), reporter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(sentinel)
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.ErrorIs(t, reported, sentinel)
+		assert.Contains(t, string(stack), "TestRecover")
+	})
+
+	t.Run("non-error panic value", func(t *testing.T) {
+		handler := middleware.Recover(slogdiscard.NewDiscardLogger(), reporter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("bad state")
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.EqualError(t, reported, "panic: bad state")
+	})
+
+	t.Run("abort handler is re-panicked", func(t *testing.T) {
+		reported = nil
+		handler := middleware.Recover(slogdiscard.NewDiscardLogger(), reporter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+		assert.Nil(t, reported)
+	})
+}
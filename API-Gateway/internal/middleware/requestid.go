@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"apigateway/pkg/lib/requestid"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// TraceparentHeader is the W3C Trace Context header a request id can be derived from.
+const TraceparentHeader = "traceparent"
+
+// maxRequestIDLength is the longest client-supplied request id that is accepted.
+const maxRequestIDLength = 128
+
+// RequestIDHeader takes the request id from header, X-Request-Id if header is empty,
+// stores it in the request context and echoes it in the response. Without a valid
+// id in header, the trace id of a valid traceparent header is used before a new id
+// is generated. Ids longer than 128 bytes or with characters other than printable
+// ASCII are ignored, since the id is forwarded to backends as gRPC metadata.
+// Configured as traceparent itself, the id is taken from the trace id and not
+// echoed, since a bare id is not a valid traceparent.
+func RequestIDHeader(header string) func(http.Handler) http.Handler {
+	if header == "" {
+		header = requestid.Header
+	}
+	isTraceparent := strings.EqualFold(header, TraceparentHeader)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var id string
+			if !isTraceparent {
+				id = r.Header.Get(header)
+			}
+			if !isValidRequestID(id) {
+				id = ""
+			}
+			if id == "" {
+				id = traceID(r.Header.Get(TraceparentHeader))
+			}
+			if id == "" {
+				id = uuid.NewString()
+			}
+
+			if !isTraceparent {
+				w.Header().Set(header, id)
+			}
+			next.ServeHTTP(w, r.WithContext(requestid.WithID(r.Context(), id)))
+		})
+	}
+}
+
+// isValidRequestID reports whether id is short enough and made of printable ASCII only.
+func isValidRequestID(id string) bool {
+	if len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x20 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// traceID returns the trace id of a version 00 traceparent value,
+// e.g. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", or an empty
+// string if the value is not a valid traceparent.
+func traceID(traceparent string) string {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return ""
+	}
+
+	id, parent, flags := parts[1], parts[2], parts[3]
+	if !isLowerHex(id, 32) || !isLowerHex(parent, 16) || !isLowerHex(flags, 2) {
+		return ""
+	}
+
+	// All-zero trace and parent ids are invalid.
+	if strings.Trim(id, "0") == "" || strings.Trim(parent, "0") == "" {
+		return ""
+	}
+
+	return id
+}
+
+func isLowerHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// DefaultSecurityHeaders are added to API responses unless configured otherwise.
+var DefaultSecurityHeaders = map[string]string{
+	"X-Content-Type-Options": "nosniff",
+	"X-Frame-Options":        "DENY",
+}
+
+// strippedHeaders are removed from every response before it is written.
+var strippedHeaders = []string{"Server", "X-Powered-By"}
+
+// internalHeaderPrefix marks headers meant for internal hops only.
+const internalHeaderPrefix = "X-Internal-"
+
+// SecureHeaders adds the given security headers to every response and removes the
+// Server header and internal headers before the response is flushed.
+func SecureHeaders(headers map[string]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for name, value := range headers {
+				w.Header().Set(name, value)
+			}
+
+			next.ServeHTTP(&headerStripper{ResponseWriter: w}, r)
+		})
+	}
+}
+
+// headerStripper removes leaking headers right before the status line is written.
+type headerStripper struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (h *headerStripper) WriteHeader(code int) {
+	if !h.wroteHeader {
+		h.wroteHeader = true
+		stripHeaders(h.Header())
+	}
+
+	h.ResponseWriter.WriteHeader(code)
+}
+
+func (h *headerStripper) Write(b []byte) (int, error) {
+	if !h.wroteHeader {
+		h.WriteHeader(http.StatusOK)
+	}
+
+	return h.ResponseWriter.Write(b)
+}
+
+func (h *headerStripper) Flush() {
+	if !h.wroteHeader {
+		h.WriteHeader(http.StatusOK)
+	}
+
+	if flusher, ok := h.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (h *headerStripper) Unwrap() http.ResponseWriter {
+	return h.ResponseWriter
+}
+
+func stripHeaders(header http.Header) {
+	for _, name := range strippedHeaders {
+		header.Del(name)
+	}
+
+	for name := range header {
+		if strings.HasPrefix(name, internalHeaderPrefix) {
+			header.Del(name)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"apigateway/internal/middleware"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecureHeaders(t *testing.T) {
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "gateway/1.0")
+		w.Header().Set("X-Internal-Backend", "user_service:50051")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	})
+
+	t.Run("default headers", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		middleware.SecureHeaders(middleware.DefaultSecurityHeaders)(inner).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+		assert.Empty(t, w.Header().Values("Server"))
+		assert.Empty(t, w.Header().Values("X-Internal-Backend"))
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("configured headers", func(t *testing.T) {
+		headers := map[string]string{"X-Frame-Options": "SAMEORIGIN"}
+
+		w := httptest.NewRecorder()
+		middleware.SecureHeaders(headers)(inner).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, "SAMEORIGIN", w.Header().Get("X-Frame-Options"))
+		assert.Empty(t, w.Header().Get("X-Content-Type-Options"))
+		assert.Empty(t, w.Header().Values("Server"))
+	})
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// TrimTrailingSlash redirects requests whose path ends in a slash to the same
+// path without it, which is the canonical form of every route. The redirect
+// uses 308 Permanent Redirect so clients repeat the request with the same
+// method and body. The root path "/" is left as is.
+func TrimTrailingSlash(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if len(path) <= 1 || !strings.HasSuffix(path, "/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		target := *r.URL
+		target.Path = "/" + strings.Trim(path, "/")
+		target.RawPath = ""
+		http.Redirect(w, r, target.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,39 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"apigateway/internal/middleware"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrimTrailingSlash(t *testing.T) {
+	handler := middleware.TrimTrailingSlash(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		want     int
+		location string
+	}{
+		{"root", http.MethodGet, "/", http.StatusOK, ""},
+		{"canonical", http.MethodGet, "/api/v1/users", http.StatusOK, ""},
+		{"slashed get", http.MethodGet, "/api/v1/users/", http.StatusPermanentRedirect, "/api/v1/users"},
+		{"slashed post", http.MethodPost, "/api/v1/users/", http.StatusPermanentRedirect, "/api/v1/users"},
+		{"query kept", http.MethodGet, "/api/v1/users/?format=ndjson", http.StatusPermanentRedirect, "/api/v1/users?format=ndjson"},
+		{"repeated slashes", http.MethodPut, "/api/v1/users/42//", http.StatusPermanentRedirect, "/api/v1/users/42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+			assert.Equal(t, tt.want, w.Code)
+			assert.Equal(t, tt.location, w.Header().Get("Location"))
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"apigateway/internal/handlers"
+	"net/http"
+)
+
+// MaxURILength rejects requests with 414 URI Too Long if the request URI is longer
+// than maxURI or any single query parameter value is longer than maxParam.
+// Non-positive limits disable the respective check.
+func MaxURILength(maxURI, maxParam int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if uriTooLong(r, maxURI, maxParam) {
+				handlers.JSONError(w, r, "request URI too long, send the data in the request body", http.StatusRequestURITooLong)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func uriTooLong(r *http.Request, maxURI, maxParam int) bool {
+	if maxURI > 0 && len(r.RequestURI) > maxURI {
+		return true
+	}
+
+	if maxParam > 0 && r.URL.RawQuery != "" {
+		for _, values := range r.URL.Query() {
+			for _, value := range values {
+				if len(value) > maxParam {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,34 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"apigateway/internal/middleware"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxURILength(t *testing.T) {
+	handler := middleware.MaxURILength(256, 64)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name string
+		url  string
+		want int
+	}{
+		{"normal query", "/api/v1/users?format=ndjson", http.StatusOK},
+		{"over-length uri", "/api/v1/users?ids=" + strings.Repeat("a,", 200), http.StatusRequestURITooLong},
+		{"over-length parameter", "/api/v1/users?ids=" + strings.Repeat("a", 100), http.StatusRequestURITooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
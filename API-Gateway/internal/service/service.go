@@ -1,6 +1,10 @@
 package serviceerrors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 var (
 	ErrNotFound        = errors.New("not found")
@@ -9,4 +13,43 @@ var (
 	ErrDeadlineExeeced = errors.New("deadline exceeded")
 	ErrContextCanceled = errors.New("context canceled")
 	ErrInternal        = errors.New("internal")
+	ErrForbidden       = errors.New("forbidden")
+
+	ErrResourceExhausted = errors.New("resource exhausted")
+	ErrUnavailable       = errors.New("unavailable")
+)
+
+// Field-specific duplicates. Both match ErrAlreadyExists with errors.Is.
+var (
+	ErrDuplicateId    = fmt.Errorf("id %w", ErrAlreadyExists)
+	ErrDuplicateLogin = fmt.Errorf("login %w", ErrAlreadyExists)
 )
+
+// UnavailableError reports that the users backend is temporarily unavailable.
+// It matches ErrUnavailable with errors.Is.
+type UnavailableError struct {
+	// RetryAfter is how long to wait before retrying, or zero if unknown.
+	RetryAfter time.Duration
+}
+
+func (e *UnavailableError) Error() string {
+	return ErrUnavailable.Error()
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return ErrUnavailable
+}
+
+// BatchError is the error of the user at Index of a batch operation.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("user %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
@@ -4,11 +4,14 @@ import (
 	"apigateway/internal/domain/models"
 	serviceerrors "apigateway/internal/service"
 	storageerrors "apigateway/internal/storage"
+	"apigateway/pkg/lib/actor"
 	"apigateway/pkg/lib/logger/sl"
+	"apigateway/pkg/lib/requestid"
 	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -16,7 +19,10 @@ import (
 type IUsersStorage interface {
 	GetUsers(ctx context.Context) ([]models.User, error)
 	GetUserById(ctx context.Context, uid uuid.UUID) (models.User, error)
+	GetUserByLogin(ctx context.Context, login string) (models.User, error)
+	GetUsersByRole(ctx context.Context, role string) ([]models.User, error)
 	Insert(ctx context.Context, user models.User) (models.User, error)
+	InsertBatch(ctx context.Context, users []models.User) ([]models.User, error)
 	Update(ctx context.Context, uid uuid.UUID, user models.User) (models.User, error)
 	Delete(ctx context.Context, uid uuid.UUID) (models.User, error)
 }
@@ -33,14 +39,44 @@ func New(log *slog.Logger, storage IUsersStorage) *UsersService {
 	}
 }
 
+// validateUserID rejects ids that cannot belong to a user: uuid.Nil and uuids without a version.
+func validateUserID(uid uuid.UUID) error {
+	if uid == uuid.Nil || uid.Version() == 0 {
+		return serviceerrors.ErrInvalidArgument
+	}
+
+	return nil
+}
+
+// contextError maps the error of a done context to ErrDeadlineExeeced or
+// ErrContextCanceled, keeping the context error in the chain.
+func contextError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", serviceerrors.ErrDeadlineExeeced, ctx.Err())
+	}
+
+	return fmt.Errorf("%w: %w", serviceerrors.ErrContextCanceled, ctx.Err())
+}
+
+// authorizeWrite allows a write to the target user only for the user themselves or an admin.
+// Requests without an actor in the context are not checked.
+func authorizeWrite(ctx context.Context, target uuid.UUID) error {
+	a, ok := actor.FromContext(ctx)
+	if !ok || a.IsAdmin() || a.Id == target {
+		return nil
+	}
+
+	return serviceerrors.ErrForbidden
+}
+
 func (u *UsersService) GetUsers(ctx context.Context) ([]models.User, error) {
 	const op = "service.users.GetUsers"
-	log := u.log.With("op", op)
+	log := u.log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
-		log.Info("Context cancelled", sl.Err(ctx.Err()))
-		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+		log.Info("Context done", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, contextError(ctx))
 	default:
 	}
 
@@ -53,9 +89,18 @@ func (u *UsersService) GetUsers(ctx context.Context) ([]models.User, error) {
 		case errors.Is(err, storageerrors.ErrDeadlineExeeced):
 			log.Warn("Deadline exceeded", sl.Err(err))
 			return nil, fmt.Errorf("%s: %w", op, serviceerrors.ErrDeadlineExeeced)
+		case errors.Is(err, storageerrors.ErrUnavailable):
+			log.Warn("Users backend unavailable", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, unavailableError(err))
+		case errors.Is(err, storageerrors.ErrResourceExhausted):
+			log.Error("Users response too large", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, serviceerrors.ErrResourceExhausted)
+		case errors.Is(err, storageerrors.ErrInvalidArgument):
+			log.Error("Malformed user in response", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, serviceerrors.ErrInvalidArgument)
 		default:
 			log.Error("Failed to fetch users", sl.Err(err))
-			return nil, fmt.Errorf("%s: %w", op, err)
+			return nil, fmt.Errorf("%s: %w", op, serviceerrors.ErrInternal)
 		}
 	}
 
@@ -65,15 +110,20 @@ func (u *UsersService) GetUsers(ctx context.Context) ([]models.User, error) {
 
 func (u *UsersService) GetUserById(ctx context.Context, uid uuid.UUID) (models.User, error) {
 	const op = "service.users.GetUserById"
-	log := u.log.With("op", op)
+	log := u.log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
-		log.Info("Context cancelled", sl.Err(ctx.Err()))
-		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+		log.Info("Context done", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, contextError(ctx))
 	default:
 	}
 
+	if err := validateUserID(uid); err != nil {
+		log.Warn("Invalid user id", sl.Err(err), slog.String("user_id", uid.String()))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
 	user, err := u.storage.GetUserById(ctx, uid)
 	if err != nil {
 		switch {
@@ -83,6 +133,9 @@ func (u *UsersService) GetUserById(ctx context.Context, uid uuid.UUID) (models.U
 		case errors.Is(err, storageerrors.ErrDeadlineExeeced):
 			log.Warn("Deadline exceeded", sl.Err(err))
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrDeadlineExeeced)
+		case errors.Is(err, storageerrors.ErrUnavailable):
+			log.Warn("Users backend unavailable", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, unavailableError(err))
 		case errors.Is(err, storageerrors.ErrInvalidArgument):
 			log.Warn("Invalid argument", sl.Err(err))
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrInvalidArgument)
@@ -99,14 +152,91 @@ func (u *UsersService) GetUserById(ctx context.Context, uid uuid.UUID) (models.U
 	return user, nil
 }
 
+func (u *UsersService) GetUsersByRole(ctx context.Context, role string) ([]models.User, error) {
+	const op = "service.users.GetUsersByRole"
+	log := u.log.With("op", op, "request_id", requestid.FromContext(ctx))
+
+	select {
+	case <-ctx.Done():
+		log.Info("Context done", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, contextError(ctx))
+	default:
+	}
+
+	if !models.IsValidRole(role) {
+		log.Warn("Unknown role", slog.String("role", role))
+		return nil, fmt.Errorf("%s: %w", op, serviceerrors.ErrInvalidArgument)
+	}
+
+	users, err := u.storage.GetUsersByRole(ctx, role)
+	if err != nil {
+		switch {
+		case errors.Is(err, storageerrors.ErrContextCanceled):
+			log.Warn("Context cancelled", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, serviceerrors.ErrContextCanceled)
+		case errors.Is(err, storageerrors.ErrDeadlineExeeced):
+			log.Warn("Deadline exceeded", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, serviceerrors.ErrDeadlineExeeced)
+		case errors.Is(err, storageerrors.ErrUnavailable):
+			log.Warn("Users backend unavailable", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, unavailableError(err))
+		case errors.Is(err, storageerrors.ErrInvalidArgument):
+			log.Warn("Role rejected by backend", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, serviceerrors.ErrInvalidArgument)
+		default:
+			log.Error("Failed to fetch users by role", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, serviceerrors.ErrInternal)
+		}
+	}
+
+	log.Info("Users fetched successfully", slog.String("role", role), slog.Int("count", len(users)))
+	return users, nil
+}
+
+func (u *UsersService) GetUserByLogin(ctx context.Context, login string) (models.User, error) {
+	const op = "service.users.GetUserByLogin"
+	log := u.log.With("op", op, "request_id", requestid.FromContext(ctx))
+
+	select {
+	case <-ctx.Done():
+		log.Info("Context done", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, contextError(ctx))
+	default:
+	}
+
+	user, err := u.storage.GetUserByLogin(ctx, login)
+	if err != nil {
+		switch {
+		case errors.Is(err, storageerrors.ErrContextCanceled):
+			log.Warn("Context cancelled", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrContextCanceled)
+		case errors.Is(err, storageerrors.ErrDeadlineExeeced):
+			log.Warn("Deadline exceeded", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrDeadlineExeeced)
+		case errors.Is(err, storageerrors.ErrUnavailable):
+			log.Warn("Users backend unavailable", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, unavailableError(err))
+		case errors.Is(err, storageerrors.ErrNotFound):
+			log.Warn("User not found by login", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrNotFound)
+		default:
+			log.Error("Failed to fetch user by login", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrInternal)
+		}
+	}
+
+	log.Info("User fetched successfully", slog.String("user_id", user.Id.String()))
+	return user, nil
+}
+
 func (u *UsersService) Insert(ctx context.Context, userForInsert models.User) (models.User, error) {
 	const op = "service.users.Insert"
-	log := u.log.With("op", op)
+	log := u.log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
-		log.Info("Context cancelled", sl.Err(ctx.Err()))
-		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+		log.Info("Context done", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, contextError(ctx))
 	default:
 	}
 
@@ -119,12 +249,15 @@ func (u *UsersService) Insert(ctx context.Context, userForInsert models.User) (m
 		case errors.Is(err, storageerrors.ErrDeadlineExeeced):
 			log.Warn("Deadline exceeded", sl.Err(err))
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrDeadlineExeeced)
+		case errors.Is(err, storageerrors.ErrUnavailable):
+			log.Warn("Users backend unavailable", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, unavailableError(err))
 		case errors.Is(err, storageerrors.ErrInvalidArgument):
 			log.Warn("Invalid argument", sl.Err(err))
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrInvalidArgument)
 		case errors.Is(err, storageerrors.ErrAlreadyExists):
 			log.Warn("User already exists", sl.Err(err), slog.String("user_id", userForInsert.Id.String()))
-			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrAlreadyExists)
+			return models.User{}, fmt.Errorf("%s: %w", op, duplicateError(err))
 		default:
 			log.Error("Failed to insert user", sl.Err(err), slog.String("user_id", userForInsert.Id.String()))
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrInternal)
@@ -135,17 +268,80 @@ func (u *UsersService) Insert(ctx context.Context, userForInsert models.User) (m
 	return insertedUser, nil
 }
 
+// InsertBatch inserts users in one transaction: either all of them are inserted or none is.
+// If the storage names the user that failed, the error wraps a *serviceerrors.BatchError
+// with its index.
+func (u *UsersService) InsertBatch(ctx context.Context, usersForInsert []models.User) ([]models.User, error) {
+	const op = "service.users.InsertBatch"
+	log := u.log.With("op", op, "request_id", requestid.FromContext(ctx))
+
+	select {
+	case <-ctx.Done():
+		log.Info("Context done", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, contextError(ctx))
+	default:
+	}
+
+	if len(usersForInsert) == 0 {
+		return []models.User{}, nil
+	}
+
+	insertedUsers, err := u.storage.InsertBatch(ctx, usersForInsert)
+	if err != nil {
+		var serviceErr error
+		switch {
+		case errors.Is(err, storageerrors.ErrContextCanceled):
+			log.Warn("Context cancelled", sl.Err(err))
+			serviceErr = serviceerrors.ErrContextCanceled
+		case errors.Is(err, storageerrors.ErrDeadlineExeeced):
+			log.Warn("Deadline exceeded", sl.Err(err))
+			serviceErr = serviceerrors.ErrDeadlineExeeced
+		case errors.Is(err, storageerrors.ErrUnavailable):
+			log.Warn("Users backend unavailable", sl.Err(err))
+			serviceErr = unavailableError(err)
+		case errors.Is(err, storageerrors.ErrInvalidArgument):
+			log.Warn("Invalid argument", sl.Err(err))
+			serviceErr = serviceerrors.ErrInvalidArgument
+		case errors.Is(err, storageerrors.ErrAlreadyExists):
+			log.Warn("User already exists", sl.Err(err))
+			serviceErr = duplicateError(err)
+		default:
+			log.Error("Failed to insert users", sl.Err(err), slog.Int("count", len(usersForInsert)))
+			return nil, fmt.Errorf("%s: %w", op, serviceerrors.ErrInternal)
+		}
+
+		var batchErr *storageerrors.BatchError
+		if errors.As(err, &batchErr) {
+			serviceErr = &serviceerrors.BatchError{Index: batchErr.Index, Err: serviceErr}
+		}
+		return nil, fmt.Errorf("%s: %w", op, serviceErr)
+	}
+
+	log.Info("Users inserted successfully", slog.Int("count", len(insertedUsers)))
+	return insertedUsers, nil
+}
+
 func (u *UsersService) Update(ctx context.Context, uid uuid.UUID, userForUpdate models.User) (models.User, error) {
 	const op = "service.users.Update"
-	log := u.log.With("op", op)
+	log := u.log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
-		log.Info("Context cancelled", sl.Err(ctx.Err()))
-		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+		log.Info("Context done", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, contextError(ctx))
 	default:
 	}
 
+	if err := validateUserID(uid); err != nil {
+		log.Warn("Invalid user id", sl.Err(err), slog.String("user_id", uid.String()))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := authorizeWrite(ctx, uid); err != nil {
+		log.Warn("Actor may not change this user", sl.Err(err), slog.String("user_id", uid.String()))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
 	updatedUser, err := u.storage.Update(ctx, uid, userForUpdate)
 	if err != nil {
 		switch {
@@ -155,12 +351,18 @@ func (u *UsersService) Update(ctx context.Context, uid uuid.UUID, userForUpdate
 		case errors.Is(err, storageerrors.ErrDeadlineExeeced):
 			log.Warn("Deadline exceeded", sl.Err(err))
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrDeadlineExeeced)
+		case errors.Is(err, storageerrors.ErrUnavailable):
+			log.Warn("Users backend unavailable", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, unavailableError(err))
 		case errors.Is(err, storageerrors.ErrInvalidArgument):
 			log.Warn("Invalid argument", sl.Err(err))
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrInvalidArgument)
 		case errors.Is(err, storageerrors.ErrNotFound):
 			log.Warn("User not found", sl.Err(err), slog.String("user_id", uid.String()))
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrNotFound)
+		case errors.Is(err, storageerrors.ErrAlreadyExists):
+			log.Warn("User already exists", sl.Err(err), slog.String("user_id", uid.String()))
+			return models.User{}, fmt.Errorf("%s: %w", op, duplicateError(err))
 		default:
 			log.Error("Failed to update user", sl.Err(err), slog.String("user_id", uid.String()))
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrInternal)
@@ -173,15 +375,25 @@ func (u *UsersService) Update(ctx context.Context, uid uuid.UUID, userForUpdate
 
 func (u *UsersService) Delete(ctx context.Context, uid uuid.UUID) (models.User, error) {
 	const op = "service.users.Delete"
-	log := u.log.With("op", op)
+	log := u.log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
-		log.Info("Context cancelled", sl.Err(ctx.Err()))
-		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+		log.Info("Context done", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, contextError(ctx))
 	default:
 	}
 
+	if err := validateUserID(uid); err != nil {
+		log.Warn("Invalid user id", sl.Err(err), slog.String("user_id", uid.String()))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := authorizeWrite(ctx, uid); err != nil {
+		log.Warn("Actor may not change this user", sl.Err(err), slog.String("user_id", uid.String()))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
 	deletedUser, err := u.storage.Delete(ctx, uid)
 	if err != nil {
 		switch {
@@ -191,6 +403,9 @@ func (u *UsersService) Delete(ctx context.Context, uid uuid.UUID) (models.User,
 		case errors.Is(err, storageerrors.ErrDeadlineExeeced):
 			log.Warn("Deadline exceeded", sl.Err(err))
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrDeadlineExeeced)
+		case errors.Is(err, storageerrors.ErrUnavailable):
+			log.Warn("Users backend unavailable", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, unavailableError(err))
 		case errors.Is(err, storageerrors.ErrInvalidArgument):
 			log.Warn("Invalid argument", sl.Err(err))
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrInvalidArgument)
@@ -206,3 +421,27 @@ func (u *UsersService) Delete(ctx context.Context, uid uuid.UUID) (models.User,
 	log.Info("User deleted successfully", slog.String("user_id", deletedUser.Id.String()))
 	return deletedUser, nil
 }
+
+// duplicateError maps a storage duplicate error to the service error for the
+// same field, or serviceerrors.ErrAlreadyExists if the field is unknown.
+func duplicateError(err error) error {
+	switch {
+	case errors.Is(err, storageerrors.ErrDuplicateLogin):
+		return serviceerrors.ErrDuplicateLogin
+	case errors.Is(err, storageerrors.ErrDuplicateId):
+		return serviceerrors.ErrDuplicateId
+	default:
+		return serviceerrors.ErrAlreadyExists
+	}
+}
+
+// unavailableError converts a storage unavailable error into a
+// serviceerrors.UnavailableError, keeping the backend's retry hint.
+func unavailableError(err error) error {
+	var hinted interface{ RetryAfter() time.Duration }
+	if errors.As(err, &hinted) {
+		return &serviceerrors.UnavailableError{RetryAfter: hinted.RetryAfter()}
+	}
+
+	return &serviceerrors.UnavailableError{}
+}
@@ -3,17 +3,21 @@ package usersservice_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"apigateway/internal/domain/models"
 	serviceerrors "apigateway/internal/service"
 	usersservice "apigateway/internal/service/users"
 	storageerrors "apigateway/internal/storage"
+	"apigateway/pkg/lib/actor"
 	"apigateway/pkg/lib/logger/handler/slogdiscard"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // Mock IUsersStorage
@@ -34,11 +38,32 @@ func (m *mockUsersStorage) GetUserById(ctx context.Context, uid uuid.UUID) (mode
 	return args.Get(0).(models.User), args.Error(1)
 }
 
+func (m *mockUsersStorage) GetUserByLogin(ctx context.Context, login string) (models.User, error) {
+	args := m.Called(ctx, login)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
+func (m *mockUsersStorage) GetUsersByRole(ctx context.Context, role string) ([]models.User, error) {
+	args := m.Called(ctx, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
 func (m *mockUsersStorage) Insert(ctx context.Context, user models.User) (models.User, error) {
 	args := m.Called(ctx, user)
 	return args.Get(0).(models.User), args.Error(1)
 }
 
+func (m *mockUsersStorage) InsertBatch(ctx context.Context, users []models.User) ([]models.User, error) {
+	args := m.Called(ctx, users)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
 func (m *mockUsersStorage) Update(ctx context.Context, uid uuid.UUID, user models.User) (models.User, error) {
 	args := m.Called(ctx, uid, user)
 	return args.Get(0).(models.User), args.Error(1)
@@ -106,9 +131,15 @@ func TestUsersService_GetUsers(t *testing.T) {
 		mockStorage.On("GetUsers", ctx).Return(nil, someErr).Once()
 
 		_, err := svc.GetUsers(ctx)
-		assert.Error(t, err)
-		assert.False(t, errors.Is(err, serviceerrors.ErrInternal))
-		assert.True(t, errors.Is(err, someErr))
+		assert.ErrorIs(t, err, serviceerrors.ErrInternal)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("malformed user in strict mode", func(t *testing.T) {
+		mockStorage.On("GetUsers", ctx).Return(nil, fmt.Errorf("storage.users.grpc.GetUsers: %w", storageerrors.ErrInvalidArgument)).Once()
+
+		_, err := svc.GetUsers(ctx)
+		assert.ErrorIs(t, err, serviceerrors.ErrInvalidArgument)
 		mockStorage.AssertExpectations(t)
 	})
 }
@@ -185,6 +216,52 @@ func TestUsersService_GetUserById(t *testing.T) {
 	})
 }
 
+func TestUsersService_InsertBatch(t *testing.T) {
+	svc, mockStorage := newTestService(t)
+	ctx := context.Background()
+	users := []models.User{
+		{Id: uuid.New(), Login: "first"},
+		{Id: uuid.New(), Login: "second"},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockStorage.On("InsertBatch", ctx, users).Return(users, nil).Once()
+
+		inserted, err := svc.InsertBatch(ctx, users)
+		assert.NoError(t, err)
+		assert.Equal(t, users, inserted)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		inserted, err := svc.InsertBatch(ctx, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, inserted)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("duplicate login keeps the index", func(t *testing.T) {
+		storageErr := fmt.Errorf("storage: %w", &storageerrors.BatchError{Index: 1, Err: storageerrors.ErrDuplicateLogin})
+		mockStorage.On("InsertBatch", ctx, users).Return(nil, storageErr).Once()
+
+		_, err := svc.InsertBatch(ctx, users)
+		assert.ErrorIs(t, err, serviceerrors.ErrDuplicateLogin)
+
+		var batchErr *serviceerrors.BatchError
+		require.ErrorAs(t, err, &batchErr)
+		assert.Equal(t, 1, batchErr.Index)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("other storage error", func(t *testing.T) {
+		mockStorage.On("InsertBatch", ctx, users).Return(nil, errors.New("boom")).Once()
+
+		_, err := svc.InsertBatch(ctx, users)
+		assert.ErrorIs(t, err, serviceerrors.ErrInternal)
+		mockStorage.AssertExpectations(t)
+	})
+}
+
 func TestUsersService_Insert(t *testing.T) {
 	svc, mockStorage := newTestService(t)
 	ctx := context.Background()
@@ -245,6 +322,15 @@ func TestUsersService_Insert(t *testing.T) {
 		mockStorage.AssertExpectations(t)
 	})
 
+	t.Run("storage duplicate login error", func(t *testing.T) {
+		mockStorage.On("Insert", ctx, testUser).Return(models.User{}, storageerrors.ErrDuplicateLogin).Once()
+
+		_, err := svc.Insert(ctx, testUser)
+		assert.True(t, errors.Is(err, serviceerrors.ErrDuplicateLogin))
+		assert.True(t, errors.Is(err, serviceerrors.ErrAlreadyExists))
+		mockStorage.AssertExpectations(t)
+	})
+
 	t.Run("other storage error", func(t *testing.T) {
 		someErr := errors.New("unique constraint violation")
 		mockStorage.On("Insert", ctx, testUser).Return(models.User{}, someErr).Once()
@@ -399,3 +485,135 @@ func TestUsersService_Delete(t *testing.T) {
 		mockStorage.AssertExpectations(t)
 	})
 }
+
+func TestUsersService_DeadlineExceeded(t *testing.T) {
+	svc, mockStorage := newTestService(t)
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	id := uuid.New()
+	user := models.User{Id: id, Login: "user1", Role: "user"}
+
+	calls := map[string]func() error{
+		"GetUsers":       func() error { _, err := svc.GetUsers(ctx); return err },
+		"GetUserById":    func() error { _, err := svc.GetUserById(ctx, id); return err },
+		"GetUserByLogin": func() error { _, err := svc.GetUserByLogin(ctx, "user1"); return err },
+		"GetUsersByRole": func() error { _, err := svc.GetUsersByRole(ctx, "user"); return err },
+		"Insert":         func() error { _, err := svc.Insert(ctx, user); return err },
+		"Update":         func() error { _, err := svc.Update(ctx, id, user); return err },
+		"Delete":         func() error { _, err := svc.Delete(ctx, id); return err },
+	}
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			err := call()
+			assert.ErrorIs(t, err, serviceerrors.ErrDeadlineExeeced)
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+			assert.NotErrorIs(t, err, serviceerrors.ErrContextCanceled)
+		})
+	}
+	mockStorage.AssertExpectations(t)
+}
+
+func TestUsersService_NilUserID(t *testing.T) {
+	svc, mockStorage := newTestService(t)
+	ctx := context.Background()
+
+	t.Run("get by id", func(t *testing.T) {
+		_, err := svc.GetUserById(ctx, uuid.Nil)
+		assert.ErrorIs(t, err, serviceerrors.ErrInvalidArgument)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		_, err := svc.Update(ctx, uuid.Nil, models.User{Login: "test"})
+		assert.ErrorIs(t, err, serviceerrors.ErrInvalidArgument)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		_, err := svc.Delete(ctx, uuid.Nil)
+		assert.ErrorIs(t, err, serviceerrors.ErrInvalidArgument)
+	})
+
+	mockStorage.AssertNotCalled(t, "GetUserById", mock.Anything, mock.Anything)
+	mockStorage.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	mockStorage.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestUsersService_Ownership(t *testing.T) {
+	svc, mockStorage := newTestService(t)
+	self := uuid.New()
+	other := uuid.New()
+	user := models.User{Login: "test"}
+
+	t.Run("self edit allowed", func(t *testing.T) {
+		ctx := actor.WithActor(context.Background(), actor.Actor{Id: self, Role: "user"})
+		mockStorage.On("Update", ctx, self, user).Return(models.User{Id: self}, nil).Once()
+		mockStorage.On("Delete", ctx, self).Return(models.User{Id: self}, nil).Once()
+
+		_, err := svc.Update(ctx, self, user)
+		assert.NoError(t, err)
+		_, err = svc.Delete(ctx, self)
+		assert.NoError(t, err)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("other user edit forbidden", func(t *testing.T) {
+		ctx := actor.WithActor(context.Background(), actor.Actor{Id: self, Role: "user"})
+
+		_, err := svc.Update(ctx, other, user)
+		assert.ErrorIs(t, err, serviceerrors.ErrForbidden)
+		_, err = svc.Delete(ctx, other)
+		assert.ErrorIs(t, err, serviceerrors.ErrForbidden)
+		mockStorage.AssertNotCalled(t, "Update", ctx, other, user)
+		mockStorage.AssertNotCalled(t, "Delete", ctx, other)
+	})
+
+	t.Run("admin override", func(t *testing.T) {
+		ctx := actor.WithActor(context.Background(), actor.Actor{Id: self, Role: actor.RoleAdmin})
+		mockStorage.On("Update", ctx, other, user).Return(models.User{Id: other}, nil).Once()
+		mockStorage.On("Delete", ctx, other).Return(models.User{Id: other}, nil).Once()
+
+		_, err := svc.Update(ctx, other, user)
+		assert.NoError(t, err)
+		_, err = svc.Delete(ctx, other)
+		assert.NoError(t, err)
+		mockStorage.AssertExpectations(t)
+	})
+}
+
+func TestUsersService_GetUsers_ResourceExhausted(t *testing.T) {
+	svc, mockStorage := newTestService(t)
+	ctx := context.Background()
+	mockStorage.On("GetUsers", ctx).Return(nil, storageerrors.ErrResourceExhausted).Once()
+
+	_, err := svc.GetUsers(ctx)
+	assert.ErrorIs(t, err, serviceerrors.ErrResourceExhausted)
+}
+
+func TestUsersService_GetUsersByRole(t *testing.T) {
+	svc, mockStorage := newTestService(t)
+	ctx := context.Background()
+
+	t.Run("valid role", func(t *testing.T) {
+		users := []models.User{{Id: uuid.New(), Role: models.RoleUser}}
+		mockStorage.On("GetUsersByRole", ctx, models.RoleUser).Return(users, nil).Once()
+
+		got, err := svc.GetUsersByRole(ctx, models.RoleUser)
+		assert.NoError(t, err)
+		assert.Equal(t, users, got)
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		_, err := svc.GetUsersByRole(ctx, "root")
+		assert.ErrorIs(t, err, serviceerrors.ErrInvalidArgument)
+		mockStorage.AssertNotCalled(t, "GetUsersByRole", ctx, "root")
+	})
+
+	t.Run("role rejected by backend", func(t *testing.T) {
+		mockStorage.On("GetUsersByRole", ctx, models.RoleAdmin).Return(nil, storageerrors.ErrInvalidArgument).Once()
+
+		_, err := svc.GetUsersByRole(ctx, models.RoleAdmin)
+		assert.ErrorIs(t, err, serviceerrors.ErrInvalidArgument)
+	})
+}
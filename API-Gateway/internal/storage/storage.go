@@ -2,6 +2,7 @@ package storageerrors
 
 import (
 	"errors"
+	"fmt"
 )
 
 var (
@@ -11,4 +12,27 @@ var (
 	ErrDeadlineExeeced = errors.New("deadline exceeded")
 	ErrContextCanceled = errors.New("context canceled")
 	ErrInternal        = errors.New("internal")
+
+	ErrResourceExhausted = errors.New("resource exhausted")
+	ErrUnavailable       = errors.New("unavailable")
+)
+
+// Field-specific duplicates. Both match ErrAlreadyExists with errors.Is.
+var (
+	ErrDuplicateId    = fmt.Errorf("id %w", ErrAlreadyExists)
+	ErrDuplicateLogin = fmt.Errorf("login %w", ErrAlreadyExists)
 )
+
+// BatchError is the error of the user at Index of a batch operation.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("user %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
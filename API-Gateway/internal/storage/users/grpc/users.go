@@ -2,32 +2,98 @@ package usersgrpcstorage
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
+	"time"
 
 	"apigateway/internal/domain/models"
 	"apigateway/internal/domain/profiles"
+	storageerrors "apigateway/internal/storage"
+	"apigateway/pkg/lib/actor"
+	"apigateway/pkg/lib/grpc/breaker"
 	grpchelper "apigateway/pkg/lib/grpc/helper"
 	"apigateway/pkg/lib/logger/sl"
+	"apigateway/pkg/lib/requestid"
 
 	umv1 "github.com/chas3air/protos/gen/go/usersManager"
 	"github.com/google/uuid"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
+// Response header keys UsersManager describes its build and schema with.
+const (
+	metadataKeyVersion       = "x-server-version"
+	metadataKeySchemaVersion = "x-schema-version"
+)
+
+// UsersManager methods served next to the generated UsersManager service, which
+// lacks them. They reuse its messages and the well-known wrapper types.
+const (
+	insertBatchFullMethodName    = "/usersmanager.batch.v1.UsersBatch/InsertBatch"
+	getUsersByRoleFullMethodName = "/usersmanager.lookup.v1.UsersLookup/GetUsersByRole"
+	getUserByLoginFullMethodName = "/usersmanager.lookup.v1.UsersLookup/GetUserByLogin"
+)
+
+// SkippedUsers counts users dropped from GetUsers responses because they could not be converted.
+var SkippedUsers = expvar.NewInt("storage_users_grpc_skipped_users")
+
 type GRPCUsersStorage struct {
 	Log  *slog.Logger
 	Conn *grpc.ClientConn
+
+	// Strict fails GetUsers on the first malformed user instead of skipping it.
+	Strict bool
+}
+
+// Option configures the connection made by New and Connect.
+type Option func(*options)
+
+type options struct {
+	creds credentials.TransportCredentials
+}
+
+// WithTransportCredentials dials UsersManager with creds, e.g. TLS or mutual TLS.
+func WithTransportCredentials(creds credentials.TransportCredentials) Option {
+	return func(o *options) {
+		o.creds = creds
+	}
 }
 
 // New creates a new GRPCUsersStorage instance.
-// It establishes a gRPC connection to the given host and port using insecure credentials.
+// It establishes a gRPC connection to the given host and port, in plain text unless
+// WithTransportCredentials is given, and forwards the request id from the context
+// as gRPC metadata.
+// In strict mode GetUsers rejects the whole response if any user is malformed.
+// If cb is not nil, calls go through the circuit breaker and fail with
+// storageerrors.ErrUnavailable while it is open.
 // Panics if the connection cannot be established.
-func New(log *slog.Logger, host string, port int) *GRPCUsersStorage {
+func New(log *slog.Logger, host string, port int, strict bool, cb *breaker.Breaker, opts ...Option) *GRPCUsersStorage {
+	o := options{creds: insecure.NewCredentials()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	interceptors := []grpc.UnaryClientInterceptor{
+		requestid.UnaryClientInterceptor(),
+		actor.UnaryClientInterceptor(),
+	}
+	if cb != nil {
+		interceptors = append(interceptors, cb.UnaryClientInterceptor())
+	}
+
 	conn, err := grpc.NewClient(
 		fmt.Sprintf("%s:%d", host, port),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(o.creds),
+		grpc.WithChainUnaryInterceptor(interceptors...),
 	)
 	if err != nil {
 		log.Error("Failed to connect to gRPC server", sl.Err(err))
@@ -35,11 +101,31 @@ func New(log *slog.Logger, host string, port int) *GRPCUsersStorage {
 	}
 
 	return &GRPCUsersStorage{
-		Log:  log,
-		Conn: conn,
+		Log:    log,
+		Conn:   conn,
+		Strict: strict,
 	}
 }
 
+// Connect creates a GRPCUsersStorage like New but blocks until the backend is reachable.
+// Returns an error, after closing the connection, if it is not ready within timeout.
+func Connect(log *slog.Logger, host string, port int, strict bool, cb *breaker.Breaker, timeout time.Duration, opts ...Option) (*GRPCUsersStorage, error) {
+	const op = "storage.users.grpc.Connect"
+
+	storage := New(log, host, port, strict, cb, opts...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := storage.WaitForReady(ctx); err != nil {
+		log.Error("gRPC backend is not reachable", sl.Err(err), slog.Duration("timeout", timeout))
+		_ = storage.Conn.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return storage, nil
+}
+
 // Close closes the underlying gRPC connection.
 // Panics if closing the connection fails.
 func (g *GRPCUsersStorage) Close() {
@@ -48,15 +134,57 @@ func (g *GRPCUsersStorage) Close() {
 	}
 }
 
+// WaitForReady blocks until the gRPC connection reaches the Ready state.
+// Returns the context error if ctx is done first.
+func (g *GRPCUsersStorage) WaitForReady(ctx context.Context) error {
+	g.Conn.Connect()
+
+	for {
+		state := g.Conn.GetState()
+		if state == connectivity.Ready {
+			return nil
+		}
+
+		if !g.Conn.WaitForStateChange(ctx, state) {
+			return ctx.Err()
+		}
+	}
+}
+
+// Status runs a gRPC health check against UsersManager and returns its serving
+// status with the version and migration it reports in the response header.
+func (g *GRPCUsersStorage) Status(ctx context.Context) (models.BackendStatus, error) {
+	const op = "storage.users.grpc.Status"
+
+	var header metadata.MD
+	resp, err := healthpb.NewHealthClient(g.Conn).Check(ctx, &healthpb.HealthCheckRequest{}, grpc.Header(&header))
+	if err != nil {
+		return models.BackendStatus{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	status := models.BackendStatus{
+		Serving: resp.GetStatus() == healthpb.HealthCheckResponse_SERVING,
+	}
+	if values := header.Get(metadataKeyVersion); len(values) > 0 {
+		status.Version = values[0]
+	}
+	if values := header.Get(metadataKeySchemaVersion); len(values) > 0 {
+		status.SchemaVersion, _ = strconv.ParseInt(values[0], 10, 64)
+	}
+
+	return status, nil
+}
+
 // GetUsers fetches a list of users via gRPC from the remote UsersManager service.
 // Returns:
 // - []models.User and nil error on success.
 // - error if the context is cancelled or deadline exceeded.
 // - error wrapping storageerrors.ErrContextCanceled, ErrDeadlineExeeced, or ErrInternal for different gRPC error codes.
-// - Skips and logs users that have invalid format and continues processing the rest.
+// - error wrapping storageerrors.ErrInvalidArgument if a user has invalid format in strict mode.
+// - Otherwise skips users that have invalid format, counting them in SkippedUsers, and continues processing the rest.
 func (s *GRPCUsersStorage) GetUsers(ctx context.Context) ([]models.User, error) {
 	const op = "storage.users.grpc.GetUsers"
-	log := s.Log.With("op", op)
+	log := s.Log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
@@ -73,18 +201,30 @@ func (s *GRPCUsersStorage) GetUsers(ctx context.Context) ([]models.User, error)
 	}
 
 	usersForRet := make([]models.User, 0, len(res.GetUsers()))
+	skipped := 0
 
 	for _, pbUser := range res.GetUsers() {
 		tmpUser, err := profiles.ProtoUsrToUsr(pbUser)
 		if err != nil {
+			if s.Strict {
+				log.Error("Wrong user format", sl.Err(err))
+				return nil, fmt.Errorf("%s: %w", op, storageerrors.ErrInvalidArgument)
+			}
+
 			log.Warn("Wrong user format", sl.Err(err))
+			skipped++
 			continue
 		}
 
 		usersForRet = append(usersForRet, tmpUser)
 	}
 
-	log.Info("Users fetched successfully", slog.Int("count", len(usersForRet)))
+	if skipped > 0 {
+		SkippedUsers.Add(int64(skipped))
+		log.Warn("Skipped users with wrong format", slog.Int("skipped", skipped))
+	}
+
+	log.Info("Users fetched successfully", slog.Int("count", len(usersForRet)), slog.Int("skipped", skipped))
 	return usersForRet, nil
 }
 
@@ -93,9 +233,10 @@ func (s *GRPCUsersStorage) GetUsers(ctx context.Context) ([]models.User, error)
 // - models.User and nil error on success.
 // - error wrapping storageerrors.ErrContextCanceled, ErrDeadlineExeeced, ErrInvalidArgument, ErrNotFound, or ErrInternal depending on the gRPC status code returned.
 // - error if the retrieved user data has an invalid format.
+// - error wrapping storageerrors.ErrInternal if the service returned a different user.
 func (s *GRPCUsersStorage) GetUserById(ctx context.Context, uid uuid.UUID) (models.User, error) {
 	const op = "storage.users.grpc.GetUserById"
-	log := s.Log.With("op", op)
+	log := s.Log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
@@ -117,10 +258,104 @@ func (s *GRPCUsersStorage) GetUserById(ctx context.Context, uid uuid.UUID) (mode
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if err := checkUserId(log, uid, user); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("User fetched successfully", slog.String("user_id", user.Id.String()))
+	return user, nil
+}
+
+// GetUserByLogin fetches the user with the given login via gRPC from the remote
+// UsersManager service. Logins are compared case-insensitively, matching the unique
+// login index that serves the lookup.
+// Returns:
+// - models.User and nil error on success.
+// - error wrapping storageerrors.ErrContextCanceled, ErrDeadlineExeeced, ErrInvalidArgument, ErrNotFound, or ErrInternal depending on the gRPC status code returned.
+// - error if the retrieved user data has an invalid format.
+func (s *GRPCUsersStorage) GetUserByLogin(ctx context.Context, login string) (models.User, error) {
+	const op = "storage.users.grpc.GetUserByLogin"
+	log := s.Log.With("op", op, "request_id", requestid.FromContext(ctx))
+
+	select {
+	case <-ctx.Done():
+		log.Info("Context cancelled", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	res := &umv1.GetUserByIdResponse{}
+	if err := s.Conn.Invoke(ctx, getUserByLoginFullMethodName, wrapperspb.String(login), res); err != nil {
+		err = grpchelper.GrpcErrorHelper(log, op, err)
+		return models.User{}, err
+	}
+
+	user, err := profiles.ProtoUsrToUsr(res.GetUser())
+	if err != nil {
+		log.Error("Wrong user format", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !strings.EqualFold(user.Login, login) {
+		log.Warn("Backend returned a different user", slog.String("user_id", user.Id.String()))
+		return models.User{}, fmt.Errorf("%s: returned user %s: %w", op, user.Id, storageerrors.ErrInternal)
+	}
+
 	log.Info("User fetched successfully", slog.String("user_id", user.Id.String()))
 	return user, nil
 }
 
+// GetUsersByRole fetches the users with the given role via gRPC from the remote
+// UsersManager service, which serves the lookup from its role index.
+// Returns:
+// - []models.User and nil error on success.
+// - error wrapping storageerrors.ErrContextCanceled, ErrDeadlineExeeced, ErrInvalidArgument, or ErrInternal depending on the gRPC status code returned.
+// - error if a returned user has an invalid format.
+func (s *GRPCUsersStorage) GetUsersByRole(ctx context.Context, role string) ([]models.User, error) {
+	const op = "storage.users.grpc.GetUsersByRole"
+	log := s.Log.With("op", op, "request_id", requestid.FromContext(ctx))
+
+	select {
+	case <-ctx.Done():
+		log.Info("Context cancelled", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	res := &umv1.GetUsersResponse{}
+	if err := s.Conn.Invoke(ctx, getUsersByRoleFullMethodName, wrapperspb.String(role), res); err != nil {
+		err = grpchelper.GrpcErrorHelper(log, op, err)
+		return nil, err
+	}
+
+	users := make([]models.User, 0, len(res.GetUsers()))
+	for _, pbUser := range res.GetUsers() {
+		user, err := profiles.ProtoUsrToUsr(pbUser)
+		if err != nil {
+			log.Error("Wrong user format", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		users = append(users, user)
+	}
+
+	log.Info("Users fetched successfully", slog.String("role", role), slog.Int("count", len(users)))
+	return users, nil
+}
+
+// checkUserId makes sure the backend answered for the user that was asked for,
+// so a backend bug can never hand one user's data to a request about another.
+func checkUserId(log *slog.Logger, uid uuid.UUID, user models.User) error {
+	if user.Id != uid {
+		log.Warn("Backend returned a different user",
+			slog.String("user_id", uid.String()),
+			slog.String("returned_id", user.Id.String()),
+		)
+		return fmt.Errorf("returned user %s for %s: %w", user.Id, uid, storageerrors.ErrInternal)
+	}
+
+	return nil
+}
+
 // Insert sends a new user to be inserted via gRPC to the remote UsersManager service.
 // Returns:
 // - the inserted models.User and nil on success.
@@ -128,7 +363,7 @@ func (s *GRPCUsersStorage) GetUserById(ctx context.Context, uid uuid.UUID) (mode
 // - error if the inserted user returned from the service has an invalid format.
 func (s *GRPCUsersStorage) Insert(ctx context.Context, userForInsert models.User) (models.User, error) {
 	const op = "storage.users.grpc.Insert"
-	log := s.Log.With("op", op)
+	log := s.Log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
@@ -156,14 +391,63 @@ func (s *GRPCUsersStorage) Insert(ctx context.Context, userForInsert models.User
 	return insertedUser, nil
 }
 
+// InsertBatch inserts users via gRPC on the remote UsersManager service in one
+// transaction: either all of them are inserted or none is.
+// Returns:
+// - the inserted users, in order, and nil on success.
+// - error wrapping storageerrors.ErrContextCanceled, ErrDeadlineExeeced, ErrInvalidArgument, ErrAlreadyExists, or ErrInternal depending on the gRPC status code returned.
+// - error wrapping a *storageerrors.BatchError if the backend names the user that failed.
+// - error if an inserted user returned from the service has an invalid format.
+func (s *GRPCUsersStorage) InsertBatch(ctx context.Context, users []models.User) ([]models.User, error) {
+	const op = "storage.users.grpc.InsertBatch"
+	log := s.Log.With("op", op, "request_id", requestid.FromContext(ctx))
+
+	select {
+	case <-ctx.Done():
+		log.Info("Context cancelled", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	req := &umv1.GetUsersResponse{Users: make([]*umv1.User, 0, len(users))}
+	for _, user := range users {
+		req.Users = append(req.Users, profiles.UsrToProtoUsr(user))
+	}
+
+	res := &umv1.GetUsersResponse{}
+	if err := s.Conn.Invoke(ctx, insertBatchFullMethodName, req, res); err != nil {
+		err = grpchelper.GrpcErrorHelper(log, op, err)
+		if info, ok := grpchelper.ErrorInfo(err); ok {
+			if index, convErr := strconv.Atoi(info.Metadata[grpchelper.MetadataIndex]); convErr == nil {
+				return nil, fmt.Errorf("%s: %w", op, &storageerrors.BatchError{Index: index, Err: err})
+			}
+		}
+		return nil, err
+	}
+
+	insertedUsers := make([]models.User, 0, len(res.GetUsers()))
+	for _, pbUser := range res.GetUsers() {
+		user, err := profiles.ProtoUsrToUsr(pbUser)
+		if err != nil {
+			log.Error("Wrong user format", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		insertedUsers = append(insertedUsers, user)
+	}
+
+	log.Info("Users inserted successfully", slog.Int("count", len(insertedUsers)))
+	return insertedUsers, nil
+}
+
 // Update sends updated user data via gRPC to update the user with the given UUID on the remote UsersManager service.
 // Returns:
 // - the updated models.User and nil on success.
 // - error wrapping storageerrors.ErrContextCanceled, ErrDeadlineExeeced, ErrInvalidArgument, ErrNotFound, or ErrInternal depending on the gRPC status code returned.
 // - error if the updated user data returned from the service has an invalid format.
+// - error wrapping storageerrors.ErrInternal if the service returned a different user.
 func (s *GRPCUsersStorage) Update(ctx context.Context, uid uuid.UUID, userForUpdate models.User) (models.User, error) {
 	const op = "storage.users.grpc.Update"
-	log := s.Log.With("op", op)
+	log := s.Log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
@@ -190,6 +474,10 @@ func (s *GRPCUsersStorage) Update(ctx context.Context, uid uuid.UUID, userForUpd
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if err := checkUserId(log, uid, updatedUser); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
 	log.Info("User updated successfully", slog.String("user_id", updatedUser.Id.String()))
 	return updatedUser, nil
 }
@@ -199,9 +487,10 @@ func (s *GRPCUsersStorage) Update(ctx context.Context, uid uuid.UUID, userForUpd
 // - the deleted models.User and nil on success.
 // - error wrapping storageerrors.ErrContextCanceled, ErrDeadlineExeeced, ErrInvalidArgument, ErrNotFound, or ErrInternal depending on the gRPC status code returned.
 // - error if the deleted user data returned from the service has an invalid format.
+// - error wrapping storageerrors.ErrInternal if the service returned a different user.
 func (s *GRPCUsersStorage) Delete(ctx context.Context, uid uuid.UUID) (models.User, error) {
 	const op = "storage.users.grpc.Delete"
-	log := s.Log.With("op", op)
+	log := s.Log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
@@ -223,6 +512,10 @@ func (s *GRPCUsersStorage) Delete(ctx context.Context, uid uuid.UUID) (models.Us
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if err := checkUserId(log, uid, deletedUser); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
 	log.Info("User deleted successfully", slog.String("user_id", deletedUser.Id.String()))
 	return deletedUser, nil
 }
@@ -0,0 +1,509 @@
+package usersgrpcstorage_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"apigateway/internal/domain/models"
+	"apigateway/internal/domain/profiles"
+	storageerrors "apigateway/internal/storage"
+	usersgrpcstorage "apigateway/internal/storage/users/grpc"
+	"apigateway/pkg/lib/actor"
+	grpchelper "apigateway/pkg/lib/grpc/helper"
+	"apigateway/pkg/lib/logger/handler/slogdiscard"
+
+	umv1 "github.com/chas3air/protos/gen/go/usersManager"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// fakeUsersManager is a UsersManager backend whose responses are set per test.
+type fakeUsersManager struct {
+	umv1.UnimplementedUsersManagerServer
+
+	getUsers    func(ctx context.Context, req *umv1.GetUsersRequest) (*umv1.GetUsersResponse, error)
+	getUserById func(ctx context.Context, req *umv1.GetUserByIdRequest) (*umv1.GetUserByIdResponse, error)
+	insert      func(ctx context.Context, req *umv1.InsertRequest) (*umv1.InsertResponse, error)
+	update      func(ctx context.Context, req *umv1.UpdateRequest) (*umv1.UpdateResponse, error)
+	delete      func(ctx context.Context, req *umv1.DeleteRequest) (*umv1.DeleteResponse, error)
+	insertBatch func(ctx context.Context, req *umv1.GetUsersResponse) (*umv1.GetUsersResponse, error)
+
+	getUsersByRole func(ctx context.Context, req *wrapperspb.StringValue) (*umv1.GetUsersResponse, error)
+	getUserByLogin func(ctx context.Context, req *wrapperspb.StringValue) (*umv1.GetUserByIdResponse, error)
+}
+
+func (f *fakeUsersManager) GetUsers(ctx context.Context, req *umv1.GetUsersRequest) (*umv1.GetUsersResponse, error) {
+	return f.getUsers(ctx, req)
+}
+
+func (f *fakeUsersManager) GetUserById(ctx context.Context, req *umv1.GetUserByIdRequest) (*umv1.GetUserByIdResponse, error) {
+	return f.getUserById(ctx, req)
+}
+
+func (f *fakeUsersManager) Insert(ctx context.Context, req *umv1.InsertRequest) (*umv1.InsertResponse, error) {
+	return f.insert(ctx, req)
+}
+
+func (f *fakeUsersManager) Update(ctx context.Context, req *umv1.UpdateRequest) (*umv1.UpdateResponse, error) {
+	return f.update(ctx, req)
+}
+
+func (f *fakeUsersManager) Delete(ctx context.Context, req *umv1.DeleteRequest) (*umv1.DeleteResponse, error) {
+	return f.delete(ctx, req)
+}
+
+// fakeBatchServiceDesc serves the UsersManager batch insert, which is not in the generated code.
+var fakeBatchServiceDesc = grpc.ServiceDesc{
+	ServiceName: "usersmanager.batch.v1.UsersBatch",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "InsertBatch",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				req := &umv1.GetUsersResponse{}
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				return srv.(*fakeUsersManager).insertBatch(ctx, req)
+			},
+		},
+	},
+}
+
+// fakeLookupServiceDesc serves the UsersManager lookups missing from the generated code.
+var fakeLookupServiceDesc = grpc.ServiceDesc{
+	ServiceName: "usersmanager.lookup.v1.UsersLookup",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetUsersByRole",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				req := &wrapperspb.StringValue{}
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				return srv.(*fakeUsersManager).getUsersByRole(ctx, req)
+			},
+		},
+		{
+			MethodName: "GetUserByLogin",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				req := &wrapperspb.StringValue{}
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				return srv.(*fakeUsersManager).getUserByLogin(ctx, req)
+			},
+		},
+	},
+}
+
+func newTestStorage(t *testing.T, backend *fakeUsersManager, opts ...grpc.DialOption) *usersgrpcstorage.GRPCUsersStorage {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	umv1.RegisterUsersManagerServer(srv, backend)
+	srv.RegisterService(&fakeBatchServiceDesc, backend)
+	srv.RegisterService(&fakeLookupServiceDesc, backend)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	opts = append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, opts...)
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return &usersgrpcstorage.GRPCUsersStorage{
+		Log:  slogdiscard.NewDiscardLogger(),
+		Conn: conn,
+	}
+}
+
+func detailedStatus(t *testing.T, code codes.Code, reason string, metadata map[string]string) error {
+	st, err := status.New(code, "backend error").WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   "usersmanager",
+		Metadata: metadata,
+	})
+	require.NoError(t, err)
+	return st.Err()
+}
+
+func TestGRPCUsersStorage_ErrorInfo(t *testing.T) {
+	backend := &fakeUsersManager{}
+	storage := newTestStorage(t, backend)
+	ctx := context.Background()
+
+	t.Run("already exists with details", func(t *testing.T) {
+		user := models.User{Id: uuid.New(), Login: "u1", Password: "p1", Role: "user"}
+		backend.insert = func(ctx context.Context, req *umv1.InsertRequest) (*umv1.InsertResponse, error) {
+			return nil, detailedStatus(t, codes.AlreadyExists, grpchelper.ReasonUserAlreadyExists, map[string]string{
+				"user_id": req.GetUser().GetId(),
+				"login":   req.GetUser().GetLogin(),
+			})
+		}
+
+		_, err := storage.Insert(ctx, user)
+		assert.ErrorIs(t, err, storageerrors.ErrAlreadyExists)
+
+		info, ok := grpchelper.ErrorInfo(err)
+		require.True(t, ok)
+		assert.Equal(t, grpchelper.ReasonUserAlreadyExists, info.Reason)
+		assert.Equal(t, "usersmanager", info.Domain)
+		assert.Equal(t, user.Id.String(), info.Metadata["user_id"])
+		assert.Equal(t, "u1", info.Metadata["login"])
+	})
+
+	t.Run("duplicate login", func(t *testing.T) {
+		user := models.User{Id: uuid.New(), Login: "taken", Password: "p1", Role: "user"}
+		backend.insert = func(ctx context.Context, req *umv1.InsertRequest) (*umv1.InsertResponse, error) {
+			return nil, detailedStatus(t, codes.AlreadyExists, grpchelper.ReasonUserAlreadyExists, map[string]string{
+				grpchelper.MetadataField: "login",
+			})
+		}
+
+		_, err := storage.Insert(ctx, user)
+		assert.ErrorIs(t, err, storageerrors.ErrDuplicateLogin)
+		assert.ErrorIs(t, err, storageerrors.ErrAlreadyExists)
+	})
+
+	t.Run("unavailable with retry delay", func(t *testing.T) {
+		id := uuid.New()
+		backend.getUserById = func(ctx context.Context, req *umv1.GetUserByIdRequest) (*umv1.GetUserByIdResponse, error) {
+			st, err := status.New(codes.Unavailable, "breaker open").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(7 * time.Second)})
+			require.NoError(t, err)
+			return nil, st.Err()
+		}
+
+		_, err := storage.GetUserById(ctx, id)
+		assert.ErrorIs(t, err, storageerrors.ErrUnavailable)
+
+		info, ok := grpchelper.ErrorInfo(err)
+		require.True(t, ok)
+		assert.Equal(t, 7*time.Second, info.RetryAfter())
+	})
+
+	t.Run("not found with details", func(t *testing.T) {
+		id := uuid.New()
+		backend.getUserById = func(ctx context.Context, req *umv1.GetUserByIdRequest) (*umv1.GetUserByIdResponse, error) {
+			return nil, detailedStatus(t, codes.NotFound, grpchelper.ReasonUserNotFound, map[string]string{"user_id": req.GetId()})
+		}
+
+		_, err := storage.GetUserById(ctx, id)
+		assert.ErrorIs(t, err, storageerrors.ErrNotFound)
+
+		info, ok := grpchelper.ErrorInfo(err)
+		require.True(t, ok)
+		assert.Equal(t, grpchelper.ReasonUserNotFound, info.Reason)
+		assert.Equal(t, id.String(), info.Metadata["user_id"])
+	})
+
+	t.Run("plain status without details", func(t *testing.T) {
+		backend.delete = func(ctx context.Context, req *umv1.DeleteRequest) (*umv1.DeleteResponse, error) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+
+		_, err := storage.Delete(ctx, uuid.New())
+		assert.ErrorIs(t, err, storageerrors.ErrNotFound)
+
+		_, ok := grpchelper.ErrorInfo(err)
+		assert.False(t, ok)
+	})
+
+	t.Run("resource exhausted", func(t *testing.T) {
+		err := grpchelper.GrpcErrorHelper(slogdiscard.NewDiscardLogger(), "op", status.Error(codes.ResourceExhausted, "message larger than max"))
+		assert.ErrorIs(t, err, storageerrors.ErrResourceExhausted)
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		st, err := status.New(codes.ResourceExhausted, "rate limit exceeded for GetUsers").WithDetails(
+			&errdetails.ErrorInfo{Reason: grpchelper.ReasonRateLimited, Domain: "usersmanager"},
+			&errdetails.RetryInfo{RetryDelay: durationpb.New(20 * time.Second)},
+		)
+		require.NoError(t, err)
+
+		err = grpchelper.GrpcErrorHelper(slogdiscard.NewDiscardLogger(), "op", st.Err())
+		assert.ErrorIs(t, err, storageerrors.ErrUnavailable)
+		assert.NotErrorIs(t, err, storageerrors.ErrResourceExhausted)
+
+		info, ok := grpchelper.ErrorInfo(err)
+		require.True(t, ok)
+		assert.Equal(t, 20*time.Second, info.RetryAfter())
+	})
+
+	t.Run("non-status error", func(t *testing.T) {
+		err := grpchelper.GrpcErrorHelper(slogdiscard.NewDiscardLogger(), "op", errors.New("boom"))
+		assert.ErrorIs(t, err, storageerrors.ErrInternal)
+	})
+}
+
+func TestGRPCUsersStorage_InsertBatch(t *testing.T) {
+	backend := &fakeUsersManager{}
+	storage := newTestStorage(t, backend)
+	ctx := context.Background()
+
+	users := []models.User{
+		{Id: uuid.New(), Login: "u1", Password: "p1", Role: "user"},
+		{Id: uuid.New(), Login: "u2", Password: "p2", Role: "admin"},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		backend.insertBatch = func(ctx context.Context, req *umv1.GetUsersResponse) (*umv1.GetUsersResponse, error) {
+			return req, nil
+		}
+
+		inserted, err := storage.InsertBatch(ctx, users)
+		require.NoError(t, err)
+		assert.Equal(t, users, inserted)
+	})
+
+	t.Run("failed user", func(t *testing.T) {
+		backend.insertBatch = func(ctx context.Context, req *umv1.GetUsersResponse) (*umv1.GetUsersResponse, error) {
+			return nil, detailedStatus(t, codes.AlreadyExists, grpchelper.ReasonUserAlreadyExists, map[string]string{
+				grpchelper.MetadataIndex: "1",
+				grpchelper.MetadataField: "login",
+			})
+		}
+
+		_, err := storage.InsertBatch(ctx, users)
+		assert.ErrorIs(t, err, storageerrors.ErrDuplicateLogin)
+
+		var batchErr *storageerrors.BatchError
+		require.ErrorAs(t, err, &batchErr)
+		assert.Equal(t, 1, batchErr.Index)
+	})
+
+	t.Run("error without index", func(t *testing.T) {
+		backend.insertBatch = func(ctx context.Context, req *umv1.GetUsersResponse) (*umv1.GetUsersResponse, error) {
+			return nil, status.Error(codes.Internal, "boom")
+		}
+
+		_, err := storage.InsertBatch(ctx, users)
+		assert.ErrorIs(t, err, storageerrors.ErrInternal)
+
+		var batchErr *storageerrors.BatchError
+		assert.False(t, errors.As(err, &batchErr))
+	})
+}
+
+func TestGRPCUsersStorage_GetUsersByRole(t *testing.T) {
+	backend := &fakeUsersManager{}
+	storage := newTestStorage(t, backend)
+	ctx := context.Background()
+
+	t.Run("uses the role lookup", func(t *testing.T) {
+		admin := models.User{Id: uuid.New(), Login: "admin1", Password: "p1", Role: "admin"}
+		backend.getUsers = func(ctx context.Context, req *umv1.GetUsersRequest) (*umv1.GetUsersResponse, error) {
+			t.Error("GetUsersByRole must not fetch the full user list")
+			return nil, status.Error(codes.Internal, "unexpected call")
+		}
+		backend.getUsersByRole = func(ctx context.Context, req *wrapperspb.StringValue) (*umv1.GetUsersResponse, error) {
+			assert.Equal(t, "admin", req.GetValue())
+			return &umv1.GetUsersResponse{Users: []*umv1.User{profiles.UsrToProtoUsr(admin)}}, nil
+		}
+
+		users, err := storage.GetUsersByRole(ctx, "admin")
+		require.NoError(t, err)
+		assert.Equal(t, []models.User{admin}, users)
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		backend.getUsersByRole = func(ctx context.Context, req *wrapperspb.StringValue) (*umv1.GetUsersResponse, error) {
+			return nil, status.Error(codes.InvalidArgument, "unknown role")
+		}
+
+		_, err := storage.GetUsersByRole(ctx, "root")
+		assert.ErrorIs(t, err, storageerrors.ErrInvalidArgument)
+	})
+}
+
+func TestGRPCUsersStorage_GetUsers_MalformedUser(t *testing.T) {
+	valid := &umv1.User{Id: uuid.NewString(), Login: "u1", Password: "p1", Role: "user"}
+	malformed := &umv1.User{Id: "not-a-uuid", Login: "u2"}
+
+	backend := &fakeUsersManager{
+		getUsers: func(ctx context.Context, req *umv1.GetUsersRequest) (*umv1.GetUsersResponse, error) {
+			return &umv1.GetUsersResponse{Users: []*umv1.User{valid, malformed}}, nil
+		},
+	}
+	storage := newTestStorage(t, backend)
+	ctx := context.Background()
+
+	t.Run("lenient skips and counts", func(t *testing.T) {
+		storage.Strict = false
+		before := usersgrpcstorage.SkippedUsers.Value()
+
+		users, err := storage.GetUsers(ctx)
+		require.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.Equal(t, "u1", users[0].Login)
+		assert.Equal(t, before+1, usersgrpcstorage.SkippedUsers.Value())
+	})
+
+	t.Run("strict fails the whole call", func(t *testing.T) {
+		storage.Strict = true
+
+		users, err := storage.GetUsers(ctx)
+		assert.ErrorIs(t, err, storageerrors.ErrInvalidArgument)
+		assert.Nil(t, users)
+	})
+}
+
+func TestGRPCUsersStorage_RejectsOtherUser(t *testing.T) {
+	other := &umv1.User{Id: uuid.NewString(), Login: "other", Password: "p", Role: "user"}
+	backend := &fakeUsersManager{
+		getUserById: func(context.Context, *umv1.GetUserByIdRequest) (*umv1.GetUserByIdResponse, error) {
+			return &umv1.GetUserByIdResponse{User: other}, nil
+		},
+		update: func(context.Context, *umv1.UpdateRequest) (*umv1.UpdateResponse, error) {
+			return &umv1.UpdateResponse{User: other}, nil
+		},
+		delete: func(context.Context, *umv1.DeleteRequest) (*umv1.DeleteResponse, error) {
+			return &umv1.DeleteResponse{User: other}, nil
+		},
+	}
+	storage := newTestStorage(t, backend)
+	ctx := context.Background()
+	uid := uuid.New()
+
+	calls := map[string]func() (models.User, error){
+		"GetUserById": func() (models.User, error) { return storage.GetUserById(ctx, uid) },
+		"Update":      func() (models.User, error) { return storage.Update(ctx, uid, models.User{Id: uid, Login: "u"}) },
+		"Delete":      func() (models.User, error) { return storage.Delete(ctx, uid) },
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			user, err := call()
+			assert.ErrorIs(t, err, storageerrors.ErrInternal)
+			assert.Zero(t, user)
+		})
+	}
+
+	t.Run("matching id passes", func(t *testing.T) {
+		backend.getUserById = func(_ context.Context, req *umv1.GetUserByIdRequest) (*umv1.GetUserByIdResponse, error) {
+			return &umv1.GetUserByIdResponse{User: &umv1.User{Id: req.GetId(), Login: "u", Role: "user"}}, nil
+		}
+
+		user, err := storage.GetUserById(ctx, uid)
+		require.NoError(t, err)
+		assert.Equal(t, uid, user.Id)
+	})
+}
+
+func TestConnect_Timeout(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := lis.Addr().(*net.TCPAddr).Port
+	require.NoError(t, lis.Close())
+
+	start := time.Now()
+	storage, err := usersgrpcstorage.Connect(slogdiscard.NewDiscardLogger(), "127.0.0.1", port, false, nil, 200*time.Millisecond)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, storage)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestGRPCUsersStorage_ForwardsActor(t *testing.T) {
+	var got metadata.MD
+	backend := &fakeUsersManager{
+		getUserById: func(ctx context.Context, req *umv1.GetUserByIdRequest) (*umv1.GetUserByIdResponse, error) {
+			got, _ = metadata.FromIncomingContext(ctx)
+			return &umv1.GetUserByIdResponse{User: &umv1.User{Id: req.GetId(), Login: "user1", Role: "user"}}, nil
+		},
+	}
+	storage := newTestStorage(t, backend, grpc.WithUnaryInterceptor(actor.UnaryClientInterceptor()))
+
+	a := actor.Actor{Id: uuid.New(), Role: "admin"}
+	_, err := storage.GetUserById(actor.WithActor(context.Background(), a), uuid.New())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{a.Id.String()}, got.Get(actor.MetadataKeyId))
+	assert.Equal(t, []string{"admin"}, got.Get(actor.MetadataKeyRole))
+}
+
+func TestGRPCUsersStorage_GetUserByLogin(t *testing.T) {
+	id := uuid.New()
+	backend := &fakeUsersManager{
+		getUsers: func(ctx context.Context, req *umv1.GetUsersRequest) (*umv1.GetUsersResponse, error) {
+			t.Error("GetUserByLogin must not fetch the full user list")
+			return nil, status.Error(codes.Internal, "unexpected call")
+		},
+		getUserByLogin: func(ctx context.Context, req *wrapperspb.StringValue) (*umv1.GetUserByIdResponse, error) {
+			if !strings.EqualFold(req.GetValue(), "alice") {
+				return nil, status.Error(codes.NotFound, "user not found")
+			}
+			return &umv1.GetUserByIdResponse{User: &umv1.User{Id: id.String(), Login: "Alice", Role: "user"}}, nil
+		},
+	}
+	storage := newTestStorage(t, backend)
+
+	for _, login := range []string{"Alice", "alice", "ALICE"} {
+		user, err := storage.GetUserByLogin(context.Background(), login)
+		require.NoError(t, err, login)
+		assert.Equal(t, id, user.Id)
+	}
+
+	_, err := storage.GetUserByLogin(context.Background(), "bob")
+	assert.ErrorIs(t, err, storageerrors.ErrNotFound)
+
+	t.Run("rejects another user", func(t *testing.T) {
+		backend.getUserByLogin = func(ctx context.Context, req *wrapperspb.StringValue) (*umv1.GetUserByIdResponse, error) {
+			return &umv1.GetUserByIdResponse{User: &umv1.User{Id: uuid.NewString(), Login: "mallory", Role: "user"}}, nil
+		}
+
+		_, err := storage.GetUserByLogin(context.Background(), "alice")
+		assert.ErrorIs(t, err, storageerrors.ErrInternal)
+	})
+}
+
+func TestGRPCUsersStorage_Status(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		_ = grpc.SetHeader(ctx, metadata.Pairs("x-server-version", "abc123", "x-schema-version", "20251016140000"))
+		return handler(ctx, req)
+	}))
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(srv, healthServer)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	storage := &usersgrpcstorage.GRPCUsersStorage{Log: slogdiscard.NewDiscardLogger(), Conn: conn}
+
+	got, err := storage.Status(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, models.BackendStatus{Serving: true, Version: "abc123", SchemaVersion: 20251016140000}, got)
+
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	got, err = storage.Status(context.Background())
+	require.NoError(t, err)
+	assert.False(t, got.Serving)
+}
@@ -0,0 +1,228 @@
+package usersmemorystorage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"apigateway/internal/domain/models"
+	storageerrors "apigateway/internal/storage"
+	"apigateway/pkg/lib/requestid"
+
+	"github.com/google/uuid"
+)
+
+// MemoryUsersStorage keeps users in process memory. It lets the gateway run
+// without UsersManager and Postgres for local development; nothing survives
+// a restart. Logins are unique case-insensitively, like in UsersManager.
+type MemoryUsersStorage struct {
+	Log *slog.Logger
+
+	mu    sync.RWMutex
+	users []models.User
+}
+
+// New creates an empty MemoryUsersStorage.
+func New(log *slog.Logger) *MemoryUsersStorage {
+	return &MemoryUsersStorage{Log: log}
+}
+
+// GetUsers returns all users in insertion order.
+func (m *MemoryUsersStorage) GetUsers(ctx context.Context) ([]models.User, error) {
+	const op = "storage.users.memory.GetUsers"
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return slices.Clone(m.users), nil
+}
+
+// GetUserById returns the user with the given id, or an error wrapping
+// storageerrors.ErrNotFound.
+func (m *MemoryUsersStorage) GetUserById(ctx context.Context, uid uuid.UUID) (models.User, error) {
+	const op = "storage.users.memory.GetUserById"
+
+	if err := ctx.Err(); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i := m.indexById(uid)
+	if i < 0 {
+		return models.User{}, fmt.Errorf("%s: %w", op, storageerrors.ErrNotFound)
+	}
+
+	return m.users[i], nil
+}
+
+// GetUserByLogin returns the user with the given login, compared
+// case-insensitively, or an error wrapping storageerrors.ErrNotFound.
+func (m *MemoryUsersStorage) GetUserByLogin(ctx context.Context, login string) (models.User, error) {
+	const op = "storage.users.memory.GetUserByLogin"
+
+	if err := ctx.Err(); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i := m.indexByLogin(login)
+	if i < 0 {
+		return models.User{}, fmt.Errorf("%s: %w", op, storageerrors.ErrNotFound)
+	}
+
+	return m.users[i], nil
+}
+
+// GetUsersByRole returns the users with the given role.
+func (m *MemoryUsersStorage) GetUsersByRole(ctx context.Context, role string) ([]models.User, error) {
+	const op = "storage.users.memory.GetUsersByRole"
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	filtered := make([]models.User, 0, len(m.users))
+	for _, user := range m.users {
+		if user.Role == role {
+			filtered = append(filtered, user)
+		}
+	}
+
+	return filtered, nil
+}
+
+// Insert stores a new user. Returns an error wrapping storageerrors.ErrDuplicateId
+// or ErrDuplicateLogin if the id or login is taken.
+func (m *MemoryUsersStorage) Insert(ctx context.Context, user models.User) (models.User, error) {
+	const op = "storage.users.memory.Insert"
+	log := m.Log.With("op", op, "request_id", requestid.FromContext(ctx))
+
+	if err := ctx.Err(); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.indexById(user.Id) >= 0 {
+		return models.User{}, fmt.Errorf("%s: %w", op, storageerrors.ErrDuplicateId)
+	}
+	if m.indexByLogin(user.Login) >= 0 {
+		return models.User{}, fmt.Errorf("%s: %w", op, storageerrors.ErrDuplicateLogin)
+	}
+
+	m.users = append(m.users, user)
+
+	log.Info("User inserted successfully", slog.String("user_id", user.Id.String()))
+	return user, nil
+}
+
+// InsertBatch stores users all at once: if one of them has a taken id or login,
+// none is stored and the returned error wraps a *storageerrors.BatchError for it.
+func (m *MemoryUsersStorage) InsertBatch(ctx context.Context, users []models.User) ([]models.User, error) {
+	const op = "storage.users.memory.InsertBatch"
+	log := m.Log.With("op", op, "request_id", requestid.FromContext(ctx))
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, user := range users {
+		var err error
+		switch {
+		case m.indexById(user.Id) >= 0 || slices.ContainsFunc(users[:i], func(u models.User) bool { return u.Id == user.Id }):
+			err = storageerrors.ErrDuplicateId
+		case m.indexByLogin(user.Login) >= 0 || slices.ContainsFunc(users[:i], func(u models.User) bool { return strings.EqualFold(u.Login, user.Login) }):
+			err = storageerrors.ErrDuplicateLogin
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, &storageerrors.BatchError{Index: i, Err: err})
+		}
+	}
+
+	m.users = append(m.users, users...)
+
+	log.Info("Users inserted successfully", slog.Int("count", len(users)))
+	return slices.Clone(users), nil
+}
+
+// Update replaces the user with the given id. Returns an error wrapping
+// storageerrors.ErrNotFound if there is none, or ErrDuplicateLogin if another
+// user has the new login.
+func (m *MemoryUsersStorage) Update(ctx context.Context, uid uuid.UUID, user models.User) (models.User, error) {
+	const op = "storage.users.memory.Update"
+	log := m.Log.With("op", op, "request_id", requestid.FromContext(ctx))
+
+	if err := ctx.Err(); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.indexById(uid)
+	if i < 0 {
+		return models.User{}, fmt.Errorf("%s: %w", op, storageerrors.ErrNotFound)
+	}
+	if j := m.indexByLogin(user.Login); j >= 0 && j != i {
+		return models.User{}, fmt.Errorf("%s: %w", op, storageerrors.ErrDuplicateLogin)
+	}
+
+	user.Id = uid
+	m.users[i] = user
+
+	log.Info("User updated successfully", slog.String("user_id", uid.String()))
+	return user, nil
+}
+
+// Delete removes the user with the given id and returns it. Returns an error
+// wrapping storageerrors.ErrNotFound if there is none.
+func (m *MemoryUsersStorage) Delete(ctx context.Context, uid uuid.UUID) (models.User, error) {
+	const op = "storage.users.memory.Delete"
+	log := m.Log.With("op", op, "request_id", requestid.FromContext(ctx))
+
+	if err := ctx.Err(); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.indexById(uid)
+	if i < 0 {
+		return models.User{}, fmt.Errorf("%s: %w", op, storageerrors.ErrNotFound)
+	}
+
+	deleted := m.users[i]
+	m.users = slices.Delete(m.users, i, i+1)
+
+	log.Info("User deleted successfully", slog.String("user_id", uid.String()))
+	return deleted, nil
+}
+
+// indexById returns the index of the user with uid, or -1. The caller holds mu.
+func (m *MemoryUsersStorage) indexById(uid uuid.UUID) int {
+	return slices.IndexFunc(m.users, func(u models.User) bool { return u.Id == uid })
+}
+
+// indexByLogin returns the index of the user with login, or -1. The caller holds mu.
+func (m *MemoryUsersStorage) indexByLogin(login string) int {
+	return slices.IndexFunc(m.users, func(u models.User) bool { return strings.EqualFold(u.Login, login) })
+}
@@ -0,0 +1,95 @@
+package usersmemorystorage_test
+
+import (
+	"context"
+	"testing"
+
+	"apigateway/internal/domain/models"
+	storageerrors "apigateway/internal/storage"
+	usersmemorystorage "apigateway/internal/storage/users/memory"
+	"apigateway/pkg/lib/logger/handler/slogdiscard"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryUsersStorage(t *testing.T) {
+	ctx := context.Background()
+	storage := usersmemorystorage.New(slogdiscard.NewDiscardLogger())
+
+	alice := models.User{Id: uuid.New(), Login: "alice", Password: "p", Role: models.RoleAdmin}
+	bob := models.User{Id: uuid.New(), Login: "bob", Password: "p", Role: models.RoleUser}
+	for _, user := range []models.User{alice, bob} {
+		_, err := storage.Insert(ctx, user)
+		require.NoError(t, err)
+	}
+
+	t.Run("duplicates", func(t *testing.T) {
+		_, err := storage.Insert(ctx, models.User{Id: alice.Id, Login: "carol"})
+		assert.ErrorIs(t, err, storageerrors.ErrDuplicateId)
+
+		_, err = storage.Insert(ctx, models.User{Id: uuid.New(), Login: "ALICE"})
+		assert.ErrorIs(t, err, storageerrors.ErrDuplicateLogin)
+
+		_, err = storage.Update(ctx, bob.Id, models.User{Login: "Alice"})
+		assert.ErrorIs(t, err, storageerrors.ErrDuplicateLogin)
+	})
+
+	t.Run("lookups", func(t *testing.T) {
+		users, err := storage.GetUsers(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []models.User{alice, bob}, users)
+
+		user, err := storage.GetUserByLogin(ctx, "BOB")
+		require.NoError(t, err)
+		assert.Equal(t, bob, user)
+
+		admins, err := storage.GetUsersByRole(ctx, models.RoleAdmin)
+		require.NoError(t, err)
+		assert.Equal(t, []models.User{alice}, admins)
+	})
+
+	t.Run("update keeps the id", func(t *testing.T) {
+		updated, err := storage.Update(ctx, bob.Id, models.User{Id: uuid.New(), Login: "robert", Role: models.RoleUser})
+		require.NoError(t, err)
+		assert.Equal(t, bob.Id, updated.Id)
+
+		user, err := storage.GetUserById(ctx, bob.Id)
+		require.NoError(t, err)
+		assert.Equal(t, "robert", user.Login)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		deleted, err := storage.Delete(ctx, alice.Id)
+		require.NoError(t, err)
+		assert.Equal(t, alice, deleted)
+
+		_, err = storage.GetUserById(ctx, alice.Id)
+		assert.ErrorIs(t, err, storageerrors.ErrNotFound)
+		_, err = storage.Delete(ctx, alice.Id)
+		assert.ErrorIs(t, err, storageerrors.ErrNotFound)
+		_, err = storage.Update(ctx, alice.Id, alice)
+		assert.ErrorIs(t, err, storageerrors.ErrNotFound)
+	})
+	t.Run("batch is all or nothing", func(t *testing.T) {
+		carol := models.User{Id: uuid.New(), Login: "carol", Password: "p", Role: models.RoleUser}
+		dave := models.User{Id: uuid.New(), Login: "dave", Password: "p", Role: models.RoleUser}
+
+		_, err := storage.InsertBatch(ctx, []models.User{carol, dave, {Id: uuid.New(), Login: "ROBERT"}})
+		assert.ErrorIs(t, err, storageerrors.ErrDuplicateLogin)
+		var batchErr *storageerrors.BatchError
+		require.ErrorAs(t, err, &batchErr)
+		assert.Equal(t, 2, batchErr.Index)
+
+		_, err = storage.GetUserByLogin(ctx, "carol")
+		assert.ErrorIs(t, err, storageerrors.ErrNotFound)
+
+		_, err = storage.InsertBatch(ctx, []models.User{carol, {Id: uuid.New(), Login: "Carol"}})
+		assert.ErrorIs(t, err, storageerrors.ErrDuplicateLogin)
+
+		inserted, err := storage.InsertBatch(ctx, []models.User{carol, dave})
+		require.NoError(t, err)
+		assert.Equal(t, []models.User{carol, dave}, inserted)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"log"
 	"os"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/joho/godotenv"
@@ -13,8 +14,109 @@ type Config struct {
 	Env  string `yaml:"env" env:"ENV" env-default:"local"`
 	Port int    `yaml:"port" env:"PORT" env-default:"8080"`
 
+	// RequestIDHeader is the header the request id is read from and echoed in, e.g. X-Correlation-Id.
+	// Without one, the trace id of a W3C traceparent header is used before a new id is generated.
+	RequestIDHeader string `env:"REQUEST_ID_HEADER" env-default:"X-Request-Id"`
+
+	// UsersStorageType selects where users live: "grpc" talks to UsersManager, "memory" keeps
+	// them in the gateway process so the API runs on its own for local development.
+	UsersStorageType string `env:"USERS_STORAGE_TYPE" env-default:"grpc"`
+
 	UsersStorageHost string `env:"USERS_STORAGE_HOST" env-default:"user_service"`
 	UsersStoragePort int    `env:"USERS_STORAGE_PORT" env-default:"50051"`
+
+	// UsersStorageTLS dials UsersManager over TLS, verifying its certificate against
+	// UsersStorageTLSCAFile or, if empty, the system roots. UsersStorageTLSCertFile and
+	// UsersStorageTLSKeyFile are the client certificate UsersManager requires for mutual TLS.
+	// UsersStorageTLSServerName overrides the name its certificate is checked against.
+	UsersStorageTLS           bool   `env:"USERS_STORAGE_TLS" env-default:"false"`
+	UsersStorageTLSCAFile     string `env:"USERS_STORAGE_TLS_CA_FILE"`
+	UsersStorageTLSCertFile   string `env:"USERS_STORAGE_TLS_CERT_FILE"`
+	UsersStorageTLSKeyFile    string `env:"USERS_STORAGE_TLS_KEY_FILE"`
+	UsersStorageTLSServerName string `env:"USERS_STORAGE_TLS_SERVER_NAME"`
+
+	// UsersStorageBlockingConnect makes startup fail if UsersManager is not reachable within UsersStorageDialTimeout.
+	UsersStorageBlockingConnect bool          `env:"USERS_STORAGE_BLOCKING_CONNECT" env-default:"false"`
+	UsersStorageDialTimeout     time.Duration `env:"USERS_STORAGE_DIAL_TIMEOUT" env-default:"5s"`
+
+	// UsersStorageBreakerThreshold is how many consecutive backend failures open the circuit breaker.
+	// While open, user calls fail fast with 503 for UsersStorageBreakerOpenTimeout. Zero disables the breaker.
+	UsersStorageBreakerThreshold   int           `env:"USERS_STORAGE_BREAKER_THRESHOLD" env-default:"5"`
+	UsersStorageBreakerOpenTimeout time.Duration `env:"USERS_STORAGE_BREAKER_OPEN_TIMEOUT" env-default:"30s"`
+
+	// StrictUsersDecoding fails the users list if the backend returns a malformed user.
+	StrictUsersDecoding bool `env:"STRICT_USERS_DECODING" env-default:"false"`
+
+	// PprofPort is the internal port serving /debug/pprof in local and dev environments.
+	PprofPort int `env:"PPROF_PORT" env-default:"6060"`
+
+	// MaxInFlight caps concurrently served API requests; extra requests get 503. Zero disables the cap.
+	MaxInFlight int `env:"MAX_IN_FLIGHT" env-default:"512"`
+
+	// LoginAvailabilityLimit caps login availability and validate checks together per client IP
+	// and LoginAvailabilityWindow. Zero disables the limit.
+	LoginAvailabilityLimit  int           `env:"LOGIN_AVAILABILITY_LIMIT" env-default:"10"`
+	LoginAvailabilityWindow time.Duration `env:"LOGIN_AVAILABILITY_WINDOW" env-default:"1m"`
+
+	// MaxURILength and MaxQueryParamLength bound the request URI and each query value; longer requests get 414.
+	MaxURILength        int `env:"MAX_URI_LENGTH" env-default:"4096"`
+	MaxQueryParamLength int `env:"MAX_QUERY_PARAM_LENGTH" env-default:"1024"`
+
+	// MaxHeaderBytes bounds the size of the request header block; larger requests get 431.
+	MaxHeaderBytes int `env:"MAX_HEADER_BYTES" env-default:"1048576"`
+
+	// PanicReportURL receives a JSON record for every recovered panic. Empty disables reporting.
+	PanicReportURL     string        `env:"PANIC_REPORT_URL"`
+	PanicReportTimeout time.Duration `env:"PANIC_REPORT_TIMEOUT" env-default:"2s"`
+
+	// ShutdownDelay is how long /readyz reports not ready before the server stops accepting
+	// requests, so load balancers can drain the instance. ShutdownTimeout then bounds waiting
+	// for in-flight requests.
+	ShutdownDelay   time.Duration `env:"SHUTDOWN_DELAY" env-default:"0s"`
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" env-default:"15s"`
+
+	// BodyReadTimeout bounds reading the request body of write endpoints.
+	BodyReadTimeout time.Duration `env:"BODY_READ_TIMEOUT" env-default:"10s"`
+	// MaxBodyBytes caps the request body of write endpoints; larger bodies get 413.
+	// A non-positive value disables the limit.
+	MaxBodyBytes int64 `env:"MAX_BODY_BYTES" env-default:"10485760"`
+
+	// UserCacheMaxAge is the private Cache-Control max-age of successful user fetches.
+	UserCacheMaxAge time.Duration `env:"USER_CACHE_MAX_AGE" env-default:"30s"`
+
+	// SecurityHeaders are added to every API response, as "Name:value" pairs separated by commas.
+	SecurityHeaders map[string]string `env:"SECURITY_HEADERS" env-default:"X-Content-Type-Options:nosniff,X-Frame-Options:DENY"`
+
+	// GzipMinSize is the smallest response body, in bytes, compressed for clients accepting gzip.
+	// A negative value disables compression.
+	GzipMinSize int `env:"GZIP_MIN_SIZE" env-default:"1024"`
+	// GzipContentTypes lists the compressed content types, e.g. "application/json,text/*".
+	// Empty uses the defaults.
+	GzipContentTypes []string `env:"GZIP_CONTENT_TYPES" env-separator:","`
+
+	// DisabledRoutes lists route names, e.g. "users.insert", that are not registered.
+	DisabledRoutes []string `env:"DISABLED_ROUTES" env-separator:","`
+
+	// FeatureFlags switches experimental routes on by route name, e.g. "users.import:true".
+	// Experimental routes that are not switched on answer 404.
+	FeatureFlags map[string]bool `env:"FEATURE_FLAGS" env-separator:","`
+
+	// ListEnvelope wraps list responses in {"data":...,"meta":...} instead of a bare array.
+	ListEnvelope bool `env:"LIST_ENVELOPE" env-default:"false"`
+
+	// ListDefaultLimit is the page size of list responses when the client sends no limit.
+	// ListMaxLimit is the hard cap on a requested limit; larger limits are clamped and flagged
+	// with a Warning header. Zero disables either.
+	ListDefaultLimit int `env:"LIST_DEFAULT_LIMIT" env-default:"100"`
+	ListMaxLimit     int `env:"LIST_MAX_LIMIT" env-default:"1000"`
+
+	// ListDefaultSort orders list responses when the client sends no sort, e.g. "role,-login".
+	// The id is always appended as the final tiebreaker.
+	ListDefaultSort string `env:"LIST_DEFAULT_SORT" env-default:"login"`
+
+	// MaxBatchSize is the most items a bulk endpoint, e.g. the CSV import, accepts per request.
+	// Zero disables the limit.
+	MaxBatchSize int `env:"MAX_BATCH_SIZE" env-default:"500"`
 }
 
 func MustLoad() *Config {
@@ -5,3 +5,9 @@ const (
 	EnvDev   = "dev"
 	EnvProd  = "prod"
 )
+
+// Users storage types accepted in UsersStorageType.
+const (
+	StorageGRPC   = "grpc"
+	StorageMemory = "memory"
+)
@@ -0,0 +1,58 @@
+package actor
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RoleAdmin is the role allowed to act on any user.
+const RoleAdmin = "admin"
+
+// gRPC metadata keys carrying the actor to backends.
+const (
+	MetadataKeyId   = "x-actor-id"
+	MetadataKeyRole = "x-actor-role"
+)
+
+// Actor is the authenticated user a request is made on behalf of.
+type Actor struct {
+	Id   uuid.UUID
+	Role string
+}
+
+// IsAdmin reports whether the actor has the admin role.
+func (a Actor) IsAdmin() bool {
+	return a.Role == RoleAdmin
+}
+
+type ctxKey struct{}
+
+// WithActor returns a copy of ctx carrying the actor.
+func WithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, ctxKey{}, a)
+}
+
+// FromContext returns the actor stored in ctx and whether one was set.
+func FromContext(ctx context.Context) (Actor, bool) {
+	a, ok := ctx.Value(ctxKey{}).(Actor)
+	return a, ok
+}
+
+// actorToMetadata returns the metadata pairs forwarding a to backends.
+func actorToMetadata(a Actor) []string {
+	return []string{MetadataKeyId, a.Id.String(), MetadataKeyRole, a.Role}
+}
+
+// UnaryClientInterceptor forwards the actor from the context as outgoing gRPC metadata,
+// so backends can audit and authorize calls on its behalf.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if a, ok := FromContext(ctx); ok {
+			ctx = metadata.AppendToOutgoingContext(ctx, actorToMetadata(a)...)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
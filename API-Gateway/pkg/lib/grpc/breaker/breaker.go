@@ -0,0 +1,202 @@
+package breaker
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// State is the state of a Breaker.
+type State int64
+
+const (
+	// StateClosed lets all calls through.
+	StateClosed State = iota
+	// StateOpen rejects all calls until the open timeout has passed.
+	StateOpen
+	// StateHalfOpen lets a single probe call through to test recovery.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerState exposes the state of the most recently changed breaker: 0 closed, 1 open, 2 half-open.
+var BreakerState = expvar.NewInt("grpc_breaker_state")
+
+// Breaker is a circuit breaker for gRPC calls to a failing backend.
+// It opens after threshold consecutive failures, rejects calls while open and,
+// once openTimeout has passed, lets one probe through: a successful probe
+// closes it, a failed one opens it again.
+type Breaker struct {
+	mu          sync.Mutex
+	threshold   int
+	openTimeout time.Duration
+	state       State
+	failures    int
+	openedAt    time.Time
+	probing     bool
+	// generation changes with every state change, so outcomes of calls let
+	// through in an earlier state cannot settle the current one.
+	generation uint64
+
+	now func() time.Time
+}
+
+// New creates a closed breaker. A non-positive threshold disables it.
+func New(threshold int, openTimeout time.Duration) *Breaker {
+	return &Breaker{
+		threshold:   threshold,
+		openTimeout: openTimeout,
+		now:         time.Now,
+	}
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.openTimeout {
+		return StateHalfOpen
+	}
+
+	return b.state
+}
+
+// UnaryClientInterceptor short-circuits calls with codes.Unavailable while the
+// breaker is open. The rejection carries a google.rpc.RetryInfo with the time
+// left until the breaker half-opens.
+func (b *Breaker) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if b.threshold <= 0 {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		t, retryAfter, ok := b.allow()
+		if !ok {
+			return openError(retryAfter)
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.record(t, isFailure(err))
+		return err
+	}
+}
+
+// ticket identifies a call let through by allow. Only the holder of the probe
+// ticket may settle a half-open breaker.
+type ticket struct {
+	generation uint64
+	probe      bool
+}
+
+// allow reports whether a call may go through and hands out its ticket. While
+// half-open only one probe is in flight at a time. If the call may not go
+// through, it returns the time left until the breaker half-opens.
+func (b *Breaker) allow() (ticket, time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		left := b.openTimeout - b.now().Sub(b.openedAt)
+		if left > 0 {
+			return ticket{}, left, false
+		}
+		b.setState(StateHalfOpen)
+		b.probing = true
+		return ticket{generation: b.generation, probe: true}, 0, true
+	case StateHalfOpen:
+		if b.probing {
+			return ticket{}, b.openTimeout, false
+		}
+		b.probing = true
+		return ticket{generation: b.generation, probe: true}, 0, true
+	default:
+		return ticket{generation: b.generation}, 0, true
+	}
+}
+
+// record updates the breaker with the outcome of a call that went through.
+// Outcomes of calls let through before the last state change are ignored.
+func (b *Breaker) record(t ticket, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if t.generation != b.generation {
+		return
+	}
+
+	if t.probe {
+		b.probing = false
+		if failed {
+			b.open()
+		} else {
+			b.failures = 0
+			b.setState(StateClosed)
+		}
+		return
+	}
+
+	if !failed {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.open()
+	}
+}
+
+func (b *Breaker) open() {
+	b.failures = 0
+	b.openedAt = b.now()
+	b.setState(StateOpen)
+}
+
+func (b *Breaker) setState(s State) {
+	b.state = s
+	b.generation++
+	BreakerState.Set(int64(s))
+}
+
+// isFailure reports whether err means the backend is unhealthy,
+// as opposed to a rejected request.
+func isFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown:
+		return true
+	default:
+		return false
+	}
+}
+
+func openError(retryAfter time.Duration) error {
+	st := status.New(codes.Unavailable, "circuit breaker is open")
+
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
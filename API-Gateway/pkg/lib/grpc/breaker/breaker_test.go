@@ -0,0 +1,212 @@
+package breaker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"apigateway/pkg/lib/grpc/breaker"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// call runs a call through the interceptor against a backend answering with code
+// and reports whether the backend was reached.
+func call(t *testing.T, interceptor grpc.UnaryClientInterceptor, code codes.Code) (bool, error) {
+	t.Helper()
+
+	reached := false
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		reached = true
+		return status.Error(code, "backend")
+	}
+
+	err := interceptor(context.Background(), "/users/Get", nil, nil, nil, invoker)
+	return reached, err
+}
+
+func TestBreaker_OpensAfterThreshold(t *testing.T) {
+	cb := breaker.New(3, time.Minute)
+	interceptor := cb.UnaryClientInterceptor()
+
+	for i := 0; i < 3; i++ {
+		reached, _ := call(t, interceptor, codes.Unavailable)
+		require.True(t, reached)
+	}
+	assert.Equal(t, breaker.StateOpen, cb.State())
+	assert.EqualValues(t, breaker.StateOpen, breaker.BreakerState.Value())
+
+	reached, err := call(t, interceptor, codes.OK)
+	assert.False(t, reached, "open breaker must not call the backend")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	st, _ := status.FromError(err)
+	require.Len(t, st.Details(), 1)
+	retry, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	assert.Greater(t, retry.GetRetryDelay().AsDuration(), time.Duration(0))
+}
+
+func TestBreaker_IgnoresRequestErrors(t *testing.T) {
+	cb := breaker.New(2, time.Minute)
+	interceptor := cb.UnaryClientInterceptor()
+
+	for i := 0; i < 5; i++ {
+		call(t, interceptor, codes.NotFound)
+		call(t, interceptor, codes.InvalidArgument)
+	}
+	assert.Equal(t, breaker.StateClosed, cb.State())
+
+	// A success resets the count of consecutive failures.
+	call(t, interceptor, codes.Unavailable)
+	call(t, interceptor, codes.OK)
+	call(t, interceptor, codes.Unavailable)
+	assert.Equal(t, breaker.StateClosed, cb.State())
+}
+
+func TestBreaker_HalfOpenProbe(t *testing.T) {
+	const openTimeout = 20 * time.Millisecond
+
+	t.Run("successful probe closes", func(t *testing.T) {
+		cb := breaker.New(1, openTimeout)
+		interceptor := cb.UnaryClientInterceptor()
+
+		call(t, interceptor, codes.Unavailable)
+		require.Equal(t, breaker.StateOpen, cb.State())
+
+		time.Sleep(openTimeout)
+		assert.Equal(t, breaker.StateHalfOpen, cb.State())
+
+		reached, _ := call(t, interceptor, codes.OK)
+		assert.True(t, reached)
+		assert.Equal(t, breaker.StateClosed, cb.State())
+	})
+
+	t.Run("failed probe reopens", func(t *testing.T) {
+		cb := breaker.New(1, openTimeout)
+		interceptor := cb.UnaryClientInterceptor()
+
+		call(t, interceptor, codes.Unavailable)
+		time.Sleep(openTimeout)
+
+		reached, _ := call(t, interceptor, codes.Unavailable)
+		assert.True(t, reached)
+		assert.Equal(t, breaker.StateOpen, cb.State())
+
+		reached, _ = call(t, interceptor, codes.OK)
+		assert.False(t, reached)
+	})
+}
+
+// blockingCall starts a call through the interceptor that reaches the backend and
+// waits for release before answering with code. It returns the channel the
+// call's error is sent on, and reports on entered once the backend is reached.
+func blockingCall(interceptor grpc.UnaryClientInterceptor, entered chan<- struct{}, release <-chan struct{}, code codes.Code) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			entered <- struct{}{}
+			<-release
+			return status.Error(code, "backend")
+		}
+		done <- interceptor(context.Background(), "/users/Get", nil, nil, nil, invoker)
+	}()
+	return done
+}
+
+func TestBreaker_HalfOpenSingleProbe(t *testing.T) {
+	const (
+		openTimeout = 20 * time.Millisecond
+		callers     = 20
+	)
+
+	cb := breaker.New(1, openTimeout)
+	interceptor := cb.UnaryClientInterceptor()
+
+	call(t, interceptor, codes.Unavailable)
+	time.Sleep(openTimeout)
+
+	entered := make(chan struct{}, callers)
+	release := make(chan struct{})
+	results := make([]<-chan error, callers)
+	for i := range results {
+		results[i] = blockingCall(interceptor, entered, release, codes.OK)
+	}
+
+	select {
+	case <-entered:
+	case <-time.After(time.Second):
+		t.Fatal("no probe reached the backend")
+	}
+
+	// Every caller but the probe is rejected while the probe is in flight.
+	rejected := 0
+	var probe <-chan error
+	for _, done := range results {
+		select {
+		case err := <-done:
+			assert.Equal(t, codes.Unavailable, status.Code(err))
+			rejected++
+		case <-time.After(100 * time.Millisecond):
+			probe = done
+		}
+	}
+	assert.Equal(t, callers-1, rejected)
+	assert.Len(t, entered, 0, "only one probe may reach the backend")
+
+	close(release)
+	require.NotNil(t, probe)
+	assert.NoError(t, <-probe)
+	assert.Equal(t, breaker.StateClosed, cb.State())
+}
+
+func TestBreaker_StaleCallDoesNotSettleProbe(t *testing.T) {
+	const openTimeout = 20 * time.Millisecond
+
+	cb := breaker.New(2, openTimeout)
+	interceptor := cb.UnaryClientInterceptor()
+
+	// A slow call goes through while the breaker is still closed.
+	entered := make(chan struct{}, 2)
+	releaseSlow := make(chan struct{})
+	slow := blockingCall(interceptor, entered, releaseSlow, codes.OK)
+	<-entered
+
+	call(t, interceptor, codes.Unavailable)
+	call(t, interceptor, codes.Unavailable)
+	require.Equal(t, breaker.StateOpen, cb.State())
+	time.Sleep(openTimeout)
+
+	releaseProbe := make(chan struct{})
+	probe := blockingCall(interceptor, entered, releaseProbe, codes.OK)
+	<-entered
+
+	// The slow call finishing must not be taken for the probe's outcome.
+	close(releaseSlow)
+	assert.NoError(t, <-slow)
+	assert.Equal(t, breaker.StateHalfOpen, cb.State())
+
+	reached, err := call(t, interceptor, codes.OK)
+	assert.False(t, reached, "a second probe must not reach the backend")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	close(releaseProbe)
+	assert.NoError(t, <-probe)
+	assert.Equal(t, breaker.StateClosed, cb.State())
+}
+
+func TestBreaker_Disabled(t *testing.T) {
+	cb := breaker.New(0, time.Minute)
+	interceptor := cb.UnaryClientInterceptor()
+
+	for i := 0; i < 10; i++ {
+		reached, _ := call(t, interceptor, codes.Unavailable)
+		require.True(t, reached)
+	}
+	assert.Equal(t, breaker.StateClosed, cb.State())
+}
@@ -3,41 +3,162 @@ package grpchelper
 import (
 	storageerrors "apigateway/internal/storage"
 	"apigateway/pkg/lib/logger/sl"
+	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// Machine-readable reasons reported by UsersManager in google.rpc.ErrorInfo.
+const (
+	ReasonCanceled          = "CANCELED"
+	ReasonInvalidId         = "INVALID_ID"
+	ReasonInvalidUser       = "INVALID_USER"
+	ReasonUserNotFound      = "USER_NOT_FOUND"
+	ReasonUserAlreadyExists = "USER_ALREADY_EXISTS"
+	ReasonInternal          = "INTERNAL"
+	ReasonRateLimited       = "RATE_LIMITED"
+)
+
+// MetadataField is the ErrorInfo metadata key naming the field that caused a conflict.
+const MetadataField = "field"
+
+// MetadataIndex is the ErrorInfo metadata key holding the position of the user
+// that failed a batch operation.
+const MetadataIndex = "index"
+
+// DetailedError is a storage error enriched with the google.rpc.ErrorInfo
+// reported by the backend. It unwraps to the matching storageerrors sentinel.
+type DetailedError struct {
+	Reason   string
+	Domain   string
+	Metadata map[string]string
+	// RetryDelay is how long the caller should wait before retrying, from google.rpc.RetryInfo.
+	RetryDelay time.Duration
+	Err        error
+}
+
+func (e *DetailedError) Error() string {
+	if e.Reason == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s (%s)", e.Err.Error(), e.Reason)
+}
+
+// RetryAfter returns the retry delay suggested by the backend, or zero if there is none.
+func (e *DetailedError) RetryAfter() time.Duration {
+	return e.RetryDelay
+}
+
+func (e *DetailedError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorInfo extracts the backend error details from err, if any.
+func ErrorInfo(err error) (*DetailedError, bool) {
+	var detailed *DetailedError
+	if errors.As(err, &detailed) {
+		return detailed, true
+	}
+
+	return nil, false
+}
+
 func GrpcErrorHelper(log *slog.Logger, op string, err error) error {
 	if st, ok := status.FromError(err); ok {
+		var storageErr error
+		info := errorInfoFromStatus(st)
+
 		switch st.Code() {
 		case codes.Canceled:
 			log.Warn("Context cancelled", sl.Err(err))
-			return fmt.Errorf("%s: %w", op, storageerrors.ErrContextCanceled)
+			storageErr = storageerrors.ErrContextCanceled
 
 		case codes.DeadlineExceeded:
 			log.Warn("Deadline exeeced", sl.Err(err))
-			return fmt.Errorf("%s: %w", op, storageerrors.ErrDeadlineExeeced)
+			storageErr = storageerrors.ErrDeadlineExeeced
 
 		case codes.InvalidArgument:
 			log.Warn("Invalid arguments", sl.Err(err))
-			return fmt.Errorf("%s: %w", op, storageerrors.ErrInvalidArgument)
+			storageErr = storageerrors.ErrInvalidArgument
 
 		case codes.AlreadyExists:
 			log.Warn("Record with given ID already exists", sl.Err(err))
-			return fmt.Errorf("%s: %w", op, storageerrors.ErrAlreadyExists)
+			storageErr = duplicateError(info)
 
 		case codes.NotFound:
 			log.Warn("Record not found", sl.Err(err))
-			return fmt.Errorf("%s: %w", op, storageerrors.ErrNotFound)
+			storageErr = storageerrors.ErrNotFound
+
+		case codes.Unavailable:
+			log.Warn("Backend unavailable", sl.Err(err))
+			storageErr = storageerrors.ErrUnavailable
+
+		case codes.ResourceExhausted:
+			// A rate limit is temporary and clears by itself, unlike an oversized response.
+			if info.GetReason() == ReasonRateLimited {
+				log.Warn("Rate limited by backend", sl.Err(err))
+				storageErr = storageerrors.ErrUnavailable
+				break
+			}
+			log.Error("Response exceeds resource limits", sl.Err(err))
+			storageErr = storageerrors.ErrResourceExhausted
 
 		default:
 			log.Error("Failed to carry out work with record ", sl.Err(err))
-			return fmt.Errorf("%s: %w", op, storageerrors.ErrInternal)
+			storageErr = storageerrors.ErrInternal
 		}
+
+		retryDelay := retryDelayFromStatus(st)
+		if info != nil || retryDelay > 0 {
+			return fmt.Errorf("%s: %w", op, &DetailedError{
+				Reason:     info.GetReason(),
+				Domain:     info.GetDomain(),
+				Metadata:   info.GetMetadata(),
+				RetryDelay: retryDelay,
+				Err:        storageErr,
+			})
+		}
+
+		return fmt.Errorf("%s: %w", op, storageErr)
 	} else {
 		return fmt.Errorf("%s: %w", op, storageerrors.ErrInternal)
 	}
 }
+
+// duplicateError returns the duplicate error for the field named in info,
+// or storageerrors.ErrAlreadyExists if no field is reported.
+func duplicateError(info *errdetails.ErrorInfo) error {
+	switch info.GetMetadata()[MetadataField] {
+	case "login":
+		return storageerrors.ErrDuplicateLogin
+	case "id":
+		return storageerrors.ErrDuplicateId
+	default:
+		return storageerrors.ErrAlreadyExists
+	}
+}
+
+func errorInfoFromStatus(st *status.Status) *errdetails.ErrorInfo {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+
+	return nil
+}
+
+func retryDelayFromStatus(st *status.Status) time.Duration {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			return info.GetRetryDelay().AsDuration()
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,90 @@
+package panicreport_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"apigateway/pkg/lib/panicreport"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPReporter(t *testing.T) {
+	records := make(chan panicreport.Record, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rec panicreport.Record
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&rec))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		records <- rec
+	}))
+	defer srv.Close()
+
+	reporter := panicreport.New(srv.URL, "apigateway", time.Second)
+	reporter.Report(errors.New("panic: boom"), []byte("goroutine 1 [running]:"))
+
+	select {
+	case rec := <-records:
+		assert.Equal(t, "apigateway", rec.Service)
+		assert.Equal(t, "panic: boom", rec.Error)
+		assert.Equal(t, "goroutine 1 [running]:", rec.Stack)
+		assert.False(t, rec.Time.IsZero())
+	case <-time.After(time.Second):
+		require.Fail(t, "no record was posted")
+	}
+}
+
+func TestHTTPReporter_DoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	reporter := panicreport.New(srv.URL, "apigateway", time.Minute)
+
+	start := time.Now()
+	for range panicreport.MaxInFlight + 2 {
+		reporter.Report(errors.New("panic: boom"), nil)
+	}
+
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+	assert.Eventually(t, func() bool { return requests.Load() == panicreport.MaxInFlight }, time.Second, 10*time.Millisecond)
+	assert.Never(t, func() bool { return requests.Load() > panicreport.MaxInFlight }, 100*time.Millisecond, 10*time.Millisecond)
+}
+
+func TestHTTPReporter_Timeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The server only notices the client going away once the body is read.
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+			close(done)
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	reporter := panicreport.New(srv.URL, "apigateway", 50*time.Millisecond)
+	reporter.Report(errors.New("panic: boom"), nil)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.Fail(t, "the report was not cancelled after the timeout")
+	}
+}
+
+func TestNew_WithoutEndpoint(t *testing.T) {
+	assert.Equal(t, panicreport.Nop{}, panicreport.New("", "apigateway", time.Second))
+}
@@ -0,0 +1,25 @@
+package patch
+
+import (
+	"encoding/json"
+)
+
+// OptionalString is a string field of a partial update. A field absent from the
+// JSON document leaves Set false; a present field, including an empty string or
+// null, sets Set. null is captured as an empty Value, e.g. to clear the field.
+type OptionalString struct {
+	Set   bool
+	Value string
+}
+
+// UnmarshalJSON is only called for fields present in the document.
+func (o *OptionalString) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	o.Value = ""
+
+	if string(data) == "null" {
+		return nil
+	}
+
+	return json.Unmarshal(data, &o.Value)
+}
@@ -0,0 +1,40 @@
+package patch_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"apigateway/pkg/lib/patch"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptionalString(t *testing.T) {
+	type request struct {
+		Login patch.OptionalString `json:"login"`
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want patch.OptionalString
+	}{
+		{"absent", `{}`, patch.OptionalString{}},
+		{"null", `{"login":null}`, patch.OptionalString{Set: true}},
+		{"empty string", `{"login":""}`, patch.OptionalString{Set: true}},
+		{"value", `{"login":"alice"}`, patch.OptionalString{Set: true, Value: "alice"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got request
+			assert.NoError(t, json.Unmarshal([]byte(tt.body), &got))
+			assert.Equal(t, tt.want, got.Login)
+		})
+	}
+
+	t.Run("not a string", func(t *testing.T) {
+		var got request
+		assert.Error(t, json.Unmarshal([]byte(`{"login":42}`), &got))
+	})
+}
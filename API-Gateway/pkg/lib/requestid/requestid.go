@@ -0,0 +1,39 @@
+package requestid
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// Header is the HTTP header carrying the request id.
+	Header = "X-Request-Id"
+	// MetadataKey is the gRPC metadata key carrying the request id to backends.
+	MetadataKey = "x-request-id"
+)
+
+type ctxKey struct{}
+
+// WithID returns a copy of ctx carrying the request id.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the request id stored in ctx, or an empty string.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// UnaryClientInterceptor forwards the request id from the context as outgoing gRPC metadata.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if id := FromContext(ctx); id != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, MetadataKey, id)
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
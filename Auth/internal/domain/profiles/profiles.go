@@ -2,11 +2,15 @@ package profiles
 
 import (
 	"auth/internal/domain/models"
+	"errors"
 
 	umv1 "github.com/chas3air/protos/gen/go/usersManager"
 	"github.com/google/uuid"
 )
 
+var ErrNilUser = errors.New("proto user is nil")
+
+// UsrToProtoUsr converts a domain user into its protobuf representation.
 func UsrToProtoUsr(user models.User) *umv1.User {
 	return &umv1.User{
 		Id:       user.Id.String(),
@@ -16,7 +20,14 @@ func UsrToProtoUsr(user models.User) *umv1.User {
 	}
 }
 
+// ProtoUsrToUsr converts a protobuf user into a domain user.
+// Only the id is required; fields missing from the message are left at their zero values.
+// Returns ErrNilUser if proto_usr is nil.
 func ProtoUsrToUsr(proto_usr *umv1.User) (models.User, error) {
+	if proto_usr == nil {
+		return models.User{}, ErrNilUser
+	}
+
 	parsedUUID, err := uuid.Parse(proto_usr.GetId())
 	if err != nil {
 		return models.User{}, err
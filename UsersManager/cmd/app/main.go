@@ -1,14 +1,24 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 	"usersmanager/internal/app"
+	"usersmanager/internal/app/dbhealth"
+	grpcapp "usersmanager/internal/app/grpc"
+	maintenanceservice "usersmanager/internal/service/maintenance"
 	userspsqlstorage "usersmanager/internal/storage/users/psql"
 	"usersmanager/pkg/config"
 	"usersmanager/pkg/lib/logger"
+	"usersmanager/pkg/lib/mtls"
+	"usersmanager/pkg/lib/panicreport"
+	"usersmanager/pkg/lib/ratelimit"
+	"usersmanager/pkg/lib/serverinfo"
+
+	"google.golang.org/grpc/keepalive"
 )
 
 func main() {
@@ -18,18 +28,68 @@ func main() {
 
 	log.Info("application", slog.Any("config", config))
 
-	psqlStorage := userspsqlstorage.New(log, config.PsqlConnStr, config.PsqlUsersTableName)
+	psqlStorage := userspsqlstorage.New(log, config.PsqlConnStr, config.PsqlUsersTableName,
+		userspsqlstorage.WithTxRetries(config.PsqlTxRetries),
+		userspsqlstorage.WithConnMaxLifetime(config.PsqlConnMaxLifetime),
+		userspsqlstorage.WithStatementTimeout(config.PsqlStatementTimeout),
+		userspsqlstorage.WithWarmupConns(config.PsqlWarmupConns),
+	)
+
+	// Migrations only run at startup, so the version is read once.
+	schemaVersion, err := psqlStorage.MigrationVersion(context.Background())
+	if err != nil {
+		panic(err)
+	}
 
-	application := app.New(log, config.Port, psqlStorage)
+	limiter := ratelimit.New(config.GRPCMethodLimits, config.GRPCRateLimitWindow)
+	grpcOpts := []grpcapp.Option{
+		grpcapp.WithMaxConcurrentStreams(config.GRPCMaxConcurrentStreams),
+		grpcapp.WithRateLimiter(limiter),
+		grpcapp.WithKeepalive(
+			keepalive.ServerParameters{
+				MaxConnectionIdle: config.GRPCMaxConnectionIdle,
+				MaxConnectionAge:  config.GRPCMaxConnectionAge,
+			},
+			keepalive.EnforcementPolicy{
+				MinTime:             config.GRPCKeepaliveMinTime,
+				PermitWithoutStream: true,
+			},
+		),
+		grpcapp.WithPanicReporter(panicreport.New(config.PanicReportURL, "usersmanager", config.PanicReportTimeout)),
+		grpcapp.WithServerInfo(serverinfo.Info{Version: serverinfo.BuildVersion(), SchemaVersion: schemaVersion}),
+		grpcapp.WithMaintenance(maintenanceservice.New(log, psqlStorage)),
+	}
+	if config.LogPayloads {
+		grpcOpts = append(grpcOpts, grpcapp.WithPayloadLogging())
+	}
+	if config.TLSEnabled() {
+		policy, err := config.TLSPolicy()
+		if err != nil {
+			panic(err)
+		}
+		creds, err := mtls.ServerCredentials(config.TLSCertFile, config.TLSKeyFile, config.TLSClientCAFile, policy)
+		if err != nil {
+			panic(err)
+		}
+		grpcOpts = append(grpcOpts, grpcapp.WithTransportCredentials(creds), grpcapp.WithAllowedClients(config.AllowedClientCNs))
+	}
+
+	application := app.New(log, config.Port, psqlStorage, grpcOpts...)
 
 	go func() {
 		application.GRPCApp.MustRun()
 	}()
 
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	dbWatcher := dbhealth.New(log, psqlStorage, application.GRPCApp.Health(), config.DBHealthInterval)
+	go dbWatcher.Run(watchCtx)
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
 	<-stop
 
+	// Stop the DB watcher first so it cannot report SERVING again while draining.
+	stopWatch()
+	application.GRPCApp.Shutdown(config.ShutdownDelay)
 	psqlStorage.Close()
-	application.GRPCApp.Stop()
 }
@@ -17,14 +17,17 @@ type App struct {
 type IUsersStorage interface {
 	GetUsers(ctx context.Context) ([]models.User, error)
 	GetUserById(ctx context.Context, uid uuid.UUID) (models.User, error)
+	GetUsersByRole(ctx context.Context, role models.Role) ([]models.User, error)
+	GetUserByLogin(ctx context.Context, login string) (models.User, error)
 	Insert(ctx context.Context, user models.User) (models.User, error)
+	InsertBatch(ctx context.Context, users []models.User) ([]models.User, error)
 	Update(ctx context.Context, uid uuid.UUID, user models.User) (models.User, error)
 	Delete(ctx context.Context, uid uuid.UUID) (models.User, error)
 }
 
-func New(log *slog.Logger, port int, usersStorage IUsersStorage) *App {
+func New(log *slog.Logger, port int, usersStorage IUsersStorage, opts ...grpcapp.Option) *App {
 	usersService := usersservice.New(log, usersStorage)
-	grpcApp := grpcapp.New(log, usersService, port)
+	grpcApp := grpcapp.New(log, usersService, port, opts...)
 
 	return &App{
 		GRPCApp: grpcApp,
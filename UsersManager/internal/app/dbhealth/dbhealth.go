@@ -0,0 +1,85 @@
+package dbhealth
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"usersmanager/pkg/lib/logger/sl"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Pinger checks that the database is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// StatusSetter receives serving status updates, e.g. *health.Server.
+type StatusSetter interface {
+	SetServingStatus(service string, status healthpb.HealthCheckResponse_ServingStatus)
+}
+
+// Watcher pings the database periodically and reports health transitions.
+type Watcher struct {
+	log      *slog.Logger
+	pinger   Pinger
+	status   StatusSetter
+	interval time.Duration
+	healthy  bool
+}
+
+// New creates a Watcher. The database is assumed healthy until the first failed ping.
+func New(log *slog.Logger, pinger Pinger, status StatusSetter, interval time.Duration) *Watcher {
+	return &Watcher{
+		log:      log,
+		pinger:   pinger,
+		status:   status,
+		interval: interval,
+		healthy:  true,
+	}
+}
+
+// Run pings the database every interval until ctx is done.
+func (w *Watcher) Run(ctx context.Context) {
+	const op = "dbhealth.Run"
+	log := w.log.With("op", op)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	log.Info("Starting DB health watcher", slog.Duration("interval", w.interval))
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("Stopping DB health watcher")
+			return
+		case <-ticker.C:
+			w.Check(ctx)
+		}
+	}
+}
+
+// Check pings the database once and updates the serving status on a transition.
+// Returns whether the database is healthy.
+func (w *Watcher) Check(ctx context.Context) bool {
+	const op = "dbhealth.Check"
+	log := w.log.With("op", op)
+
+	pingCtx, cancel := context.WithTimeout(ctx, w.interval)
+	defer cancel()
+
+	err := w.pinger.Ping(pingCtx)
+	switch {
+	case err != nil && w.healthy:
+		log.Warn("DB became unreachable", sl.Err(err))
+		w.healthy = false
+		w.status.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	case err == nil && !w.healthy:
+		log.Info("DB recovered")
+		w.healthy = true
+		w.status.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	}
+
+	return w.healthy
+}
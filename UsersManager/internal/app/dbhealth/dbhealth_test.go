@@ -0,0 +1,70 @@
+package dbhealth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"usersmanager/internal/app/dbhealth"
+	"usersmanager/pkg/lib/logger/handler/slogdiscard"
+
+	"github.com/stretchr/testify/assert"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type scriptedPinger struct {
+	results []error
+	calls   int
+}
+
+func (p *scriptedPinger) Ping(ctx context.Context) error {
+	err := p.results[p.calls%len(p.results)]
+	p.calls++
+	return err
+}
+
+type recordingStatus struct {
+	statuses []healthpb.HealthCheckResponse_ServingStatus
+}
+
+func (r *recordingStatus) SetServingStatus(service string, status healthpb.HealthCheckResponse_ServingStatus) {
+	r.statuses = append(r.statuses, status)
+}
+
+func TestWatcher_Check(t *testing.T) {
+	errDown := errors.New("connection refused")
+	pinger := &scriptedPinger{results: []error{nil, errDown, errDown, nil, nil, errDown}}
+	status := &recordingStatus{}
+	watcher := dbhealth.New(slogdiscard.NewDiscardLogger(), pinger, status, time.Second)
+
+	var healthy []bool
+	for range pinger.results {
+		healthy = append(healthy, watcher.Check(context.Background()))
+	}
+
+	assert.Equal(t, []bool{true, false, false, true, true, false}, healthy)
+	assert.Equal(t, []healthpb.HealthCheckResponse_ServingStatus{
+		healthpb.HealthCheckResponse_NOT_SERVING,
+		healthpb.HealthCheckResponse_SERVING,
+		healthpb.HealthCheckResponse_NOT_SERVING,
+	}, status.statuses)
+}
+
+func TestWatcher_RunStopsOnCancel(t *testing.T) {
+	watcher := dbhealth.New(slogdiscard.NewDiscardLogger(), &scriptedPinger{results: []error{nil}}, &recordingStatus{}, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		watcher.Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watcher did not stop after cancel")
+	}
+}
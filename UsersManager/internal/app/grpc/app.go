@@ -5,38 +5,200 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"time"
 	"usersmanager/internal/domain/models"
 	usersgrpc "usersmanager/internal/grpc/users"
+	"usersmanager/pkg/lib/actor"
+	"usersmanager/pkg/lib/deadline"
+	"usersmanager/pkg/lib/mtls"
+	"usersmanager/pkg/lib/panicreport"
+	"usersmanager/pkg/lib/payloadlog"
+	"usersmanager/pkg/lib/ratelimit"
+	"usersmanager/pkg/lib/requestid"
+	"usersmanager/pkg/lib/serverinfo"
+	"usersmanager/pkg/lib/validation"
 
 	"github.com/google/uuid"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
 )
 
 type App struct {
-	log        *slog.Logger
-	gRPCServer *grpc.Server
-	port       int
+	log          *slog.Logger
+	gRPCServer   *grpc.Server
+	healthServer *health.Server
+	port         int
 }
 
 type IUsersService interface {
 	GetUsers(ctx context.Context) ([]models.User, error)
 	GetUserById(ctx context.Context, uid uuid.UUID) (models.User, error)
+	GetUsersByRole(ctx context.Context, role models.Role) ([]models.User, error)
+	GetUserByLogin(ctx context.Context, login string) (models.User, error)
 	Insert(ctx context.Context, user models.User) (models.User, error)
+	InsertBatch(ctx context.Context, users []models.User) ([]models.User, error)
 	Update(ctx context.Context, uid uuid.UUID, user models.User) (models.User, error)
 	Delete(ctx context.Context, uid uuid.UUID) (models.User, error)
 }
 
-func New(log *slog.Logger, usersService IUsersService, port int) *App {
-	gRPCServer := grpc.NewServer()
+// Option configures the gRPC server built by New.
+type Option func(*options)
+
+type options struct {
+	maxStreams     uint32
+	limiter        *ratelimit.Limiter
+	creds          credentials.TransportCredentials
+	allowedClients []string
+	keepalive      *keepalive.ServerParameters
+	enforcement    *keepalive.EnforcementPolicy
+	reporter       panicreport.PanicReporter
+	info           *serverinfo.Info
+	logPayloads    bool
+	maintenance    usersgrpc.IMaintenanceService
+
+	// interceptors run right before the deadline check; only tests set them.
+	interceptors []grpc.UnaryServerInterceptor
+}
+
+// WithMaxConcurrentStreams caps the concurrent streams (calls) a single client
+// connection may have open; extra calls wait for a free stream instead of
+// consuming server resources. Zero keeps the gRPC default.
+func WithMaxConcurrentStreams(n uint32) Option {
+	return func(o *options) {
+		o.maxStreams = n
+	}
+}
+
+// WithRateLimiter limits calls per method and peer with limiter.
+func WithRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(o *options) {
+		o.limiter = limiter
+	}
+}
+
+// WithTransportCredentials serves gRPC with creds, e.g. TLS or mutual TLS.
+func WithTransportCredentials(creds credentials.TransportCredentials) Option {
+	return func(o *options) {
+		o.creds = creds
+	}
+}
+
+// WithAllowedClients only lets through clients whose verified certificate common
+// name is in cns. It needs mutual TLS credentials; an empty list allows all clients.
+func WithAllowedClients(cns []string) Option {
+	return func(o *options) {
+		o.allowedClients = cns
+	}
+}
+
+// WithKeepalive closes idle and old client connections according to params and
+// closes connections of clients that ping more often than policy allows.
+func WithKeepalive(params keepalive.ServerParameters, policy keepalive.EnforcementPolicy) Option {
+	return func(o *options) {
+		o.keepalive = &params
+		o.enforcement = &policy
+	}
+}
+
+// WithPanicReporter sends panics recovered from handlers to reporter.
+func WithPanicReporter(reporter panicreport.PanicReporter) Option {
+	return func(o *options) {
+		o.reporter = reporter
+	}
+}
+
+// WithServerInfo sends info as response header metadata with every call.
+func WithServerInfo(info serverinfo.Info) Option {
+	return func(o *options) {
+		o.info = &info
+	}
+}
+
+// WithPayloadLogging logs request and response payloads at debug level with
+// user passwords cleared. Payloads can be large, so only enable it for debugging.
+func WithPayloadLogging() Option {
+	return func(o *options) {
+		o.logPayloads = true
+	}
+}
+
+// WithMaintenance serves the admin-only maintenance operations of service,
+// e.g. VACUUM ANALYZE of the users table.
+func WithMaintenance(service usersgrpc.IMaintenanceService) Option {
+	return func(o *options) {
+		o.maintenance = service
+	}
+}
+
+// New builds the gRPC server.
+func New(log *slog.Logger, usersService IUsersService, port int, opts ...Option) *App {
+	o := options{reporter: panicreport.Nop{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	interceptors := []grpc.UnaryServerInterceptor{
+		requestid.UnaryServerInterceptor(),
+		panicreport.UnaryServerInterceptor(log, o.reporter),
+		actor.UnaryServerInterceptor(),
+	}
+	if o.logPayloads {
+		interceptors = append(interceptors, payloadlog.UnaryServerInterceptor(log))
+	}
+	if o.info != nil {
+		interceptors = append(interceptors, serverinfo.UnaryServerInterceptor(*o.info))
+	}
+	if len(o.allowedClients) > 0 {
+		interceptors = append(interceptors, mtls.UnaryServerInterceptor(o.allowedClients))
+	}
+	if o.limiter != nil {
+		interceptors = append(interceptors, o.limiter.UnaryServerInterceptor(usersgrpc.ErrorDomain))
+	}
+	interceptors = append(interceptors, validation.UnaryServerInterceptor(usersgrpc.Validators()))
+	interceptors = append(interceptors, o.interceptors...)
+	interceptors = append(interceptors, deadline.UnaryServerInterceptor())
+
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(interceptors...),
+	}
+	if o.maxStreams > 0 {
+		serverOpts = append(serverOpts, grpc.MaxConcurrentStreams(o.maxStreams))
+	}
+	if o.creds != nil {
+		serverOpts = append(serverOpts, grpc.Creds(o.creds))
+	}
+	if o.keepalive != nil {
+		serverOpts = append(serverOpts,
+			grpc.KeepaliveParams(*o.keepalive),
+			grpc.KeepaliveEnforcementPolicy(*o.enforcement),
+		)
+	}
+
+	gRPCServer := grpc.NewServer(serverOpts...)
 	usersgrpc.Register(gRPCServer, log, usersService)
+	if o.maintenance != nil {
+		usersgrpc.RegisterMaintenance(gRPCServer, log, o.maintenance)
+	}
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(gRPCServer, healthServer)
 
 	return &App{
-		log:        log,
-		gRPCServer: gRPCServer,
-		port:       port,
+		log:          log,
+		gRPCServer:   gRPCServer,
+		healthServer: healthServer,
+		port:         port,
 	}
 }
 
+// Health returns the gRPC health server so its serving status can be updated.
+func (a *App) Health() *health.Server {
+	return a.healthServer
+}
+
 func (a *App) MustRun() {
 	if err := a.Run(); err != nil {
 		panic(err)
@@ -57,13 +219,32 @@ func (a *App) Run() error {
 
 	log.Info("Starting grpc server")
 
-	if err := a.gRPCServer.Serve(l); err != nil {
+	if err := a.Serve(l); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	return nil
 }
 
+// Serve accepts connections on l until the server is stopped.
+func (a *App) Serve(l net.Listener) error {
+	return a.gRPCServer.Serve(l)
+}
+
+// Shutdown drains the server: health checks report NOT_SERVING at once while
+// calls are still served for delay, then the server stops gracefully.
+func (a *App) Shutdown(delay time.Duration) {
+	const op = "grpcapp.Shutdown"
+	log := a.log.With("op", op)
+
+	a.healthServer.Shutdown()
+	log.Info("Draining", slog.Duration("delay", delay))
+	time.Sleep(delay)
+
+	a.Stop()
+}
+
 func (a *App) Stop() {
+	a.healthServer.Shutdown()
 	a.gRPCServer.GracefulStop()
 }
@@ -0,0 +1,709 @@
+package grpcapp_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	grpcapp "usersmanager/internal/app/grpc"
+	"usersmanager/internal/domain/models"
+	usersgrpc "usersmanager/internal/grpc/users"
+	maintenanceservice "usersmanager/internal/service/maintenance"
+	"usersmanager/pkg/lib/actor"
+	"usersmanager/pkg/lib/logger/handler/slogdiscard"
+	"usersmanager/pkg/lib/mtls"
+	"usersmanager/pkg/lib/panicreport"
+	"usersmanager/pkg/lib/ratelimit"
+	"usersmanager/pkg/lib/serverinfo"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	umv1 "github.com/chas3air/protos/gen/go/usersManager"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// actorRecordingService records the actor in the context of GetUserById calls.
+type actorRecordingService struct {
+	blockingUsersService
+	actor    actor.Actor
+	hasActor bool
+}
+
+func (s *actorRecordingService) GetUserById(ctx context.Context, _ uuid.UUID) (models.User, error) {
+	s.actor, s.hasActor = actor.FromContext(ctx)
+	return models.User{}, nil
+}
+
+// panickingService panics in GetUserById.
+type panickingService struct {
+	blockingUsersService
+}
+
+func (s *panickingService) GetUserById(context.Context, uuid.UUID) (models.User, error) {
+	panic("boom")
+}
+
+// countingService counts the GetUserById calls that reach it.
+type countingService struct {
+	blockingUsersService
+	calls atomic.Int32
+}
+
+func (s *countingService) GetUserById(context.Context, uuid.UUID) (models.User, error) {
+	s.calls.Add(1)
+	return models.User{}, nil
+}
+
+// blockingUsersService holds GetUsers calls open until release is closed
+// and counts the writes that reach it.
+type blockingUsersService struct {
+	entered chan struct{}
+	release chan struct{}
+	writes  atomic.Int32
+}
+
+func (s *blockingUsersService) GetUsers(ctx context.Context) ([]models.User, error) {
+	s.entered <- struct{}{}
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return []models.User{}, nil
+}
+
+func (s *blockingUsersService) GetUserById(context.Context, uuid.UUID) (models.User, error) {
+	return models.User{}, nil
+}
+
+func (s *blockingUsersService) GetUsersByRole(context.Context, models.Role) ([]models.User, error) {
+	return []models.User{}, nil
+}
+
+func (s *blockingUsersService) GetUserByLogin(context.Context, string) (models.User, error) {
+	return models.User{}, nil
+}
+
+func (s *blockingUsersService) Insert(_ context.Context, user models.User) (models.User, error) {
+	s.writes.Add(1)
+	return user, nil
+}
+
+func (s *blockingUsersService) InsertBatch(_ context.Context, users []models.User) ([]models.User, error) {
+	s.writes.Add(1)
+	return users, nil
+}
+
+func (s *blockingUsersService) Update(_ context.Context, _ uuid.UUID, user models.User) (models.User, error) {
+	s.writes.Add(1)
+	return user, nil
+}
+
+func (s *blockingUsersService) Delete(context.Context, uuid.UUID) (models.User, error) {
+	return models.User{}, nil
+}
+
+func newBufconnClient(t *testing.T, application *grpcapp.App) umv1.UsersManagerClient {
+	lis := serveBufconn(t, application)
+	return dialBufconn(t, lis, insecure.NewCredentials())
+}
+
+func serveBufconn(t *testing.T, application *grpcapp.App) *bufconn.Listener {
+	lis := bufconn.Listen(1024 * 1024)
+	go func() { _ = application.Serve(lis) }()
+	t.Cleanup(application.Stop)
+	return lis
+}
+
+func dialBufconn(t *testing.T, lis *bufconn.Listener, creds credentials.TransportCredentials) umv1.UsersManagerClient {
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(creds),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return umv1.NewUsersManagerClient(conn)
+}
+
+func TestApp_MaxConcurrentStreams(t *testing.T) {
+	svc := &blockingUsersService{
+		entered: make(chan struct{}, 2),
+		release: make(chan struct{}),
+	}
+
+	application := grpcapp.New(slogdiscard.NewDiscardLogger(), svc, 0, grpcapp.WithMaxConcurrentStreams(1))
+	client := newBufconnClient(t, application)
+
+	firstDone := make(chan error, 1)
+	go func() {
+		_, err := client.GetUsers(context.Background(), &umv1.GetUsersRequest{})
+		firstDone <- err
+	}()
+
+	select {
+	case <-svc.entered:
+	case <-time.After(2 * time.Second):
+		t.Fatal("first call did not reach the service")
+	}
+
+	// The only stream is taken, so the second call waits for it instead of
+	// reaching the service.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err := client.GetUsers(ctx, &umv1.GetUsersRequest{})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Len(t, svc.entered, 0)
+
+	close(svc.release)
+	require.NoError(t, <-firstDone)
+
+	// Once the stream is released new calls go through again.
+	_, err = client.GetUsers(context.Background(), &umv1.GetUsersRequest{})
+	assert.NoError(t, err)
+}
+
+func TestApp_RateLimit(t *testing.T) {
+	svc := &blockingUsersService{
+		entered: make(chan struct{}, 10),
+		release: make(chan struct{}),
+	}
+	close(svc.release)
+
+	limiter := ratelimit.New(map[string]int{"GetUsers": 2}, time.Minute)
+	application := grpcapp.New(slogdiscard.NewDiscardLogger(), svc, 0, grpcapp.WithRateLimiter(limiter))
+	client := newBufconnClient(t, application)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := client.GetUsers(ctx, &umv1.GetUsersRequest{})
+		require.NoError(t, err)
+	}
+
+	_, err := client.GetUsers(ctx, &umv1.GetUsersRequest{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// The rejection says why and when to retry, unlike an oversized response.
+	var info *errdetails.ErrorInfo
+	var retry *errdetails.RetryInfo
+	for _, detail := range status.Convert(err).Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			info = d
+		case *errdetails.RetryInfo:
+			retry = d
+		}
+	}
+	require.NotNil(t, info)
+	assert.Equal(t, ratelimit.ReasonRateLimited, info.GetReason())
+	assert.Equal(t, usersgrpc.ErrorDomain, info.GetDomain())
+	require.NotNil(t, retry)
+	assert.Positive(t, retry.GetRetryDelay().AsDuration())
+	assert.LessOrEqual(t, retry.GetRetryDelay().AsDuration(), time.Minute)
+
+	// Methods without a limit are not affected.
+	_, err = client.GetUserById(ctx, &umv1.GetUserByIdRequest{Id: uuid.NewString()})
+	assert.NotEqual(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestApp_ValidationInterceptor(t *testing.T) {
+	svc := &blockingUsersService{}
+	application := grpcapp.New(slogdiscard.NewDiscardLogger(), svc, 0)
+	client := newBufconnClient(t, application)
+	ctx := context.Background()
+
+	valid := &umv1.User{Id: uuid.NewString(), Login: "user1", Password: "secret", Role: "user"}
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"insert without user", func() error {
+			_, err := client.Insert(ctx, &umv1.InsertRequest{})
+			return err
+		}},
+		{"insert without login", func() error {
+			_, err := client.Insert(ctx, &umv1.InsertRequest{User: &umv1.User{Id: uuid.NewString(), Password: "secret", Role: "user"}})
+			return err
+		}},
+		{"insert with unknown role", func() error {
+			_, err := client.Insert(ctx, &umv1.InsertRequest{User: &umv1.User{Id: uuid.NewString(), Login: "user1", Password: "secret", Role: "root"}})
+			return err
+		}},
+		{"update with bad id", func() error {
+			_, err := client.Update(ctx, &umv1.UpdateRequest{Id: "bad-uuid", User: valid})
+			return err
+		}},
+		{"update without password", func() error {
+			_, err := client.Update(ctx, &umv1.UpdateRequest{Id: valid.GetId(), User: &umv1.User{Id: valid.GetId(), Login: "user1", Role: "user"}})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, codes.InvalidArgument, status.Code(tt.call()))
+		})
+	}
+	assert.Zero(t, svc.writes.Load(), "invalid requests must not reach the service")
+
+	_, err := client.Insert(ctx, &umv1.InsertRequest{User: valid})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, svc.writes.Load())
+}
+
+func TestApp_ShutdownDrain(t *testing.T) {
+	application := grpcapp.New(slogdiscard.NewDiscardLogger(), &blockingUsersService{}, 0)
+	lis := serveBufconn(t, application)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	health := healthpb.NewHealthClient(conn)
+	client := umv1.NewUsersManagerClient(conn)
+	ctx := context.Background()
+	req := &umv1.GetUserByIdRequest{Id: uuid.NewString()}
+
+	resp, err := health.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	done := make(chan struct{})
+	go func() {
+		application.Shutdown(300 * time.Millisecond)
+		close(done)
+	}()
+
+	// During the drain window health checks fail but calls are still served.
+	assert.Eventually(t, func() bool {
+		resp, err := health.Check(ctx, &healthpb.HealthCheckRequest{})
+		return err == nil && resp.GetStatus() == healthpb.HealthCheckResponse_NOT_SERVING
+	}, time.Second, 10*time.Millisecond)
+	_, err = client.GetUserById(ctx, req)
+	assert.NoError(t, err)
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("shutdown did not finish")
+	}
+
+	_, err = client.GetUserById(ctx, req)
+	assert.Error(t, err)
+}
+
+func TestApp_ActorMetadata(t *testing.T) {
+	svc := &actorRecordingService{}
+	application := grpcapp.New(slogdiscard.NewDiscardLogger(), svc, 0)
+	client := newBufconnClient(t, application)
+	req := &umv1.GetUserByIdRequest{Id: uuid.NewString()}
+
+	t.Run("actor reaches the handler context", func(t *testing.T) {
+		id := uuid.New()
+		ctx := metadata.AppendToOutgoingContext(context.Background(),
+			actor.MetadataKeyId, id.String(),
+			actor.MetadataKeyRole, "admin",
+		)
+
+		_, err := client.GetUserById(ctx, req)
+		require.NoError(t, err)
+		assert.True(t, svc.hasActor)
+		assert.Equal(t, actor.Actor{Id: id, Role: "admin"}, svc.actor)
+	})
+
+	t.Run("no actor", func(t *testing.T) {
+		_, err := client.GetUserById(context.Background(), req)
+		require.NoError(t, err)
+		assert.False(t, svc.hasActor)
+	})
+
+	t.Run("malformed actor id", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), actor.MetadataKeyId, "not-a-uuid")
+
+		_, err := client.GetUserById(ctx, req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+// vacuumCountingStorage counts the VACUUM ANALYZE runs that reach it.
+type vacuumCountingStorage struct {
+	calls atomic.Int32
+}
+
+func (s *vacuumCountingStorage) VacuumAnalyze(context.Context) error {
+	s.calls.Add(1)
+	return nil
+}
+
+func TestApp_Maintenance(t *testing.T) {
+	storage := &vacuumCountingStorage{}
+	log := slogdiscard.NewDiscardLogger()
+	application := grpcapp.New(log, &countingService{}, 0,
+		grpcapp.WithMaintenance(maintenanceservice.New(log, storage)),
+	)
+	lis := serveBufconn(t, application)
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	vacuum := func(ctx context.Context) error {
+		return conn.Invoke(ctx, usersgrpc.MaintenanceVacuumAnalyzeFullMethodName, &emptypb.Empty{}, &emptypb.Empty{})
+	}
+
+	t.Run("admin actor", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(),
+			actor.MetadataKeyId, uuid.NewString(),
+			actor.MetadataKeyRole, "admin",
+		)
+
+		require.NoError(t, vacuum(ctx))
+		assert.Equal(t, int32(1), storage.calls.Load())
+	})
+
+	t.Run("no actor", func(t *testing.T) {
+		err := vacuum(context.Background())
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.Equal(t, int32(1), storage.calls.Load())
+	})
+}
+
+func TestApp_PanicReporter(t *testing.T) {
+	var (
+		reported error
+		stack    []byte
+	)
+	reporter := panicreport.ReporterFunc(func(err error, s []byte) {
+		reported, stack = err, s
+	})
+
+	application := grpcapp.New(slogdiscard.NewDiscardLogger(), &panickingService{}, 0, grpcapp.WithPanicReporter(reporter))
+	client := newBufconnClient(t, application)
+
+	_, err := client.GetUserById(context.Background(), &umv1.GetUserByIdRequest{Id: uuid.NewString()})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.EqualError(t, reported, "panic: boom")
+	assert.Contains(t, string(stack), "panickingService")
+
+	// The server keeps serving after a panic.
+	_, err = client.Delete(context.Background(), &umv1.DeleteRequest{Id: uuid.NewString()})
+	assert.NoError(t, err)
+}
+
+func TestApp_ServerInfo(t *testing.T) {
+	info := serverinfo.Info{Version: "abc123", SchemaVersion: 20251016140000}
+	application := grpcapp.New(slogdiscard.NewDiscardLogger(), &blockingUsersService{}, 0, grpcapp.WithServerInfo(info))
+	client := newBufconnClient(t, application)
+
+	var header metadata.MD
+	_, err := client.GetUserById(context.Background(), &umv1.GetUserByIdRequest{Id: uuid.NewString()}, grpc.Header(&header))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"abc123"}, header.Get(serverinfo.MetadataKeyVersion))
+	assert.Equal(t, []string{"20251016140000"}, header.Get(serverinfo.MetadataKeySchemaVersion))
+}
+
+func TestApp_PayloadLogging(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	application := grpcapp.New(log, &blockingUsersService{}, 0, grpcapp.WithPayloadLogging())
+	client := newBufconnClient(t, application)
+
+	user := &umv1.User{Id: uuid.NewString(), Login: "user1", Password: "s3cr3t-password", Role: "user"}
+	_, err := client.Insert(context.Background(), &umv1.InsertRequest{User: user})
+	require.NoError(t, err)
+	_, err = client.Update(context.Background(), &umv1.UpdateRequest{Id: user.GetId(), User: user})
+	require.NoError(t, err)
+
+	logs := buf.String()
+	assert.Equal(t, 2, strings.Count(logs, `"msg":"Request payload"`))
+	assert.Equal(t, 2, strings.Count(logs, `"msg":"Response payload"`))
+	assert.Contains(t, logs, "user1")
+	assert.NotContains(t, logs, "s3cr3t-password")
+	// The caller's message is not modified.
+	assert.Equal(t, "s3cr3t-password", user.GetPassword())
+}
+
+func TestApp_ExpiredDeadline(t *testing.T) {
+	// Hold calls back until their deadline has passed, as if queued behind slow work,
+	// and record what the server answers; the client only sees its own deadline.
+	served := make(chan error, 1)
+	queued := func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		dl, _ := ctx.Deadline()
+		time.Sleep(time.Until(dl))
+		resp, err := handler(ctx, req)
+		served <- err
+		return resp, err
+	}
+
+	svc := &countingService{}
+	application := grpcapp.New(slogdiscard.NewDiscardLogger(), svc, 0, grpcapp.WithInterceptor(queued))
+	client := newBufconnClient(t, application)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetUserById(ctx, &umv1.GetUserByIdRequest{Id: uuid.NewString()})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(<-served))
+	assert.Zero(t, svc.calls.Load(), "handler ran after the deadline")
+
+	// Calls within their deadline still reach the handler.
+	application = grpcapp.New(slogdiscard.NewDiscardLogger(), svc, 0)
+	client = newBufconnClient(t, application)
+
+	_, err = client.GetUserById(context.Background(), &umv1.GetUserByIdRequest{Id: uuid.NewString()})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), svc.calls.Load())
+}
+
+// closeNotifyListener reports when the server closes an accepted connection.
+type closeNotifyListener struct {
+	net.Listener
+	closed chan struct{}
+}
+
+func (l *closeNotifyListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &closeNotifyConn{Conn: conn, closed: l.closed}, nil
+}
+
+type closeNotifyConn struct {
+	net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (c *closeNotifyConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return c.Conn.Close()
+}
+
+func TestApp_KeepaliveClosesIdleConnections(t *testing.T) {
+	application := grpcapp.New(slogdiscard.NewDiscardLogger(), &blockingUsersService{}, 0,
+		grpcapp.WithKeepalive(
+			keepalive.ServerParameters{MaxConnectionIdle: 100 * time.Millisecond},
+			keepalive.EnforcementPolicy{MinTime: time.Minute},
+		),
+	)
+
+	lis := bufconn.Listen(1024 * 1024)
+	notify := &closeNotifyListener{Listener: lis, closed: make(chan struct{})}
+	go func() { _ = application.Serve(notify) }()
+	t.Cleanup(application.Stop)
+
+	client := dialBufconn(t, lis, insecure.NewCredentials())
+	_, err := client.GetUserById(context.Background(), &umv1.GetUserByIdRequest{Id: uuid.NewString()})
+	require.NoError(t, err)
+
+	select {
+	case <-notify.closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("idle connection was not closed")
+	}
+}
+
+// testCA issues certificates for the mTLS tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pool: pool,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue returns a certificate with the given common name signed by the CA,
+// in PEM form, for server or client use.
+func (ca *testCA) issue(t *testing.T, cn string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestApp_MTLSAllowedClients(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+
+	serverCert, serverKey := ca.issue(t, "bufnet", x509.ExtKeyUsageServerAuth)
+	serverCreds, err := mtls.ServerCredentials(
+		writeFile(t, dir, "server.crt", serverCert),
+		writeFile(t, dir, "server.key", serverKey),
+		writeFile(t, dir, "ca.crt", ca.pem),
+		mtls.Policy{},
+	)
+	require.NoError(t, err)
+
+	application := grpcapp.New(slogdiscard.NewDiscardLogger(), &blockingUsersService{}, 0,
+		grpcapp.WithTransportCredentials(serverCreds),
+		grpcapp.WithAllowedClients([]string{"apigateway"}),
+	)
+	lis := serveBufconn(t, application)
+
+	clientFor := func(t *testing.T, cn string) umv1.UsersManagerClient {
+		certPEM, keyPEM := ca.issue(t, cn, x509.ExtKeyUsageClientAuth)
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		require.NoError(t, err)
+
+		return dialBufconn(t, lis, credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{cert},
+			RootCAs:      ca.pool,
+			ServerName:   "bufnet",
+		}))
+	}
+
+	req := &umv1.GetUserByIdRequest{Id: uuid.NewString()}
+
+	t.Run("allowed subject", func(t *testing.T) {
+		_, err := clientFor(t, "apigateway").GetUserById(context.Background(), req)
+		assert.NoError(t, err)
+	})
+
+	t.Run("disallowed subject", func(t *testing.T) {
+		_, err := clientFor(t, "intruder").GetUserById(context.Background(), req)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("no client certificate", func(t *testing.T) {
+		client := dialBufconn(t, lis, credentials.NewTLS(&tls.Config{RootCAs: ca.pool, ServerName: "bufnet"}))
+		_, err := client.GetUserById(context.Background(), req)
+		assert.Error(t, err)
+		assert.NotEqual(t, codes.OK, status.Code(err))
+	})
+}
+
+func TestApp_TLSMinVersion(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+
+	serverCert, serverKey := ca.issue(t, "bufnet", x509.ExtKeyUsageServerAuth)
+	certFile := writeFile(t, dir, "server.crt", serverCert)
+	keyFile := writeFile(t, dir, "server.key", serverKey)
+
+	policy, err := mtls.ParsePolicy("1.3", nil)
+	require.NoError(t, err)
+
+	cfg, err := mtls.ServerConfig(certFile, keyFile, "", policy)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), cfg.MinVersion)
+
+	serverCreds, err := mtls.ServerCredentials(certFile, keyFile, "", policy)
+	require.NoError(t, err)
+	application := grpcapp.New(slogdiscard.NewDiscardLogger(), &blockingUsersService{}, 0,
+		grpcapp.WithTransportCredentials(serverCreds),
+	)
+	lis := serveBufconn(t, application)
+	req := &umv1.GetUserByIdRequest{Id: uuid.NewString()}
+
+	t.Run("TLS 1.3 client", func(t *testing.T) {
+		client := dialBufconn(t, lis, credentials.NewTLS(&tls.Config{RootCAs: ca.pool, ServerName: "bufnet"}))
+		_, err := client.GetUserById(context.Background(), req)
+		assert.NoError(t, err)
+	})
+
+	t.Run("TLS 1.2 client", func(t *testing.T) {
+		client := dialBufconn(t, lis, credentials.NewTLS(&tls.Config{RootCAs: ca.pool, ServerName: "bufnet", MaxVersion: tls.VersionTLS12}))
+		_, err := client.GetUserById(context.Background(), req)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
@@ -0,0 +1,11 @@
+package grpcapp
+
+import "google.golang.org/grpc"
+
+// WithInterceptor runs interceptor right before the deadline check, e.g. to
+// hold calls back until their deadline has passed.
+func WithInterceptor(interceptor grpc.UnaryServerInterceptor) Option {
+	return func(o *options) {
+		o.interceptors = append(o.interceptors, interceptor)
+	}
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Role is the role of a user. Only the constants below are valid roles.
+type Role string
+
+// Roles a user can have.
+const (
+	RoleUser  Role = "user"
+	RoleAdmin Role = "admin"
+)
+
+var ErrUnknownRole = errors.New("unknown role")
+
+// ParseRole converts s into a Role.
+// Returns an error wrapping ErrUnknownRole if s is not a known role.
+func ParseRole(s string) (Role, error) {
+	role := Role(s)
+	if !role.IsValid() {
+		return "", fmt.Errorf("%w: %q", ErrUnknownRole, s)
+	}
+
+	return role, nil
+}
+
+// IsValid reports whether r is one of the known roles.
+func (r Role) IsValid() bool {
+	return r == RoleUser || r == RoleAdmin
+}
+
+func (r Role) String() string {
+	return string(r)
+}
+
+// Scan implements sql.Scanner. Unknown roles stored in the database are rejected;
+// the users_role_check constraint keeps them out of the table in the first place.
+func (r *Role) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrUnknownRole, src)
+	}
+
+	role, err := ParseRole(s)
+	if err != nil {
+		return err
+	}
+
+	*r = role
+	return nil
+}
+
+// Value implements driver.Valuer, so unknown roles never reach the database.
+func (r Role) Value() (driver.Value, error) {
+	if !r.IsValid() {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownRole, string(r))
+	}
+
+	return string(r), nil
+}
+
+func (r Role) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(r))
+}
+
+// UnmarshalJSON implements json.Unmarshaler and rejects unknown roles.
+func (r *Role) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	role, err := ParseRole(s)
+	if err != nil {
+		return err
+	}
+
+	*r = role
+	return nil
+}
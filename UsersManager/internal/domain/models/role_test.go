@@ -0,0 +1,56 @@
+package models_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"usersmanager/internal/domain/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRole_Scan(t *testing.T) {
+	t.Run("known role", func(t *testing.T) {
+		var role models.Role
+		require.NoError(t, role.Scan([]byte("admin")))
+		assert.Equal(t, models.RoleAdmin, role)
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		var role models.Role
+		assert.ErrorIs(t, role.Scan("superuser"), models.ErrUnknownRole)
+		assert.Empty(t, role)
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+		var role models.Role
+		assert.ErrorIs(t, role.Scan(nil), models.ErrUnknownRole)
+	})
+}
+
+func TestRole_Value(t *testing.T) {
+	v, err := models.RoleUser.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "user", v)
+
+	_, err = models.Role("superuser").Value()
+	assert.ErrorIs(t, err, models.ErrUnknownRole)
+}
+
+func TestRole_JSON(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		data, err := json.Marshal(models.RoleAdmin)
+		require.NoError(t, err)
+		assert.JSONEq(t, `"admin"`, string(data))
+
+		var role models.Role
+		require.NoError(t, json.Unmarshal(data, &role))
+		assert.Equal(t, models.RoleAdmin, role)
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		var role models.Role
+		assert.ErrorIs(t, json.Unmarshal([]byte(`"superuser"`), &role), models.ErrUnknownRole)
+	})
+}
@@ -6,5 +6,5 @@ type User struct {
 	Id       uuid.UUID
 	Login    string
 	Password string
-	Role     string
+	Role     Role
 }
@@ -1,31 +1,64 @@
 package profiles
 
 import (
+	"errors"
 	"usersmanager/internal/domain/models"
 
 	umv1 "github.com/chas3air/protos/gen/go/usersManager"
 	"github.com/google/uuid"
 )
 
+var ErrNilUser = errors.New("proto user is nil")
+
+// UsrToProtoUsr converts a domain user into its protobuf representation.
 func UsrToProtoUsr(user models.User) *umv1.User {
 	return &umv1.User{
 		Id:       user.Id.String(),
 		Login:    user.Login,
 		Password: user.Password,
-		Role:     user.Role,
+		Role:     RoleToProto(user.Role),
 	}
 }
 
+// RoleToProto converts a domain role into its protobuf representation.
+func RoleToProto(role models.Role) string {
+	return role.String()
+}
+
+// RoleFromProto converts a protobuf role into a domain role.
+// An empty role is left unset; any other unknown role returns an error
+// wrapping models.ErrUnknownRole.
+func RoleFromProto(role string) (models.Role, error) {
+	if role == "" {
+		return "", nil
+	}
+
+	return models.ParseRole(role)
+}
+
+// ProtoUsrToUsr converts a protobuf user into a domain user.
+// Only the id is required; fields missing from the message are left at their zero values.
+// Returns ErrNilUser if proto_usr is nil and an error wrapping models.ErrUnknownRole
+// if it carries an unknown role.
 func ProtoUsrToUsr(proto_usr *umv1.User) (models.User, error) {
+	if proto_usr == nil {
+		return models.User{}, ErrNilUser
+	}
+
 	parsedUUID, err := uuid.Parse(proto_usr.GetId())
 	if err != nil {
 		return models.User{}, err
 	}
 
+	role, err := RoleFromProto(proto_usr.GetRole())
+	if err != nil {
+		return models.User{}, err
+	}
+
 	return models.User{
 		Id:       parsedUUID,
 		Login:    proto_usr.GetLogin(),
 		Password: proto_usr.GetPassword(),
-		Role:     proto_usr.GetRole(),
+		Role:     role,
 	}, nil
 }
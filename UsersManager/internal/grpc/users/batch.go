@@ -0,0 +1,133 @@
+package usersgrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"usersmanager/internal/domain/models"
+	"usersmanager/internal/domain/profiles"
+	serviceerrors "usersmanager/internal/service"
+	"usersmanager/pkg/lib/logger/sl"
+	"usersmanager/pkg/lib/requestid"
+
+	umv1 "github.com/chas3air/protos/gen/go/usersManager"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// The shared protos module has no batch insert, so it is described here by hand.
+// GetUsersResponse, a plain list of users, carries the users both ways.
+// Switch to generated stubs once protos defines the method.
+const (
+	BatchServiceName               = "usersmanager.batch.v1.UsersBatch"
+	BatchInsertBatchFullMethodName = "/" + BatchServiceName + "/InsertBatch"
+)
+
+// batchServer is the handler type of the batch service description.
+type batchServer interface {
+	InsertBatch(ctx context.Context, req *umv1.GetUsersResponse) (*umv1.GetUsersResponse, error)
+}
+
+// InsertBatch inserts all users of req in one transaction: either every user is
+// inserted or none is. Errors carry the position of the failed user as ErrorInfo
+// metadata under MetadataIndex.
+func (s *ServerAPI) InsertBatch(ctx context.Context, req *umv1.GetUsersResponse) (*umv1.GetUsersResponse, error) {
+	const op = "grpc.users.InsertBatch"
+	log := s.Log.With(
+		"op", op,
+		"request_id", requestid.FromContext(ctx),
+	)
+
+	select {
+	case <-ctx.Done():
+		log.Info("Context cancelled", sl.Err(ctx.Err()))
+		return nil, statusError(codes.Canceled, "context is over", ReasonCanceled, nil)
+	default:
+	}
+
+	users := make([]models.User, 0, len(req.GetUsers()))
+	for i, protoUser := range req.GetUsers() {
+		if err := validateUser(protoUser); err != nil {
+			log.Warn("Invalid user data for batch insertion", sl.Err(err), slog.Int("index", i))
+			return nil, statusError(codes.InvalidArgument, fmt.Sprintf("invalid user data at %d", i), ReasonInvalidUser, indexMetadata(nil, i))
+		}
+
+		// validateUser already converted the user successfully.
+		user, _ := profiles.ProtoUsrToUsr(protoUser)
+		users = append(users, user)
+	}
+
+	inserted, err := s.Service.InsertBatch(ctx, users)
+	if err != nil {
+		var batchErr *serviceerrors.BatchError
+		if errors.As(err, &batchErr) && batchErr.Index >= 0 && batchErr.Index < len(users) {
+			if errors.Is(err, serviceerrors.ErrAlreadyExists) {
+				log.Warn("User with given ID or login already exists", sl.Err(err))
+				return nil, statusError(codes.AlreadyExists, "user already exists", ReasonUserAlreadyExists,
+					indexMetadata(conflictMetadata(users[batchErr.Index], err), batchErr.Index))
+			}
+
+			if errors.Is(err, serviceerrors.ErrInvalidArgument) {
+				log.Warn("Invalid user data for batch insertion", sl.Err(err))
+				return nil, statusError(codes.InvalidArgument, "invalid user data", ReasonInvalidUser, indexMetadata(nil, batchErr.Index))
+			}
+		}
+
+		log.Error("Failed to insert users", sl.Err(err))
+		return nil, statusError(codes.Internal, "failed to insert users", ReasonInternal, nil)
+	}
+
+	pbUsers := make([]*umv1.User, 0, len(inserted))
+	for _, user := range inserted {
+		pbUsers = append(pbUsers, profiles.UsrToProtoUsr(user))
+	}
+
+	log.Info("Users inserted successfully", slog.Int("count", len(inserted)))
+	return &umv1.GetUsersResponse{
+		Users: pbUsers,
+	}, nil
+}
+
+// indexMetadata adds the position of the failed user to metadata.
+func indexMetadata(metadata map[string]string, index int) map[string]string {
+	if metadata == nil {
+		metadata = make(map[string]string, 1)
+	}
+	metadata[MetadataIndex] = strconv.Itoa(index)
+
+	return metadata
+}
+
+func insertBatchHandler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(umv1.GetUsersResponse)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(batchServer).InsertBatch(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BatchInsertBatchFullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(batchServer).InsertBatch(ctx, req.(*umv1.GetUsersResponse))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+var batchServiceDesc = grpc.ServiceDesc{
+	ServiceName: BatchServiceName,
+	HandlerType: (*batchServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "InsertBatch",
+			Handler:    insertBatchHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
@@ -0,0 +1,107 @@
+package usersgrpc_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"usersmanager/internal/domain/models"
+	usersgrpc "usersmanager/internal/grpc/users"
+	serviceerrors "usersmanager/internal/service"
+	"usersmanager/pkg/lib/logger/handler/slogdiscard"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	umv1 "github.com/chas3air/protos/gen/go/usersManager"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newUsersConn(t *testing.T, svc usersgrpc.IUsersService) *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	usersgrpc.Register(srv, slogdiscard.NewDiscardLogger(), svc)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func insertBatch(conn *grpc.ClientConn, users ...*umv1.User) (*umv1.GetUsersResponse, error) {
+	resp := &umv1.GetUsersResponse{}
+	err := conn.Invoke(context.Background(), usersgrpc.BatchInsertBatchFullMethodName, &umv1.GetUsersResponse{Users: users}, resp)
+	return resp, err
+}
+
+func TestServerAPI_InsertBatch(t *testing.T) {
+	first := models.User{Id: uuid.New(), Login: "first", Password: "pass", Role: models.RoleUser}
+	second := models.User{Id: uuid.New(), Login: "second", Password: "pass", Role: models.RoleAdmin}
+	users := []models.User{first, second}
+
+	t.Run("success", func(t *testing.T) {
+		svc := new(mockUsersService)
+		svc.On("InsertBatch", mock.Anything, users).Return(users, nil).Once()
+		conn := newUsersConn(t, svc)
+
+		resp, err := insertBatch(conn, toProto(first), toProto(second))
+
+		require.NoError(t, err)
+		require.Len(t, resp.GetUsers(), 2)
+		assert.Equal(t, second.Login, resp.GetUsers()[1].GetLogin())
+		svc.AssertExpectations(t)
+	})
+
+	t.Run("duplicate login names the user", func(t *testing.T) {
+		svc := new(mockUsersService)
+		serviceErr := fmt.Errorf("service.users.InsertBatch: %w", &serviceerrors.BatchError{Index: 1, Err: serviceerrors.ErrDuplicateLogin})
+		svc.On("InsertBatch", mock.Anything, users).Return(nil, serviceErr).Once()
+		conn := newUsersConn(t, svc)
+
+		_, err := insertBatch(conn, toProto(first), toProto(second))
+
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+		info := errorInfoFrom(t, err)
+		assert.Equal(t, "1", info.GetMetadata()[usersgrpc.MetadataIndex])
+		assert.Equal(t, "login", info.GetMetadata()[usersgrpc.MetadataField])
+		assert.Equal(t, second.Login, info.GetMetadata()["login"])
+	})
+
+	t.Run("invalid user is rejected before the service", func(t *testing.T) {
+		svc := new(mockUsersService)
+		conn := newUsersConn(t, svc)
+		invalid := toProto(second)
+		invalid.Password = ""
+
+		_, err := insertBatch(conn, toProto(first), invalid)
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, "1", errorInfoFrom(t, err).GetMetadata()[usersgrpc.MetadataIndex])
+		svc.AssertNotCalled(t, "InsertBatch", mock.Anything, mock.Anything)
+	})
+}
+
+func toProto(user models.User) *umv1.User {
+	return &umv1.User{
+		Id:       user.Id.String(),
+		Login:    user.Login,
+		Password: user.Password,
+		Role:     user.Role.String(),
+	}
+}
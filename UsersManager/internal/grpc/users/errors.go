@@ -0,0 +1,80 @@
+package usersgrpc
+
+import (
+	"errors"
+	"usersmanager/internal/domain/models"
+	serviceerrors "usersmanager/internal/service"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the google.rpc.ErrorInfo domain reported by UsersManager.
+const ErrorDomain = "usersmanager"
+
+// Machine-readable reasons attached to gRPC errors as google.rpc.ErrorInfo.
+const (
+	ReasonCanceled          = "CANCELED"
+	ReasonInvalidId         = "INVALID_ID"
+	ReasonInvalidUser       = "INVALID_USER"
+	ReasonInvalidRole       = "INVALID_ROLE"
+	ReasonUserNotFound      = "USER_NOT_FOUND"
+	ReasonUserAlreadyExists = "USER_ALREADY_EXISTS"
+	ReasonPermissionDenied  = "PERMISSION_DENIED"
+	ReasonLocked            = "LOCKED"
+	ReasonInternal          = "INTERNAL"
+)
+
+// MetadataField is the ErrorInfo metadata key naming the field that caused a
+// conflict, e.g. "login" for a duplicate login.
+const MetadataField = "field"
+
+// MetadataIndex is the ErrorInfo metadata key holding the position of the user
+// that failed a batch operation.
+const MetadataIndex = "index"
+
+// duplicateField returns the name of the duplicated field reported by err,
+// or an empty string if it is not known.
+func duplicateField(err error) string {
+	switch {
+	case errors.Is(err, serviceerrors.ErrDuplicateLogin):
+		return "login"
+	case errors.Is(err, serviceerrors.ErrDuplicateId):
+		return "id"
+	default:
+		return ""
+	}
+}
+
+// conflictMetadata describes the user that caused err, naming the duplicated
+// field when it is known.
+func conflictMetadata(user models.User, err error) map[string]string {
+	metadata := map[string]string{
+		"user_id": user.Id.String(),
+		"login":   user.Login,
+	}
+	if field := duplicateField(err); field != "" {
+		metadata[MetadataField] = field
+	}
+
+	return metadata
+}
+
+// statusError builds a gRPC status error carrying an errdetails.ErrorInfo with
+// the given reason and metadata. Falls back to a plain status error if the
+// details cannot be attached.
+func statusError(code codes.Code, msg string, reason string, metadata map[string]string) error {
+	st := status.New(code, msg)
+
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   ErrorDomain,
+		Metadata: metadata,
+	})
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
@@ -0,0 +1,164 @@
+package usersgrpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"usersmanager/internal/domain/models"
+	"usersmanager/internal/domain/profiles"
+	serviceerrors "usersmanager/internal/service"
+	"usersmanager/pkg/lib/logger/sl"
+	"usersmanager/pkg/lib/requestid"
+
+	umv1 "github.com/chas3air/protos/gen/go/usersManager"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// The shared protos module has no lookups other than by id, so they are described
+// here by hand. The well-known StringValue carries the key, GetUsersResponse the
+// users found and GetUserByIdResponse a single user. Switch to generated stubs once protos defines the methods.
+const (
+	LookupServiceName                  = "usersmanager.lookup.v1.UsersLookup"
+	LookupGetUsersByRoleFullMethodName = "/" + LookupServiceName + "/GetUsersByRole"
+	LookupGetUserByLoginFullMethodName = "/" + LookupServiceName + "/GetUserByLogin"
+)
+
+// lookupServer is the handler type of the lookup service description.
+type lookupServer interface {
+	GetUsersByRole(ctx context.Context, req *wrapperspb.StringValue) (*umv1.GetUsersResponse, error)
+	GetUserByLogin(ctx context.Context, req *wrapperspb.StringValue) (*umv1.GetUserByIdResponse, error)
+}
+
+// GetUsersByRole returns the users with the role in req, served by the users role index.
+// Unknown roles are rejected with codes.InvalidArgument.
+func (s *ServerAPI) GetUsersByRole(ctx context.Context, req *wrapperspb.StringValue) (*umv1.GetUsersResponse, error) {
+	const op = "grpc.users.GetUsersByRole"
+	log := s.Log.With(
+		"op", op,
+		"request_id", requestid.FromContext(ctx),
+	)
+
+	select {
+	case <-ctx.Done():
+		log.Info("Context cancelled", sl.Err(ctx.Err()))
+		return nil, statusError(codes.Canceled, "context is over", ReasonCanceled, nil)
+	default:
+	}
+
+	users, err := s.Service.GetUsersByRole(ctx, models.Role(req.GetValue()))
+	if err != nil {
+		if errors.Is(err, serviceerrors.ErrInvalidArgument) {
+			log.Warn("Unknown role", sl.Err(err), slog.String("role", req.GetValue()))
+			return nil, statusError(codes.InvalidArgument, "unknown role", ReasonInvalidRole, nil)
+		}
+
+		log.Error("Failed to fetch users by role", sl.Err(err))
+		return nil, statusError(codes.Internal, "failed to fetch users", ReasonInternal, nil)
+	}
+
+	pbUsers := make([]*umv1.User, 0, len(users))
+	for _, user := range users {
+		pbUsers = append(pbUsers, profiles.UsrToProtoUsr(user))
+	}
+
+	log.Info("Users fetched successfully", slog.Int("count", len(users)))
+	return &umv1.GetUsersResponse{
+		Users: pbUsers,
+	}, nil
+}
+
+// GetUserByLogin returns the user with the login in req, compared case-insensitively.
+// An empty login is rejected with codes.InvalidArgument and an unknown one with codes.NotFound.
+func (s *ServerAPI) GetUserByLogin(ctx context.Context, req *wrapperspb.StringValue) (*umv1.GetUserByIdResponse, error) {
+	const op = "grpc.users.GetUserByLogin"
+	log := s.Log.With(
+		"op", op,
+		"request_id", requestid.FromContext(ctx),
+	)
+
+	select {
+	case <-ctx.Done():
+		log.Info("Context cancelled", sl.Err(ctx.Err()))
+		return nil, statusError(codes.Canceled, "context is over", ReasonCanceled, nil)
+	default:
+	}
+
+	user, err := s.Service.GetUserByLogin(ctx, req.GetValue())
+	if err != nil {
+		if errors.Is(err, serviceerrors.ErrInvalidArgument) {
+			log.Warn("Invalid login", sl.Err(err))
+			return nil, statusError(codes.InvalidArgument, "invalid login", ReasonInvalidUser, nil)
+		}
+
+		if errors.Is(err, serviceerrors.ErrNotFound) {
+			log.Warn("User not found", sl.Err(err))
+			return nil, statusError(codes.NotFound, "user not found", ReasonUserNotFound, nil)
+		}
+
+		log.Error("Failed to fetch user by login", sl.Err(err))
+		return nil, statusError(codes.Internal, "failed to fetch user", ReasonInternal, nil)
+	}
+
+	log.Info("User fetched successfully", slog.String("user_id", user.Id.String()))
+	return &umv1.GetUserByIdResponse{
+		User: profiles.UsrToProtoUsr(user),
+	}, nil
+}
+
+func getUsersByRoleHandler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(wrapperspb.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(lookupServer).GetUsersByRole(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LookupGetUsersByRoleFullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(lookupServer).GetUsersByRole(ctx, req.(*wrapperspb.StringValue))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+func getUserByLoginHandler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(wrapperspb.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(lookupServer).GetUserByLogin(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LookupGetUserByLoginFullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(lookupServer).GetUserByLogin(ctx, req.(*wrapperspb.StringValue))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+var lookupServiceDesc = grpc.ServiceDesc{
+	ServiceName: LookupServiceName,
+	HandlerType: (*lookupServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetUsersByRole",
+			Handler:    getUsersByRoleHandler,
+		},
+		{
+			MethodName: "GetUserByLogin",
+			Handler:    getUserByLoginHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
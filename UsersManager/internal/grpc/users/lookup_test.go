@@ -0,0 +1,94 @@
+package usersgrpc_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"usersmanager/internal/domain/models"
+	usersgrpc "usersmanager/internal/grpc/users"
+	serviceerrors "usersmanager/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	umv1 "github.com/chas3air/protos/gen/go/usersManager"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestServerAPI_GetUsersByRole(t *testing.T) {
+	getUsersByRole := func(t *testing.T, svc *mockUsersService, role string) (*umv1.GetUsersResponse, error) {
+		resp := &umv1.GetUsersResponse{}
+		err := newUsersConn(t, svc).Invoke(context.Background(), usersgrpc.LookupGetUsersByRoleFullMethodName, wrapperspb.String(role), resp)
+		return resp, err
+	}
+
+	t.Run("populated role", func(t *testing.T) {
+		admins := []models.User{{Id: uuid.New(), Login: "admin1", Password: "pass", Role: models.RoleAdmin}}
+		svc := new(mockUsersService)
+		svc.On("GetUsersByRole", mock.Anything, models.RoleAdmin).Return(admins, nil).Once()
+
+		resp, err := getUsersByRole(t, svc, "admin")
+
+		require.NoError(t, err)
+		require.Len(t, resp.GetUsers(), 1)
+		assert.Equal(t, admins[0].Id.String(), resp.GetUsers()[0].GetId())
+		svc.AssertExpectations(t)
+	})
+
+	t.Run("empty role", func(t *testing.T) {
+		svc := new(mockUsersService)
+		svc.On("GetUsersByRole", mock.Anything, models.RoleUser).Return([]models.User{}, nil).Once()
+
+		resp, err := getUsersByRole(t, svc, "user")
+
+		require.NoError(t, err)
+		assert.Empty(t, resp.GetUsers())
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		svc := new(mockUsersService)
+		svc.On("GetUsersByRole", mock.Anything, models.Role("root")).
+			Return(nil, fmt.Errorf("service.users.GetUsersByRole: %w", serviceerrors.ErrInvalidArgument)).Once()
+
+		_, err := getUsersByRole(t, svc, "root")
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, usersgrpc.ReasonInvalidRole, errorInfoFrom(t, err).GetReason())
+	})
+}
+
+func TestServerAPI_GetUserByLogin(t *testing.T) {
+	getUserByLogin := func(t *testing.T, svc *mockUsersService, login string) (*umv1.GetUserByIdResponse, error) {
+		resp := &umv1.GetUserByIdResponse{}
+		err := newUsersConn(t, svc).Invoke(context.Background(), usersgrpc.LookupGetUserByLoginFullMethodName, wrapperspb.String(login), resp)
+		return resp, err
+	}
+
+	t.Run("found", func(t *testing.T) {
+		user := models.User{Id: uuid.New(), Login: "alice", Password: "pass", Role: models.RoleUser}
+		svc := new(mockUsersService)
+		svc.On("GetUserByLogin", mock.Anything, "Alice").Return(user, nil).Once()
+
+		resp, err := getUserByLogin(t, svc, "Alice")
+
+		require.NoError(t, err)
+		assert.Equal(t, user.Id.String(), resp.GetUser().GetId())
+		svc.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		svc := new(mockUsersService)
+		svc.On("GetUserByLogin", mock.Anything, "ghost").
+			Return(models.User{}, fmt.Errorf("service.users.GetUserByLogin: %w", serviceerrors.ErrNotFound)).Once()
+
+		_, err := getUserByLogin(t, svc, "ghost")
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Equal(t, usersgrpc.ReasonUserNotFound, errorInfoFrom(t, err).GetReason())
+	})
+}
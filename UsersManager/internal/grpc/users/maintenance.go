@@ -0,0 +1,107 @@
+package usersgrpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	serviceerrors "usersmanager/internal/service"
+	"usersmanager/pkg/lib/logger/sl"
+	"usersmanager/pkg/lib/requestid"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// The shared protos module has no maintenance service, so it is described here by
+// hand with the well-known Empty message. Switch to generated stubs once it does.
+const (
+	MaintenanceServiceName                 = "usersmanager.admin.v1.Maintenance"
+	MaintenanceVacuumAnalyzeFullMethodName = "/" + MaintenanceServiceName + "/VacuumAnalyze"
+)
+
+type IMaintenanceService interface {
+	VacuumAnalyze(ctx context.Context) error
+}
+
+// maintenanceServer is the handler type of the maintenance service description.
+type maintenanceServer interface {
+	VacuumAnalyze(ctx context.Context, req *emptypb.Empty) (*emptypb.Empty, error)
+}
+
+// MaintenanceServerAPI serves admin-only database maintenance. Callers are checked
+// against the actor forwarded by the gateway; restrict the service further with
+// mutual TLS and allowed clients, since the actor metadata is set by the client.
+type MaintenanceServerAPI struct {
+	Log     *slog.Logger
+	Service IMaintenanceService
+}
+
+func RegisterMaintenance(grpc *grpc.Server, log *slog.Logger, service IMaintenanceService) {
+	grpc.RegisterService(&maintenanceServiceDesc, &MaintenanceServerAPI{Log: log, Service: service})
+}
+
+func (s *MaintenanceServerAPI) VacuumAnalyze(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	const op = "grpc.users.VacuumAnalyze"
+	log := s.Log.With(
+		"op", op,
+		"request_id", requestid.FromContext(ctx),
+	)
+
+	select {
+	case <-ctx.Done():
+		log.Info("Context cancelled", sl.Err(ctx.Err()))
+		return nil, statusError(codes.Canceled, "context is over", ReasonCanceled, nil)
+	default:
+	}
+
+	if err := s.Service.VacuumAnalyze(ctx); err != nil {
+		if errors.Is(err, serviceerrors.ErrPermissionDenied) {
+			log.Warn("VACUUM ANALYZE not permitted", sl.Err(err))
+			return nil, statusError(codes.PermissionDenied, "vacuum analyze not permitted", ReasonPermissionDenied, nil)
+		}
+
+		if errors.Is(err, serviceerrors.ErrLocked) {
+			log.Info("VACUUM ANALYZE already running", sl.Err(err))
+			return nil, statusError(codes.Aborted, "vacuum analyze already running", ReasonLocked, nil)
+		}
+
+		log.Error("Failed to run VACUUM ANALYZE", sl.Err(err))
+		return nil, statusError(codes.Internal, "failed to run vacuum analyze", ReasonInternal, nil)
+	}
+
+	log.Info("VACUUM ANALYZE completed")
+	return &emptypb.Empty{}, nil
+}
+
+func vacuumAnalyzeHandler(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(maintenanceServer).VacuumAnalyze(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MaintenanceVacuumAnalyzeFullMethodName,
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return srv.(maintenanceServer).VacuumAnalyze(ctx, req.(*emptypb.Empty))
+	}
+
+	return interceptor(ctx, in, info, handler)
+}
+
+var maintenanceServiceDesc = grpc.ServiceDesc{
+	ServiceName: MaintenanceServiceName,
+	HandlerType: (*maintenanceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "VacuumAnalyze",
+			Handler:    vacuumAnalyzeHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
@@ -0,0 +1,99 @@
+package usersgrpc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	usersgrpc "usersmanager/internal/grpc/users"
+	serviceerrors "usersmanager/internal/service"
+	"usersmanager/pkg/lib/logger/handler/slogdiscard"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type mockMaintenanceService struct {
+	mock.Mock
+}
+
+func (m *mockMaintenanceService) VacuumAnalyze(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func newMaintenanceConn(t *testing.T, svc usersgrpc.IMaintenanceService) *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	usersgrpc.RegisterMaintenance(srv, slogdiscard.NewDiscardLogger(), svc)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial bufconn: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func TestMaintenanceServerAPI_VacuumAnalyze(t *testing.T) {
+	tests := []struct {
+		name       string
+		serviceErr error
+		wantCode   codes.Code
+		wantReason string
+	}{
+		{name: "success", wantCode: codes.OK},
+		{
+			name:       "permission denied",
+			serviceErr: fmt.Errorf("service.maintenance.VacuumAnalyze: %w", serviceerrors.ErrPermissionDenied),
+			wantCode:   codes.PermissionDenied,
+			wantReason: usersgrpc.ReasonPermissionDenied,
+		},
+		{
+			name:       "already running",
+			serviceErr: fmt.Errorf("service.maintenance.VacuumAnalyze: %w", serviceerrors.ErrLocked),
+			wantCode:   codes.Aborted,
+			wantReason: usersgrpc.ReasonLocked,
+		},
+		{
+			name:       "internal error",
+			serviceErr: errors.New("connection reset"),
+			wantCode:   codes.Internal,
+			wantReason: usersgrpc.ReasonInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockMaintenanceService)
+			svc.On("VacuumAnalyze", mock.Anything).Return(tt.serviceErr).Once()
+			conn := newMaintenanceConn(t, svc)
+
+			err := conn.Invoke(context.Background(), usersgrpc.MaintenanceVacuumAnalyzeFullMethodName, &emptypb.Empty{}, &emptypb.Empty{})
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantReason != "" {
+				assert.Equal(t, tt.wantReason, errorInfoFrom(t, err).GetReason())
+			}
+			svc.AssertExpectations(t)
+		})
+	}
+}
@@ -8,18 +8,21 @@ import (
 	"usersmanager/internal/domain/profiles"
 	serviceerrors "usersmanager/internal/service"
 	"usersmanager/pkg/lib/logger/sl"
+	"usersmanager/pkg/lib/requestid"
 
 	umv1 "github.com/chas3air/protos/gen/go/usersManager"
 	"github.com/google/uuid"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 type IUsersService interface {
 	GetUsers(ctx context.Context) ([]models.User, error)
 	GetUserById(ctx context.Context, uid uuid.UUID) (models.User, error)
+	GetUsersByRole(ctx context.Context, role models.Role) ([]models.User, error)
+	GetUserByLogin(ctx context.Context, login string) (models.User, error)
 	Insert(ctx context.Context, user models.User) (models.User, error)
+	InsertBatch(ctx context.Context, users []models.User) ([]models.User, error)
 	Update(ctx context.Context, uid uuid.UUID, user models.User) (models.User, error)
 	Delete(ctx context.Context, uid uuid.UUID) (models.User, error)
 }
@@ -31,26 +34,30 @@ type ServerAPI struct {
 }
 
 func Register(grpc *grpc.Server, log *slog.Logger, service IUsersService) {
-	umv1.RegisterUsersManagerServer(grpc, &ServerAPI{Log: log, Service: service})
+	api := &ServerAPI{Log: log, Service: service}
+	umv1.RegisterUsersManagerServer(grpc, api)
+	grpc.RegisterService(&batchServiceDesc, api)
+	grpc.RegisterService(&lookupServiceDesc, api)
 }
 
 func (s *ServerAPI) GetUsers(ctx context.Context, req *umv1.GetUsersRequest) (*umv1.GetUsersResponse, error) {
 	const op = "grpc.users.GetUsers"
 	log := s.Log.With(
 		"op", op,
+		"request_id", requestid.FromContext(ctx),
 	)
 
 	select {
 	case <-ctx.Done():
 		log.Info("Context cancelled", sl.Err(ctx.Err()))
-		return nil, status.Error(codes.Canceled, "context is over")
+		return nil, statusError(codes.Canceled, "context is over", ReasonCanceled, nil)
 	default:
 	}
 
 	users, err := s.Service.GetUsers(ctx)
 	if err != nil {
 		log.Error("Failed to fetch users", sl.Err(err))
-		return nil, status.Error(codes.Internal, "failed to fetch users")
+		return nil, statusError(codes.Internal, "failed to fetch users", ReasonInternal, nil)
 	}
 
 	var pbUsers = make([]*umv1.User, 0, len(users))
@@ -68,30 +75,31 @@ func (s *ServerAPI) GetUserById(ctx context.Context, req *umv1.GetUserByIdReques
 	const op = "grpc.users.GetUserById"
 	log := s.Log.With(
 		"op", op,
+		"request_id", requestid.FromContext(ctx),
 	)
 
 	select {
 	case <-ctx.Done():
 		log.Info("Context cancelled", sl.Err(ctx.Err()))
-		return nil, status.Error(codes.Canceled, "context is over")
+		return nil, statusError(codes.Canceled, "context is over", ReasonCanceled, nil)
 	default:
 	}
 
 	uid, err := uuid.Parse(req.GetId())
 	if err != nil {
 		log.Error("Invalid user ID format", sl.Err(err))
-		return nil, status.Error(codes.InvalidArgument, "invalid id format")
+		return nil, statusError(codes.InvalidArgument, "invalid id format", ReasonInvalidId, map[string]string{"id": req.GetId()})
 	}
 
 	user, err := s.Service.GetUserById(ctx, uid)
 	if err != nil {
 		if errors.Is(err, serviceerrors.ErrNotFound) {
 			log.Warn("User not found", sl.Err(serviceerrors.ErrNotFound))
-			return nil, status.Error(codes.NotFound, "user not found")
+			return nil, statusError(codes.NotFound, "user not found", ReasonUserNotFound, map[string]string{"user_id": uid.String()})
 		}
 
 		log.Error("Failed to fetch user by ID", sl.Err(err))
-		return nil, status.Error(codes.Internal, "failed to fetch user by id")
+		return nil, statusError(codes.Internal, "failed to fetch user by id", ReasonInternal, nil)
 	}
 
 	log.Info("User fetched successfully", slog.String("user_id", user.Id.String()))
@@ -104,30 +112,36 @@ func (s *ServerAPI) Insert(ctx context.Context, req *umv1.InsertRequest) (*umv1.
 	const op = "grpc.users.Insert"
 	log := s.Log.With(
 		"op", op,
+		"request_id", requestid.FromContext(ctx),
 	)
 
 	select {
 	case <-ctx.Done():
 		log.Info("Context cancelled", sl.Err(ctx.Err()))
-		return nil, status.Error(codes.Canceled, "context is over")
+		return nil, statusError(codes.Canceled, "context is over", ReasonCanceled, nil)
 	default:
 	}
 
 	userForInsert, err := profiles.ProtoUsrToUsr(req.GetUser())
 	if err != nil {
 		log.Error("Invalid user data for insertion", sl.Err(err))
-		return nil, status.Error(codes.InvalidArgument, "invalid user data")
+		return nil, statusError(codes.InvalidArgument, "invalid user data", ReasonInvalidUser, nil)
 	}
 
 	insertedUser, err := s.Service.Insert(ctx, userForInsert)
 	if err != nil {
 		if errors.Is(err, serviceerrors.ErrAlreadyExists) {
-			log.Warn("User with given ID or login already exists", sl.Err(serviceerrors.ErrAlreadyExists))
-			return nil, status.Error(codes.AlreadyExists, "user already exists")
+			log.Warn("User with given ID or login already exists", sl.Err(err))
+			return nil, statusError(codes.AlreadyExists, "user already exists", ReasonUserAlreadyExists, conflictMetadata(userForInsert, err))
+		}
+
+		if errors.Is(err, serviceerrors.ErrInvalidArgument) {
+			log.Warn("Invalid user data for insertion", sl.Err(err))
+			return nil, statusError(codes.InvalidArgument, "invalid user data", ReasonInvalidUser, nil)
 		}
 
 		log.Error("Failed to insert user", sl.Err(err))
-		return nil, status.Error(codes.Internal, "failed to insert user")
+		return nil, statusError(codes.Internal, "failed to insert user", ReasonInternal, nil)
 	}
 
 	log.Info("User inserted successfully", slog.String("user_id", insertedUser.Id.String()))
@@ -140,36 +154,47 @@ func (s *ServerAPI) Update(ctx context.Context, req *umv1.UpdateRequest) (*umv1.
 	const op = "grpc.users.Update"
 	log := s.Log.With(
 		"op", op,
+		"request_id", requestid.FromContext(ctx),
 	)
 
 	select {
 	case <-ctx.Done():
 		log.Info("Context cancelled", sl.Err(ctx.Err()))
-		return nil, status.Error(codes.Canceled, "context is over")
+		return nil, statusError(codes.Canceled, "context is over", ReasonCanceled, nil)
 	default:
 	}
 
 	idForUpdate, err := uuid.Parse(req.GetId())
 	if err != nil {
 		log.Error("Invalid user ID format for update", sl.Err(err))
-		return nil, status.Error(codes.InvalidArgument, "invalid id format for update")
+		return nil, statusError(codes.InvalidArgument, "invalid id format for update", ReasonInvalidId, map[string]string{"id": req.GetId()})
 	}
 
 	userForUpdate, err := profiles.ProtoUsrToUsr(req.GetUser())
 	if err != nil {
 		log.Error("Invalid user data for update", sl.Err(err))
-		return nil, status.Error(codes.InvalidArgument, "invalid user data for update")
+		return nil, statusError(codes.InvalidArgument, "invalid user data for update", ReasonInvalidUser, nil)
 	}
 
 	updatedUser, err := s.Service.Update(ctx, idForUpdate, userForUpdate)
 	if err != nil {
 		if errors.Is(err, serviceerrors.ErrNotFound) {
 			log.Warn("User not found for update", sl.Err(serviceerrors.ErrNotFound))
-			return nil, status.Error(codes.NotFound, "user not found for update")
+			return nil, statusError(codes.NotFound, "user not found for update", ReasonUserNotFound, map[string]string{"user_id": idForUpdate.String()})
+		}
+
+		if errors.Is(err, serviceerrors.ErrAlreadyExists) {
+			log.Warn("User with given login already exists", sl.Err(err))
+			return nil, statusError(codes.AlreadyExists, "user already exists", ReasonUserAlreadyExists, conflictMetadata(userForUpdate, err))
+		}
+
+		if errors.Is(err, serviceerrors.ErrInvalidArgument) {
+			log.Warn("Invalid user data for update", sl.Err(err))
+			return nil, statusError(codes.InvalidArgument, "invalid user data for update", ReasonInvalidUser, nil)
 		}
 
 		log.Error("Failed to update user", sl.Err(err))
-		return nil, status.Error(codes.Internal, "failed to update user")
+		return nil, statusError(codes.Internal, "failed to update user", ReasonInternal, nil)
 	}
 
 	log.Info("User updated successfully", slog.String("user_id", updatedUser.Id.String()))
@@ -182,30 +207,31 @@ func (s *ServerAPI) Delete(ctx context.Context, req *umv1.DeleteRequest) (*umv1.
 	const op = "grpc.users.Delete"
 	log := s.Log.With(
 		"op", op,
+		"request_id", requestid.FromContext(ctx),
 	)
 
 	select {
 	case <-ctx.Done():
 		log.Info("Context cancelled", sl.Err(ctx.Err()))
-		return nil, status.Error(codes.Canceled, "context is over")
+		return nil, statusError(codes.Canceled, "context is over", ReasonCanceled, nil)
 	default:
 	}
 
 	idForDelete, err := uuid.Parse(req.GetId())
 	if err != nil {
 		log.Error("Invalid user ID format for deletion", sl.Err(err))
-		return nil, status.Error(codes.InvalidArgument, "invalid id format for deletion")
+		return nil, statusError(codes.InvalidArgument, "invalid id format for deletion", ReasonInvalidId, map[string]string{"id": req.GetId()})
 	}
 
 	deletedUser, err := s.Service.Delete(ctx, idForDelete)
 	if err != nil {
 		if errors.Is(err, serviceerrors.ErrNotFound) {
 			log.Warn("User not found for deletion", sl.Err(serviceerrors.ErrNotFound))
-			return nil, status.Error(codes.NotFound, "user not found for deletion")
+			return nil, statusError(codes.NotFound, "user not found for deletion", ReasonUserNotFound, map[string]string{"user_id": idForDelete.String()})
 		}
 
 		log.Error("Failed to delete user", sl.Err(err))
-		return nil, status.Error(codes.Internal, "failed to delete user")
+		return nil, statusError(codes.Internal, "failed to delete user", ReasonInternal, nil)
 	}
 
 	log.Info("User deleted successfully", slog.String("user_id", deletedUser.Id.String()))
@@ -1,8 +1,13 @@
 package usersgrpc_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"log/slog"
+	"net"
+	"strings"
 	"testing"
 
 	"usersmanager/internal/domain/models"
@@ -10,14 +15,20 @@ import (
 	usersgrpc "usersmanager/internal/grpc/users"
 	serviceerrors "usersmanager/internal/service"
 	"usersmanager/pkg/lib/logger/handler/slogdiscard"
+	"usersmanager/pkg/lib/requestid"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	umv1 "github.com/chas3air/protos/gen/go/usersManager"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 )
 
 // Mock сервиса пользователей
@@ -35,11 +46,32 @@ func (m *mockUsersService) GetUserById(ctx context.Context, uid uuid.UUID) (mode
 	return args.Get(0).(models.User), args.Error(1)
 }
 
+func (m *mockUsersService) GetUsersByRole(ctx context.Context, role models.Role) ([]models.User, error) {
+	args := m.Called(ctx, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *mockUsersService) GetUserByLogin(ctx context.Context, login string) (models.User, error) {
+	args := m.Called(ctx, login)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
 func (m *mockUsersService) Insert(ctx context.Context, user models.User) (models.User, error) {
 	args := m.Called(ctx, user)
 	return args.Get(0).(models.User), args.Error(1)
 }
 
+func (m *mockUsersService) InsertBatch(ctx context.Context, users []models.User) ([]models.User, error) {
+	args := m.Called(ctx, users)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
 func (m *mockUsersService) Update(ctx context.Context, uid uuid.UUID, user models.User) (models.User, error) {
 	args := m.Called(ctx, uid, user)
 	return args.Get(0).(models.User), args.Error(1)
@@ -277,3 +309,128 @@ func TestServerAPI_Delete(t *testing.T) {
 		svc.AssertExpectations(t)
 	})
 }
+
+func newBufconnClient(t *testing.T, log *slog.Logger, svc usersgrpc.IUsersService, opts ...grpc.ServerOption) umv1.UsersManagerClient {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer(opts...)
+	usersgrpc.Register(srv, log, svc)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial bufconn: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return umv1.NewUsersManagerClient(conn)
+}
+
+func errorInfoFrom(t *testing.T, err error) *errdetails.ErrorInfo {
+	st, ok := status.FromError(err)
+	if !ok {
+		t.Fatalf("expected gRPC status error, got %v", err)
+	}
+
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+
+	t.Fatalf("no ErrorInfo in status details: %v", st.Details())
+	return nil
+}
+
+func TestServerAPI_ErrorInfo(t *testing.T) {
+	svc := new(mockUsersService)
+	client := newBufconnClient(t, slogdiscard.NewDiscardLogger(), svc)
+	ctx := context.Background()
+
+	t.Run("already exists", func(t *testing.T) {
+		user := models.User{Id: uuid.New(), Login: "u1", Password: "p1", Role: "admin"}
+		svc.On("Insert", mock.Anything, user).Return(models.User{}, serviceerrors.ErrAlreadyExists).Once()
+
+		_, err := client.Insert(ctx, &umv1.InsertRequest{User: profiles.UsrToProtoUsr(user)})
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+		info := errorInfoFrom(t, err)
+		assert.Equal(t, usersgrpc.ReasonUserAlreadyExists, info.GetReason())
+		assert.Equal(t, usersgrpc.ErrorDomain, info.GetDomain())
+		assert.Equal(t, user.Id.String(), info.GetMetadata()["user_id"])
+		assert.Equal(t, user.Login, info.GetMetadata()["login"])
+		svc.AssertExpectations(t)
+	})
+
+	t.Run("duplicate login", func(t *testing.T) {
+		user := models.User{Id: uuid.New(), Login: "taken", Password: "p1", Role: "user"}
+		svc.On("Insert", mock.Anything, user).Return(models.User{}, serviceerrors.ErrDuplicateLogin).Once()
+
+		_, err := client.Insert(ctx, &umv1.InsertRequest{User: profiles.UsrToProtoUsr(user)})
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+		assert.Equal(t, "login", errorInfoFrom(t, err).GetMetadata()[usersgrpc.MetadataField])
+		svc.AssertExpectations(t)
+	})
+
+	t.Run("update duplicate login", func(t *testing.T) {
+		user := models.User{Id: uuid.New(), Login: "taken", Password: "p1", Role: "user"}
+		svc.On("Update", mock.Anything, user.Id, user).Return(models.User{}, serviceerrors.ErrDuplicateLogin).Once()
+
+		_, err := client.Update(ctx, &umv1.UpdateRequest{Id: user.Id.String(), User: profiles.UsrToProtoUsr(user)})
+		assert.Equal(t, codes.AlreadyExists, status.Code(err))
+		assert.Equal(t, "login", errorInfoFrom(t, err).GetMetadata()[usersgrpc.MetadataField])
+		svc.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		id := uuid.New()
+		svc.On("GetUserById", mock.Anything, id).Return(models.User{}, serviceerrors.ErrNotFound).Once()
+
+		_, err := client.GetUserById(ctx, &umv1.GetUserByIdRequest{Id: id.String()})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		info := errorInfoFrom(t, err)
+		assert.Equal(t, usersgrpc.ReasonUserNotFound, info.GetReason())
+		assert.Equal(t, id.String(), info.GetMetadata()["user_id"])
+		svc.AssertExpectations(t)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		_, err := client.Delete(ctx, &umv1.DeleteRequest{Id: "not-uuid"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		info := errorInfoFrom(t, err)
+		assert.Equal(t, usersgrpc.ReasonInvalidId, info.GetReason())
+		assert.Equal(t, "not-uuid", info.GetMetadata()["id"])
+	})
+}
+
+func TestServerAPI_RequestIdInLogs(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	svc := new(mockUsersService)
+	client := newBufconnClient(t, log, svc, grpc.ChainUnaryInterceptor(requestid.UnaryServerInterceptor()))
+
+	id := uuid.New()
+	svc.On("GetUserById", mock.Anything, id).Return(models.User{}, errors.New("db error")).Once()
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), requestid.MetadataKey, "req-123")
+	_, err := client.GetUserById(ctx, &umv1.GetUserByIdRequest{Id: id.String()})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.NotEmpty(t, lines)
+	for _, line := range lines {
+		var entry map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+		assert.Equal(t, "req-123", entry["request_id"], line)
+	}
+	svc.AssertExpectations(t)
+}
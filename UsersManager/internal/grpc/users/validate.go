@@ -0,0 +1,65 @@
+package usersgrpc
+
+import (
+	"fmt"
+	"usersmanager/internal/domain/profiles"
+	"usersmanager/pkg/lib/validation"
+
+	umv1 "github.com/chas3air/protos/gen/go/usersManager"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+)
+
+// Validators returns the request validators of the UsersManager methods,
+// keyed by full method name, for validation.UnaryServerInterceptor.
+func Validators() map[string]validation.Func {
+	return map[string]validation.Func{
+		umv1.UsersManager_Insert_FullMethodName: validateInsertRequest,
+		umv1.UsersManager_Update_FullMethodName: validateUpdateRequest,
+	}
+}
+
+func validateInsertRequest(req any) error {
+	r, ok := req.(*umv1.InsertRequest)
+	if !ok {
+		return fmt.Errorf("unexpected request type %T", req)
+	}
+
+	return validateUser(r.GetUser())
+}
+
+func validateUpdateRequest(req any) error {
+	r, ok := req.(*umv1.UpdateRequest)
+	if !ok {
+		return fmt.Errorf("unexpected request type %T", req)
+	}
+
+	if _, err := uuid.Parse(r.GetId()); err != nil {
+		return statusError(codes.InvalidArgument, "invalid id format for update", ReasonInvalidId, map[string]string{"id": r.GetId()})
+	}
+
+	return validateUser(r.GetUser())
+}
+
+// validateUser checks a user written by insert or update: it must convert to a
+// domain user and carry a login, password and role, since all of them are stored.
+func validateUser(protoUser *umv1.User) error {
+	user, err := profiles.ProtoUsrToUsr(protoUser)
+	if err != nil {
+		return statusError(codes.InvalidArgument, "invalid user data", ReasonInvalidUser, nil)
+	}
+
+	var missing string
+	switch {
+	case user.Login == "":
+		missing = "login"
+	case user.Password == "":
+		missing = "password"
+	case user.Role == "":
+		missing = "role"
+	default:
+		return nil
+	}
+
+	return statusError(codes.InvalidArgument, "invalid user data: "+missing+" is required", ReasonInvalidUser, map[string]string{MetadataField: missing})
+}
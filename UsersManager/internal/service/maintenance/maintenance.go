@@ -0,0 +1,71 @@
+package maintenanceservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"usersmanager/internal/domain/models"
+	serviceerrors "usersmanager/internal/service"
+	storageerrors "usersmanager/internal/storage"
+	"usersmanager/pkg/lib/actor"
+	"usersmanager/pkg/lib/logger/sl"
+	"usersmanager/pkg/lib/requestid"
+)
+
+type IMaintenanceStorage interface {
+	VacuumAnalyze(ctx context.Context) error
+}
+
+// MaintenanceService runs admin-only database maintenance.
+type MaintenanceService struct {
+	log     *slog.Logger
+	storage IMaintenanceStorage
+}
+
+func New(log *slog.Logger, storage IMaintenanceStorage) *MaintenanceService {
+	return &MaintenanceService{
+		log:     log,
+		storage: storage,
+	}
+}
+
+// VacuumAnalyze refreshes the planner statistics of the users table.
+// Returns an error wrapping serviceerrors.ErrPermissionDenied if the caller is not an
+// admin or the database role may not vacuum the table, and serviceerrors.ErrLocked if
+// another replica is vacuuming it right now.
+func (m *MaintenanceService) VacuumAnalyze(ctx context.Context) error {
+	const op = "service.maintenance.VacuumAnalyze"
+	log := m.log.With("op", op, "request_id", requestid.FromContext(ctx))
+
+	select {
+	case <-ctx.Done():
+		log.Info("Context cancelled", sl.Err(ctx.Err()))
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	a, ok := actor.FromContext(ctx)
+	if !ok || a.Role != models.RoleAdmin.String() {
+		log.Warn("VACUUM ANALYZE refused to a non-admin caller", slog.String("actor_id", a.Id.String()))
+		return fmt.Errorf("%s: %w", op, serviceerrors.ErrPermissionDenied)
+	}
+
+	if err := m.storage.VacuumAnalyze(ctx); err != nil {
+		if errors.Is(err, storageerrors.ErrPermissionDenied) {
+			log.Error("Database role may not vacuum the users table", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, serviceerrors.ErrPermissionDenied)
+		}
+
+		if errors.Is(err, storageerrors.ErrLocked) {
+			log.Info("VACUUM ANALYZE already running", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, serviceerrors.ErrLocked)
+		}
+
+		log.Error("Failed to run VACUUM ANALYZE", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("VACUUM ANALYZE completed", slog.String("actor_id", a.Id.String()))
+	return nil
+}
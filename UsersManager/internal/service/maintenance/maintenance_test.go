@@ -0,0 +1,79 @@
+package maintenanceservice_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	serviceerros "usersmanager/internal/service"
+	maintenanceservice "usersmanager/internal/service/maintenance"
+	storageerrors "usersmanager/internal/storage"
+	"usersmanager/pkg/lib/actor"
+	"usersmanager/pkg/lib/logger/handler/slogdiscard"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMaintenanceStorage struct {
+	mock.Mock
+}
+
+func (m *MockMaintenanceStorage) VacuumAnalyze(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func newTestService(storage *MockMaintenanceStorage) *maintenanceservice.MaintenanceService {
+	return maintenanceservice.New(slogdiscard.NewDiscardLogger(), storage)
+}
+
+func adminCtx() context.Context {
+	return actor.WithActor(context.Background(), actor.Actor{Id: uuid.New(), Role: "admin"})
+}
+
+func TestVacuumAnalyze_Admin(t *testing.T) {
+	mockStorage := new(MockMaintenanceStorage)
+	mockStorage.On("VacuumAnalyze", mock.Anything).Return(nil)
+
+	err := newTestService(mockStorage).VacuumAnalyze(adminCtx())
+
+	assert.NoError(t, err)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestVacuumAnalyze_NotAdmin(t *testing.T) {
+	for name, ctx := range map[string]context.Context{
+		"no actor":   context.Background(),
+		"user actor": actor.WithActor(context.Background(), actor.Actor{Id: uuid.New(), Role: "user"}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockStorage := new(MockMaintenanceStorage)
+
+			err := newTestService(mockStorage).VacuumAnalyze(ctx)
+
+			assert.ErrorIs(t, err, serviceerros.ErrPermissionDenied)
+			mockStorage.AssertNotCalled(t, "VacuumAnalyze", mock.Anything)
+		})
+	}
+}
+
+func TestVacuumAnalyze_Locked(t *testing.T) {
+	mockStorage := new(MockMaintenanceStorage)
+	mockStorage.On("VacuumAnalyze", mock.Anything).
+		Return(fmt.Errorf("storage.users.psql.VacuumAnalyze: %w", storageerrors.ErrLocked))
+
+	err := newTestService(mockStorage).VacuumAnalyze(adminCtx())
+
+	assert.ErrorIs(t, err, serviceerros.ErrLocked)
+}
+
+func TestVacuumAnalyze_DBPermissionDenied(t *testing.T) {
+	mockStorage := new(MockMaintenanceStorage)
+	mockStorage.On("VacuumAnalyze", mock.Anything).
+		Return(fmt.Errorf("storage.users.psql.VacuumAnalyze: %w", storageerrors.ErrPermissionDenied))
+
+	err := newTestService(mockStorage).VacuumAnalyze(adminCtx())
+
+	assert.ErrorIs(t, err, serviceerros.ErrPermissionDenied)
+}
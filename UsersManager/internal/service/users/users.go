@@ -9,6 +9,7 @@ import (
 	serviceerrors "usersmanager/internal/service"
 	storageerrors "usersmanager/internal/storage"
 	"usersmanager/pkg/lib/logger/sl"
+	"usersmanager/pkg/lib/requestid"
 
 	"github.com/google/uuid"
 )
@@ -16,7 +17,10 @@ import (
 type IUsersStorage interface {
 	GetUsers(ctx context.Context) ([]models.User, error)
 	GetUserById(ctx context.Context, uid uuid.UUID) (models.User, error)
+	GetUsersByRole(ctx context.Context, role models.Role) ([]models.User, error)
+	GetUserByLogin(ctx context.Context, login string) (models.User, error)
 	Insert(ctx context.Context, user models.User) (models.User, error)
+	InsertBatch(ctx context.Context, users []models.User) ([]models.User, error)
 	Update(ctx context.Context, uid uuid.UUID, user models.User) (models.User, error)
 	Delete(ctx context.Context, uid uuid.UUID) (models.User, error)
 }
@@ -36,7 +40,7 @@ func New(log *slog.Logger, storage IUsersStorage) *UsersService {
 // GetUsers implements grpcapp.IUsersService.
 func (u *UsersService) GetUsers(ctx context.Context) ([]models.User, error) {
 	const op = "service.users.GetUsers"
-	log := u.log.With("op", op)
+	log := u.log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
@@ -55,10 +59,38 @@ func (u *UsersService) GetUsers(ctx context.Context) ([]models.User, error) {
 	return users, nil
 }
 
+// GetUsersByRole returns the users with the given role.
+// Returns an error wrapping serviceerrors.ErrInvalidArgument if role is not a known role.
+func (u *UsersService) GetUsersByRole(ctx context.Context, role models.Role) ([]models.User, error) {
+	const op = "service.users.GetUsersByRole"
+	log := u.log.With("op", op, "request_id", requestid.FromContext(ctx))
+
+	select {
+	case <-ctx.Done():
+		log.Info("Context cancelled", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	if !role.IsValid() {
+		log.Warn("Unknown role", slog.String("role", role.String()))
+		return nil, fmt.Errorf("%s: %w", op, serviceerrors.ErrInvalidArgument)
+	}
+
+	users, err := u.storage.GetUsersByRole(ctx, role)
+	if err != nil {
+		log.Error("Failed to fetch users by role", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("Users fetched successfully", slog.String("role", role.String()), slog.Int("count", len(users)))
+	return users, nil
+}
+
 // GetUserById implements grpcapp.IUsersService.
 func (u *UsersService) GetUserById(ctx context.Context, uid uuid.UUID) (models.User, error) {
 	const op = "service.users.GetUserById"
-	log := u.log.With("op", op)
+	log := u.log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
@@ -82,10 +114,44 @@ func (u *UsersService) GetUserById(ctx context.Context, uid uuid.UUID) (models.U
 	return user, nil
 }
 
+// GetUserByLogin returns the user with the given login, compared case-insensitively.
+// Returns an error wrapping serviceerrors.ErrInvalidArgument for an empty login
+// and serviceerrors.ErrNotFound if no user has it.
+func (u *UsersService) GetUserByLogin(ctx context.Context, login string) (models.User, error) {
+	const op = "service.users.GetUserByLogin"
+	log := u.log.With("op", op, "request_id", requestid.FromContext(ctx))
+
+	select {
+	case <-ctx.Done():
+		log.Info("Context cancelled", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	if login == "" {
+		log.Warn("Empty login")
+		return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrInvalidArgument)
+	}
+
+	user, err := u.storage.GetUserByLogin(ctx, login)
+	if err != nil {
+		if errors.Is(err, storageerrors.ErrNotFound) {
+			log.Warn("User not found", sl.Err(storageerrors.ErrNotFound))
+			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrNotFound)
+		}
+
+		log.Error("Failed to fetch user by login", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("User fetched successfully", slog.String("user_id", user.Id.String()))
+	return user, nil
+}
+
 // Insert implements grpcapp.IUsersService.
 func (u *UsersService) Insert(ctx context.Context, userForInsert models.User) (models.User, error) {
 	const op = "service.users.Insert"
-	log := u.log.With("op", op)
+	log := u.log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
@@ -95,10 +161,22 @@ func (u *UsersService) Insert(ctx context.Context, userForInsert models.User) (m
 	}
 
 	insertedUser, err := u.storage.Insert(ctx, userForInsert)
+	if errors.Is(err, storageerrors.ErrDuplicateId) {
+		// Ids are random UUIDs, so a clash is a fluke the client cannot act on.
+		// Retry once with a fresh id; a login clash is reported as is.
+		log.Warn("User id collision, retrying with a new id", sl.Err(err), slog.String("user_id", userForInsert.Id.String()))
+		userForInsert.Id = uuid.New()
+		insertedUser, err = u.storage.Insert(ctx, userForInsert)
+	}
 	if err != nil {
 		if errors.Is(err, storageerrors.ErrAlreadyExists) {
-			log.Warn("User already exists", sl.Err(storageerrors.ErrAlreadyExists), slog.String("user_id", userForInsert.Id.String()))
-			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrAlreadyExists)
+			log.Warn("User already exists", sl.Err(err), slog.String("user_id", userForInsert.Id.String()))
+			return models.User{}, fmt.Errorf("%s: %w", op, duplicateError(err))
+		}
+
+		if errors.Is(err, storageerrors.ErrInvalidArgument) {
+			log.Warn("Invalid user", sl.Err(storageerrors.ErrInvalidArgument), slog.String("user_id", userForInsert.Id.String()))
+			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrInvalidArgument)
 		}
 
 		log.Error("Failed to insert user", sl.Err(err), slog.String("user_id", userForInsert.Id.String()))
@@ -109,10 +187,51 @@ func (u *UsersService) Insert(ctx context.Context, userForInsert models.User) (m
 	return insertedUser, nil
 }
 
+// InsertBatch implements grpcapp.IUsersService.
+// Either every user is inserted or none is. The error of the user that failed the
+// batch is wrapped in a serviceerrors.BatchError naming it.
+func (u *UsersService) InsertBatch(ctx context.Context, users []models.User) ([]models.User, error) {
+	const op = "service.users.InsertBatch"
+	log := u.log.With("op", op, "request_id", requestid.FromContext(ctx))
+
+	select {
+	case <-ctx.Done():
+		log.Info("Context cancelled", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	if len(users) == 0 {
+		return []models.User{}, nil
+	}
+
+	inserted, err := u.storage.InsertBatch(ctx, users)
+	if err != nil {
+		var batchErr *storageerrors.BatchError
+		switch {
+		case !errors.As(err, &batchErr):
+			log.Error("Failed to insert users", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		case errors.Is(err, storageerrors.ErrAlreadyExists):
+			log.Warn("User already exists", sl.Err(err), slog.Int("index", batchErr.Index))
+			return nil, fmt.Errorf("%s: %w", op, &serviceerrors.BatchError{Index: batchErr.Index, Err: duplicateError(err)})
+		case errors.Is(err, storageerrors.ErrInvalidArgument):
+			log.Warn("Invalid user", sl.Err(err), slog.Int("index", batchErr.Index))
+			return nil, fmt.Errorf("%s: %w", op, &serviceerrors.BatchError{Index: batchErr.Index, Err: serviceerrors.ErrInvalidArgument})
+		default:
+			log.Error("Failed to insert users", sl.Err(err), slog.Int("index", batchErr.Index))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	log.Info("Users inserted successfully", slog.Int("count", len(inserted)))
+	return inserted, nil
+}
+
 // Update implements grpcapp.IUsersService.
 func (u *UsersService) Update(ctx context.Context, uid uuid.UUID, userForUpdate models.User) (models.User, error) {
 	const op = "service.users.Update"
-	log := u.log.With("op", op)
+	log := u.log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
@@ -128,6 +247,16 @@ func (u *UsersService) Update(ctx context.Context, uid uuid.UUID, userForUpdate
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrNotFound)
 		}
 
+		if errors.Is(err, storageerrors.ErrAlreadyExists) {
+			log.Warn("User already exists", sl.Err(err), slog.String("user_id", uid.String()))
+			return models.User{}, fmt.Errorf("%s: %w", op, duplicateError(err))
+		}
+
+		if errors.Is(err, storageerrors.ErrInvalidArgument) {
+			log.Warn("Invalid user for update", sl.Err(storageerrors.ErrInvalidArgument), slog.String("user_id", uid.String()))
+			return models.User{}, fmt.Errorf("%s: %w", op, serviceerrors.ErrInvalidArgument)
+		}
+
 		log.Error("Failed to update user", sl.Err(err), slog.String("user_id", uid.String()))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
@@ -139,7 +268,7 @@ func (u *UsersService) Update(ctx context.Context, uid uuid.UUID, userForUpdate
 // Delete implements grpcapp.IUsersService.
 func (u *UsersService) Delete(ctx context.Context, uid uuid.UUID) (models.User, error) {
 	const op = "service.users.Delete"
-	log := u.log.With("op", op)
+	log := u.log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
@@ -162,3 +291,16 @@ func (u *UsersService) Delete(ctx context.Context, uid uuid.UUID) (models.User,
 	log.Info("User deleted successfully", slog.String("user_id", deletedUser.Id.String()))
 	return deletedUser, nil
 }
+
+// duplicateError maps a storage duplicate error to the service error for the
+// same field, or serviceerrors.ErrAlreadyExists if the field is unknown.
+func duplicateError(err error) error {
+	switch {
+	case errors.Is(err, storageerrors.ErrDuplicateLogin):
+		return serviceerrors.ErrDuplicateLogin
+	case errors.Is(err, storageerrors.ErrDuplicateId):
+		return serviceerrors.ErrDuplicateId
+	default:
+		return serviceerrors.ErrAlreadyExists
+	}
+}
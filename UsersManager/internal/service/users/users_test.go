@@ -2,6 +2,7 @@ package usersservice_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"usersmanager/internal/domain/models"
 	serviceerros "usersmanager/internal/service"
@@ -25,6 +26,16 @@ func (m *MockUsersStorage) GetUsers(ctx context.Context) ([]models.User, error)
 	return args.Get(0).([]models.User), args.Error(1)
 }
 
+func (m *MockUsersStorage) GetUsersByRole(ctx context.Context, role models.Role) ([]models.User, error) {
+	args := m.Called(ctx, role)
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockUsersStorage) GetUserByLogin(ctx context.Context, login string) (models.User, error) {
+	args := m.Called(ctx, login)
+	return args.Get(0).(models.User), args.Error(1)
+}
+
 func (m *MockUsersStorage) GetUserById(ctx context.Context, uid uuid.UUID) (models.User, error) {
 	args := m.Called(ctx, uid)
 	return args.Get(0).(models.User), args.Error(1)
@@ -35,6 +46,14 @@ func (m *MockUsersStorage) Insert(ctx context.Context, user models.User) (models
 	return args.Get(0).(models.User), args.Error(1)
 }
 
+func (m *MockUsersStorage) InsertBatch(ctx context.Context, users []models.User) ([]models.User, error) {
+	args := m.Called(ctx, users)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
 func (m *MockUsersStorage) Update(ctx context.Context, uid uuid.UUID, user models.User) (models.User, error) {
 	args := m.Called(ctx, uid, user)
 	return args.Get(0).(models.User), args.Error(1)
@@ -83,6 +102,31 @@ func TestGetUsers_ContextCanceled(t *testing.T) {
 	assert.Contains(t, err.Error(), "context canceled")
 }
 
+func TestGetUsersByRole_Success(t *testing.T) {
+	mockStorage := new(MockUsersStorage)
+	users := []models.User{{Id: uuid.New(), Role: models.RoleAdmin}}
+
+	mockStorage.On("GetUsersByRole", mock.Anything, models.RoleAdmin).Return(users, nil)
+
+	svc := newTestService(mockStorage)
+	got, err := svc.GetUsersByRole(context.Background(), models.RoleAdmin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, users, got)
+
+	mockStorage.AssertExpectations(t)
+}
+
+func TestGetUsersByRole_UnknownRole(t *testing.T) {
+	mockStorage := new(MockUsersStorage)
+
+	svc := newTestService(mockStorage)
+	_, err := svc.GetUsersByRole(context.Background(), "superuser")
+
+	assert.ErrorIs(t, err, serviceerros.ErrInvalidArgument)
+	mockStorage.AssertNotCalled(t, "GetUsersByRole", mock.Anything, mock.Anything)
+}
+
 func TestGetUserById_Success(t *testing.T) {
 	mockStorage := new(MockUsersStorage)
 	id := uuid.New()
@@ -109,6 +153,40 @@ func TestGetUserById_NotFound(t *testing.T) {
 	mockStorage.AssertExpectations(t)
 }
 
+func TestGetUserByLogin_Success(t *testing.T) {
+	mockStorage := new(MockUsersStorage)
+	user := models.User{Id: uuid.New(), Login: "user1"}
+	mockStorage.On("GetUserByLogin", mock.Anything, "USER1").Return(user, nil)
+
+	svc := newTestService(mockStorage)
+	got, err := svc.GetUserByLogin(context.Background(), "USER1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, user, got)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestGetUserByLogin_NotFound(t *testing.T) {
+	mockStorage := new(MockUsersStorage)
+	mockStorage.On("GetUserByLogin", mock.Anything, "ghost").Return(models.User{}, storageerrors.ErrNotFound)
+
+	svc := newTestService(mockStorage)
+	_, err := svc.GetUserByLogin(context.Background(), "ghost")
+
+	assert.ErrorIs(t, err, serviceerros.ErrNotFound)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestGetUserByLogin_Empty(t *testing.T) {
+	mockStorage := new(MockUsersStorage)
+
+	svc := newTestService(mockStorage)
+	_, err := svc.GetUserByLogin(context.Background(), "")
+
+	assert.ErrorIs(t, err, serviceerros.ErrInvalidArgument)
+	mockStorage.AssertNotCalled(t, "GetUserByLogin", mock.Anything, mock.Anything)
+}
+
 func TestInsert_Success(t *testing.T) {
 	mockStorage := new(MockUsersStorage)
 	user := models.User{Id: uuid.New(), Login: "user1"}
@@ -134,6 +212,92 @@ func TestInsert_AlreadyExists(t *testing.T) {
 	mockStorage.AssertExpectations(t)
 }
 
+func TestInsert_DuplicateLogin(t *testing.T) {
+	mockStorage := new(MockUsersStorage)
+	user := models.User{Id: uuid.New(), Login: "taken"}
+	mockStorage.On("Insert", mock.Anything, user).Return(models.User{}, fmt.Errorf("storage: %w", storageerrors.ErrDuplicateLogin))
+
+	svc := newTestService(mockStorage)
+	_, err := svc.Insert(context.Background(), user)
+
+	assert.ErrorIs(t, err, serviceerros.ErrDuplicateLogin)
+	assert.ErrorIs(t, err, serviceerros.ErrAlreadyExists)
+	mockStorage.AssertExpectations(t)
+	mockStorage.AssertNumberOfCalls(t, "Insert", 1)
+}
+
+func TestInsert_IdCollisionRetried(t *testing.T) {
+	mockStorage := new(MockUsersStorage)
+	user := models.User{Id: uuid.New(), Login: "user1"}
+	mockStorage.On("Insert", mock.Anything, user).Return(models.User{}, fmt.Errorf("storage: %w", storageerrors.ErrDuplicateId)).Once()
+	var retried models.User
+	mockStorage.On("Insert", mock.Anything, mock.MatchedBy(func(u models.User) bool {
+		return u.Id != user.Id && u.Login == user.Login
+	})).Run(func(args mock.Arguments) {
+		retried = args.Get(1).(models.User)
+	}).Return(models.User{}, nil).Once()
+
+	svc := newTestService(mockStorage)
+	_, err := svc.Insert(context.Background(), user)
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, retried.Id)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestInsert_IdCollisionRetriedOnce(t *testing.T) {
+	mockStorage := new(MockUsersStorage)
+	user := models.User{Id: uuid.New(), Login: "user1"}
+	mockStorage.On("Insert", mock.Anything, mock.Anything).Return(models.User{}, fmt.Errorf("storage: %w", storageerrors.ErrDuplicateId)).Twice()
+
+	svc := newTestService(mockStorage)
+	_, err := svc.Insert(context.Background(), user)
+
+	assert.ErrorIs(t, err, serviceerros.ErrDuplicateId)
+	mockStorage.AssertExpectations(t)
+	mockStorage.AssertNumberOfCalls(t, "Insert", 2)
+}
+
+func TestInsertBatch_Success(t *testing.T) {
+	mockStorage := new(MockUsersStorage)
+	users := []models.User{{Id: uuid.New(), Login: "u1"}, {Id: uuid.New(), Login: "u2"}}
+	mockStorage.On("InsertBatch", mock.Anything, users).Return(users, nil)
+
+	svc := newTestService(mockStorage)
+	got, err := svc.InsertBatch(context.Background(), users)
+
+	assert.NoError(t, err)
+	assert.Equal(t, users, got)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestInsertBatch_Empty(t *testing.T) {
+	mockStorage := new(MockUsersStorage)
+
+	svc := newTestService(mockStorage)
+	got, err := svc.InsertBatch(context.Background(), nil)
+
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+	mockStorage.AssertNotCalled(t, "InsertBatch", mock.Anything, mock.Anything)
+}
+
+func TestInsertBatch_DuplicateLogin(t *testing.T) {
+	mockStorage := new(MockUsersStorage)
+	users := []models.User{{Id: uuid.New(), Login: "u1"}, {Id: uuid.New(), Login: "u1"}}
+	storageErr := fmt.Errorf("storage.users.psql.InsertBatch: %w", &storageerrors.BatchError{Index: 1, Err: storageerrors.ErrDuplicateLogin})
+	mockStorage.On("InsertBatch", mock.Anything, users).Return(nil, storageErr)
+
+	svc := newTestService(mockStorage)
+	_, err := svc.InsertBatch(context.Background(), users)
+
+	assert.ErrorIs(t, err, serviceerros.ErrDuplicateLogin)
+	var batchErr *serviceerros.BatchError
+	if assert.ErrorAs(t, err, &batchErr) {
+		assert.Equal(t, 1, batchErr.Index)
+	}
+}
+
 func TestUpdate_Success(t *testing.T) {
 	mockStorage := new(MockUsersStorage)
 	id := uuid.New()
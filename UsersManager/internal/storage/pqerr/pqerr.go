@@ -0,0 +1,61 @@
+package pqerr
+
+import (
+	"errors"
+	storageerrors "usersmanager/internal/storage"
+
+	"github.com/lib/pq"
+)
+
+// SQLSTATE codes mapped to domain errors.
+const (
+	CodeUniqueViolation       pq.ErrorCode = "23505"
+	CodeNotNullViolation      pq.ErrorCode = "23502"
+	CodeCheckViolation        pq.ErrorCode = "23514"
+	CodeForeignKeyViolation   pq.ErrorCode = "23503"
+	CodeInsufficientPrivilege pq.ErrorCode = "42501"
+	CodeSerializationFailure  pq.ErrorCode = "40001"
+	CodeDeadlockDetected      pq.ErrorCode = "40P01"
+)
+
+var codes = map[pq.ErrorCode]error{
+	CodeUniqueViolation:       storageerrors.ErrAlreadyExists,
+	CodeNotNullViolation:      storageerrors.ErrInvalidArgument,
+	CodeCheckViolation:        storageerrors.ErrInvalidArgument,
+	CodeForeignKeyViolation:   storageerrors.ErrInvalidArgument,
+	CodeInsufficientPrivilege: storageerrors.ErrPermissionDenied,
+}
+
+// Classify maps a *pq.Error in err's chain to the matching storageerrors sentinel.
+// Returns false if err is not a postgres error or its code has no mapping.
+func Classify(err error) (error, bool) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return nil, false
+	}
+
+	sentinel, ok := codes[pqErr.Code]
+	return sentinel, ok
+}
+
+// ConstraintName returns the name of the constraint violated by the *pq.Error
+// in err's chain, or an empty string if there is none.
+func ConstraintName(err error) string {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return ""
+	}
+
+	return pqErr.Constraint
+}
+
+// IsRetryable reports whether err is a serialization failure or deadlock,
+// after which the whole transaction can safely be retried.
+func IsRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	return pqErr.Code == CodeSerializationFailure || pqErr.Code == CodeDeadlockDetected
+}
@@ -0,0 +1,46 @@
+package pqerr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	storageerrors "usersmanager/internal/storage"
+	"usersmanager/internal/storage/pqerr"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		want   error
+		wantOk bool
+	}{
+		{"unique violation", &pq.Error{Code: pqerr.CodeUniqueViolation}, storageerrors.ErrAlreadyExists, true},
+		{"not null violation", &pq.Error{Code: pqerr.CodeNotNullViolation}, storageerrors.ErrInvalidArgument, true},
+		{"check violation", &pq.Error{Code: pqerr.CodeCheckViolation}, storageerrors.ErrInvalidArgument, true},
+		{"foreign key violation", &pq.Error{Code: pqerr.CodeForeignKeyViolation}, storageerrors.ErrInvalidArgument, true},
+		{"insufficient privilege", &pq.Error{Code: pqerr.CodeInsufficientPrivilege}, storageerrors.ErrPermissionDenied, true},
+		{"wrapped unique violation", fmt.Errorf("op: %w", &pq.Error{Code: pqerr.CodeUniqueViolation}), storageerrors.ErrAlreadyExists, true},
+		{"unmapped code", &pq.Error{Code: "42P01"}, nil, false},
+		{"not a pq error", errors.New("boom"), nil, false},
+		{"nil error", nil, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := pqerr.Classify(tt.err)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, pqerr.IsRetryable(&pq.Error{Code: pqerr.CodeSerializationFailure}))
+	assert.True(t, pqerr.IsRetryable(fmt.Errorf("op: %w", &pq.Error{Code: pqerr.CodeDeadlockDetected})))
+	assert.False(t, pqerr.IsRetryable(&pq.Error{Code: pqerr.CodeUniqueViolation}))
+	assert.False(t, pqerr.IsRetryable(errors.New("boom")))
+}
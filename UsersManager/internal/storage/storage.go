@@ -1,9 +1,35 @@
 package storageerrors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrAlreadyExists    = errors.New("already exists")
+	ErrInvalidArgument  = errors.New("invalid argument")
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrSchemaMismatch   = errors.New("schema mismatch")
+	ErrLocked           = errors.New("locked")
+)
 
+// Field-specific duplicates. Both match ErrAlreadyExists with errors.Is.
 var (
-	ErrNotFound        = errors.New("not found")
-	ErrAlreadyExists   = errors.New("already exists")
-	ErrInvalidArgument = errors.New("invalid argument")
+	ErrDuplicateId    = fmt.Errorf("id %w", ErrAlreadyExists)
+	ErrDuplicateLogin = fmt.Errorf("login %w", ErrAlreadyExists)
 )
+
+// BatchError is the error of the user at Index of a batch operation.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("user %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
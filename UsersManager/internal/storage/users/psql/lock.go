@@ -0,0 +1,55 @@
+package userspsqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	storageerrors "usersmanager/internal/storage"
+)
+
+// advisoryLockKey maps a lock name to the 64-bit key of a Postgres advisory lock.
+func advisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+// TryAdvisoryLock takes the session-level Postgres advisory lock called name on a
+// dedicated connection, so a singleton job runs on one replica at a time. It does
+// not wait: while another session holds the lock it returns an error wrapping
+// storageerrors.ErrLocked. The job runs its queries on the returned connection
+// and calls release when done. Postgres also drops the lock when the session
+// ends, so a crashed replica never keeps it.
+func TryAdvisoryLock(ctx context.Context, db *sql.DB, name string) (conn *sql.Conn, release func(), err error) {
+	const op = "storage.users.psql.TryAdvisoryLock"
+
+	conn, err = db.Conn(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	key := advisoryLockKey(name)
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1);", key).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, nil, fmt.Errorf("%s: %s: %w", op, name, storageerrors.ErrLocked)
+	}
+
+	release = func() {
+		// Unlocking must not depend on the job's context, which may be done by now.
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1);", key); err != nil {
+			// The session may still hold the lock; drop it instead of returning it to the pool.
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+
+	return conn, release, nil
+}
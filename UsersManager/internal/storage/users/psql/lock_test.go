@@ -0,0 +1,52 @@
+package userspsqlstorage_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	storageerrors "usersmanager/internal/storage"
+	userspsqlstorage "usersmanager/internal/storage/users/psql"
+)
+
+// TestTryAdvisoryLock_Postgres runs against a real database named by
+// USERSMANAGER_TEST_PSQL_CONN_STR and is skipped without one.
+func TestTryAdvisoryLock_Postgres(t *testing.T) {
+	connStr := os.Getenv("USERSMANAGER_TEST_PSQL_CONN_STR")
+	if connStr == "" {
+		t.Skip("USERSMANAGER_TEST_PSQL_CONN_STR is not set")
+	}
+
+	// Two pools stand in for two replicas.
+	first, err := sql.Open("postgres", connStr)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer first.Close()
+	second, err := sql.Open("postgres", connStr)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer second.Close()
+
+	ctx := context.Background()
+	const name = "test:advisory-lock"
+
+	_, release, err := userspsqlstorage.TryAdvisoryLock(ctx, first, name)
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+
+	if _, _, err := userspsqlstorage.TryAdvisoryLock(ctx, second, name); !errors.Is(err, storageerrors.ErrLocked) {
+		t.Fatalf("expected storageerrors.ErrLocked while held, got %v", err)
+	}
+
+	release()
+
+	_, release, err = userspsqlstorage.TryAdvisoryLock(ctx, second, name)
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	release()
+}
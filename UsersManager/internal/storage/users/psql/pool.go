@@ -0,0 +1,45 @@
+package userspsqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// WarmUp opens n connections up front so the first requests after startup do not
+// each wait for a new one. All n are held at once, which forces the pool to open
+// them, and then handed back as idle connections. The pool is allowed to keep at
+// least n idle. Zero or less does nothing.
+func WarmUp(ctx context.Context, db *sql.DB, n int) error {
+	const op = "storage.users.psql.WarmUp"
+
+	if n <= 0 {
+		return nil
+	}
+
+	// database/sql keeps only two idle connections by default.
+	if n > 2 {
+		db.SetMaxIdleConns(n)
+	}
+
+	conns := make([]*sql.Conn, 0, n)
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	for range n {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		conns = append(conns, conn)
+
+		if err := conn.PingContext(ctx); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package userspsqlstorage_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	userspsqlstorage "usersmanager/internal/storage/users/psql"
+)
+
+func TestWarmUp(t *testing.T) {
+	t.Run("opens the requested connections", func(t *testing.T) {
+		inner := &recordingConnector{}
+		db := sql.OpenDB(inner)
+		defer db.Close()
+
+		if err := userspsqlstorage.WarmUp(context.Background(), db, 5); err != nil {
+			t.Fatalf("warm up: %v", err)
+		}
+		if inner.connects != 5 {
+			t.Fatalf("expected 5 connections, got %d", inner.connects)
+		}
+		if idle := db.Stats().Idle; idle != 5 {
+			t.Fatalf("expected 5 idle connections, got %d", idle)
+		}
+
+		// Later calls reuse the warm connections instead of dialling again.
+		for range 5 {
+			if err := db.PingContext(context.Background()); err != nil {
+				t.Fatalf("ping: %v", err)
+			}
+		}
+		if inner.connects != 5 {
+			t.Fatalf("expected no new connections, got %d", inner.connects)
+		}
+	})
+
+	t.Run("zero is disabled", func(t *testing.T) {
+		inner := &recordingConnector{}
+		db := sql.OpenDB(inner)
+		defer db.Close()
+
+		if err := userspsqlstorage.WarmUp(context.Background(), db, 0); err != nil {
+			t.Fatalf("warm up: %v", err)
+		}
+		if inner.connects != 0 {
+			t.Fatalf("expected no connections, got %d", inner.connects)
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package userspsqlstorage
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// sessionConnector prepares every new connection of the pool before it is used.
+type sessionConnector struct {
+	driver.Connector
+	statementTimeout time.Duration
+}
+
+// NewSessionConnector wraps connector so each new connection runs
+// SET statement_timeout with statementTimeout, rounded down to milliseconds.
+// A non-positive statementTimeout leaves connections untouched.
+func NewSessionConnector(connector driver.Connector, statementTimeout time.Duration) driver.Connector {
+	return &sessionConnector{
+		Connector:        connector,
+		statementTimeout: statementTimeout,
+	}
+}
+
+func (c *sessionConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.statementTimeout <= 0 {
+		return conn, nil
+	}
+
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		conn.Close()
+		return nil, errors.New("connection does not support exec")
+	}
+
+	// SET takes no bind parameters, so the value is formatted into the statement.
+	query := fmt.Sprintf("SET statement_timeout = %d", c.statementTimeout.Milliseconds())
+	if _, err := execer.ExecContext(ctx, query, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("set statement_timeout: %w", err)
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,102 @@
+package userspsqlstorage_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"os"
+	"testing"
+	"time"
+	userspsqlstorage "usersmanager/internal/storage/users/psql"
+
+	"github.com/lib/pq"
+)
+
+// recordingConnector hands out connections that record the statements they execute.
+type recordingConnector struct {
+	queries  []string
+	execErr  error
+	connects int
+}
+
+func (c *recordingConnector) Connect(context.Context) (driver.Conn, error) {
+	c.connects++
+	return &recordingConn{connector: c}, nil
+}
+
+func (c *recordingConnector) Driver() driver.Driver { return nil }
+
+type recordingConn struct {
+	connector *recordingConnector
+}
+
+func (c *recordingConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.connector.queries = append(c.connector.queries, query)
+	return driver.RowsAffected(0), c.connector.execErr
+}
+
+func (c *recordingConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *recordingConn) Close() error                        { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func TestSessionConnector(t *testing.T) {
+	t.Run("sets statement timeout on new connections", func(t *testing.T) {
+		inner := &recordingConnector{}
+		db := sql.OpenDB(userspsqlstorage.NewSessionConnector(inner, 1500*time.Millisecond))
+		defer db.Close()
+
+		if err := db.PingContext(context.Background()); err != nil {
+			t.Fatalf("ping: %v", err)
+		}
+		if len(inner.queries) != 1 || inner.queries[0] != "SET statement_timeout = 1500" {
+			t.Fatalf("unexpected session statements: %q", inner.queries)
+		}
+	})
+
+	t.Run("zero keeps the server default", func(t *testing.T) {
+		inner := &recordingConnector{}
+		db := sql.OpenDB(userspsqlstorage.NewSessionConnector(inner, 0))
+		defer db.Close()
+
+		if err := db.PingContext(context.Background()); err != nil {
+			t.Fatalf("ping: %v", err)
+		}
+		if len(inner.queries) != 0 {
+			t.Fatalf("unexpected session statements: %q", inner.queries)
+		}
+	})
+
+	t.Run("failed setup fails the connection", func(t *testing.T) {
+		inner := &recordingConnector{execErr: errors.New("boom")}
+		db := sql.OpenDB(userspsqlstorage.NewSessionConnector(inner, time.Second))
+		defer db.Close()
+
+		if err := db.PingContext(context.Background()); err == nil {
+			t.Fatal("expected connection setup to fail")
+		}
+	})
+}
+
+// TestSessionConnector_Postgres runs against a real database named by
+// USERSMANAGER_TEST_PSQL_CONN_STR and is skipped without one.
+func TestSessionConnector_Postgres(t *testing.T) {
+	connStr := os.Getenv("USERSMANAGER_TEST_PSQL_CONN_STR")
+	if connStr == "" {
+		t.Skip("USERSMANAGER_TEST_PSQL_CONN_STR is not set")
+	}
+
+	connector, err := pq.NewConnector(connStr)
+	if err != nil {
+		t.Fatalf("connector: %v", err)
+	}
+	db := sql.OpenDB(userspsqlstorage.NewSessionConnector(connector, 100*time.Millisecond))
+	defer db.Close()
+
+	_, err = db.ExecContext(context.Background(), "SELECT pg_sleep(1)")
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "57014" {
+		t.Fatalf("expected query_canceled (57014), got %v", err)
+	}
+}
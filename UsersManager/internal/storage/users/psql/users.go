@@ -3,31 +3,124 @@ package userspsqlstorage
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
+	"slices"
+	"time"
 	"usersmanager/internal/domain/models"
 	storageerrors "usersmanager/internal/storage"
+	"usersmanager/internal/storage/pqerr"
 	"usersmanager/pkg/lib/logger/sl"
+	"usersmanager/pkg/lib/requestid"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"github.com/pressly/goose/v3"
 )
 
+// IUsersStorage is the set of operations available on a storage bound to a transaction.
+type IUsersStorage interface {
+	GetUsers(ctx context.Context) ([]models.User, error)
+	GetUserById(ctx context.Context, uid uuid.UUID) (models.User, error)
+	GetUsersByRole(ctx context.Context, role models.Role) ([]models.User, error)
+	GetUserByLogin(ctx context.Context, login string) (models.User, error)
+	Insert(ctx context.Context, user models.User) (models.User, error)
+	Update(ctx context.Context, uid uuid.UUID, user models.User) (models.User, error)
+	Delete(ctx context.Context, uid uuid.UUID) (models.User, error)
+}
+
+// dbtx is the subset of *sql.DB and *sql.Tx used to run queries.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// userColumns are the columns of the users table, in the order the scan code reads
+// them from SELECT *.
+var userColumns = []string{"id", "login", "password", "role"}
+
+// Unique constraint and index names created by the migrations. The migrations always
+// create the users table, so these names do not follow TableName.
+const (
+	primaryKeyName = "users_pkey"
+	loginKeyName   = "users_login_key"
+)
+
+// txRetryBaseDelay is the backoff before the first retry of a failed serializable transaction.
+const txRetryBaseDelay = 10 * time.Millisecond
+
 type UsersPsqlStorage struct {
 	Log       *slog.Logger
 	DB        *sql.DB
 	TableName string
+	// TxRetries is how many times a serializable transaction is retried after a
+	// serialization failure or deadlock.
+	TxRetries int
+
+	tx *sql.Tx
+}
+
+// Option configures the storage built by New.
+type Option func(*options)
+
+type options struct {
+	txRetries        int
+	connMaxLifetime  time.Duration
+	statementTimeout time.Duration
+	warmupConns      int
+}
+
+// WithTxRetries retries a serializable transaction up to n times after a
+// serialization failure or deadlock.
+func WithTxRetries(n int) Option {
+	return func(o *options) {
+		o.txRetries = n
+	}
+}
+
+// WithConnMaxLifetime closes pooled connections after d so stale connections are
+// recycled after a failover. A non-positive value keeps connections forever.
+func WithConnMaxLifetime(d time.Duration) Option {
+	return func(o *options) {
+		o.connMaxLifetime = d
+	}
+}
+
+// WithStatementTimeout sets d as the Postgres statement_timeout of every new
+// connection, so the server cancels runaway queries on its own. A non-positive
+// value keeps the server default.
+func WithStatementTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.statementTimeout = d
+	}
+}
+
+// WithWarmupConns opens n connections before New returns. Zero skips the warmup.
+func WithWarmupConns(n int) Option {
+	return func(o *options) {
+		o.warmupConns = n
+	}
 }
 
-func New(log *slog.Logger, connStr string, tableName string) *UsersPsqlStorage {
-	db, err := sql.Open("postgres", connStr)
+// New opens the database and applies migrations.
+func New(log *slog.Logger, connStr string, tableName string, opts ...Option) *UsersPsqlStorage {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	connector, err := pq.NewConnector(connStr)
 	if err != nil {
 		panic(err)
 	}
+	db := sql.OpenDB(NewSessionConnector(connector, o.statementTimeout))
+	db.SetConnMaxLifetime(o.connMaxLifetime)
 
 	wd, _ := os.Getwd()
 	migrationPath := filepath.Join(wd, "app", "migrations")
@@ -35,11 +128,47 @@ func New(log *slog.Logger, connStr string, tableName string) *UsersPsqlStorage {
 		panic(err)
 	}
 
-	return &UsersPsqlStorage{
+	storage := &UsersPsqlStorage{
 		Log:       log,
 		DB:        db,
 		TableName: tableName,
+		TxRetries: o.txRetries,
+	}
+
+	if err := storage.CheckSchema(context.Background()); err != nil {
+		panic(err)
 	}
+
+	// A failed warmup only costs latency, so it does not stop startup.
+	if err := WarmUp(context.Background(), db, o.warmupConns); err != nil {
+		log.Warn("Failed to warm up the connection pool", sl.Err(err))
+	}
+
+	return storage
+}
+
+// CheckSchema verifies that the table has exactly the columns the scan code expects,
+// in that order, so a migration drifting from the code fails at boot instead of on
+// the first query. Returns an error wrapping storageerrors.ErrSchemaMismatch otherwise.
+func (u *UsersPsqlStorage) CheckSchema(ctx context.Context) error {
+	const op = "storage.users.psql.CheckSchema"
+
+	rows, err := u.DB.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %s LIMIT 0;", u.TableName))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !slices.Equal(columns, userColumns) {
+		return fmt.Errorf("%s: table %s has columns %v, expected %v: %w", op, u.TableName, columns, userColumns, storageerrors.ErrSchemaMismatch)
+	}
+
+	return nil
 }
 
 func (u *UsersPsqlStorage) Close() {
@@ -48,10 +177,223 @@ func (u *UsersPsqlStorage) Close() {
 	}
 }
 
+// Ping checks that the database is reachable.
+func (u *UsersPsqlStorage) Ping(ctx context.Context) error {
+	return u.DB.PingContext(ctx)
+}
+
+// MigrationVersion returns the version of the latest applied goose migration.
+func (u *UsersPsqlStorage) MigrationVersion(ctx context.Context) (int64, error) {
+	const op = "storage.users.psql.MigrationVersion"
+
+	version, err := goose.GetDBVersionContext(ctx, u.DB)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return version, nil
+}
+
+// VacuumAnalyze runs VACUUM ANALYZE on the users table to refresh planner statistics,
+// e.g. after bulk imports. VACUUM cannot run inside a transaction, so it uses a dedicated
+// connection from the pool and is refused on a storage bound to a transaction.
+// Only one replica vacuums at a time; the others get an error wrapping storageerrors.ErrLocked.
+// Returns an error wrapping storageerrors.ErrPermissionDenied if the DB role may not vacuum the table.
+func (u *UsersPsqlStorage) VacuumAnalyze(ctx context.Context) error {
+	const op = "storage.users.psql.VacuumAnalyze"
+	log := u.Log.With("op", op, "request_id", requestid.FromContext(ctx))
+
+	if u.tx != nil {
+		log.Error("VACUUM cannot run inside a transaction")
+		return fmt.Errorf("%s: vacuum inside a transaction: %w", op, storageerrors.ErrInvalidArgument)
+	}
+
+	conn, release, err := TryAdvisoryLock(ctx, u.DB, "vacuum:"+u.TableName)
+	if errors.Is(err, storageerrors.ErrLocked) {
+		log.Info("VACUUM ANALYZE already running on another replica")
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err != nil {
+		log.Error("Error acquiring vacuum lock", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer release()
+
+	query := fmt.Sprintf("VACUUM ANALYZE %s;", u.TableName)
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		if sentinel, ok := pqerr.Classify(err); ok {
+			log.Error("Error running VACUUM ANALYZE", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, sentinel)
+		}
+
+		log.Error("Error running VACUUM ANALYZE", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("VACUUM ANALYZE completed", slog.String("table", u.TableName))
+	return nil
+}
+
+// conn returns the transaction the storage is bound to, or the DB pool otherwise.
+func (u *UsersPsqlStorage) conn() dbtx {
+	if u.tx != nil {
+		return u.tx
+	}
+
+	return u.DB
+}
+
+// WithTx runs fn inside a single database transaction.
+// The storage passed to fn is bound to the transaction. The transaction is committed
+// if fn returns nil and rolled back if fn returns an error or panics.
+// Calling WithTx on a storage that is already bound to a transaction reuses it.
+func (u *UsersPsqlStorage) WithTx(ctx context.Context, fn func(txStorage IUsersStorage) error) error {
+	return u.withTx(ctx, nil, fn)
+}
+
+// WithSerializableTx runs fn inside a SERIALIZABLE transaction like WithTx.
+// The whole transaction is retried up to TxRetries times if it fails with a
+// serialization failure or deadlock, so fn must be safe to run more than once.
+func (u *UsersPsqlStorage) WithSerializableTx(ctx context.Context, fn func(txStorage IUsersStorage) error) error {
+	if u.tx != nil {
+		return fn(u)
+	}
+
+	return u.retryOnSerializationFailure(ctx, func() error {
+		return u.withTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable}, fn)
+	})
+}
+
+// retryOnSerializationFailure runs fn and retries it with jittered exponential backoff
+// while it fails with a retryable postgres error, at most TxRetries times.
+func (u *UsersPsqlStorage) retryOnSerializationFailure(ctx context.Context, fn func() error) error {
+	const op = "storage.users.psql.retryOnSerializationFailure"
+	log := u.Log.With("op", op, "request_id", requestid.FromContext(ctx))
+
+	delay := txRetryBaseDelay
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || !pqerr.IsRetryable(err) || attempt >= u.TxRetries {
+			return err
+		}
+
+		wait := delay/2 + rand.N(delay/2+1)
+		log.Warn("Retrying transaction", sl.Err(err), slog.Int("attempt", attempt+1), slog.Duration("backoff", wait))
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w", op, ctx.Err())
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+func (u *UsersPsqlStorage) withTx(ctx context.Context, opts *sql.TxOptions, fn func(txStorage IUsersStorage) error) error {
+	const op = "storage.users.psql.WithTx"
+	log := u.Log.With("op", op, "request_id", requestid.FromContext(ctx))
+
+	if u.tx != nil {
+		return fn(u)
+	}
+
+	tx, err := u.DB.BeginTx(ctx, opts)
+	if err != nil {
+		log.Error("Error beginning transaction", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if err := tx.Rollback(); err != nil {
+				log.Error("Error rolling back transaction after panic", sl.Err(err))
+			}
+			panic(p)
+		}
+	}()
+
+	txStorage := &UsersPsqlStorage{
+		Log:       u.Log,
+		DB:        u.DB,
+		TableName: u.TableName,
+		TxRetries: u.TxRetries,
+		tx:        tx,
+	}
+
+	if err := fn(txStorage); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			log.Error("Error rolling back transaction", sl.Err(rbErr))
+		}
+
+		log.Warn("Transaction rolled back", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Error committing transaction", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// isBadConn reports whether err means the connection was unusable rather than
+// that the query itself failed.
+func isBadConn(err error) bool {
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone)
+}
+
+// retryBadConn runs the idempotent read fn and runs it once more if it failed
+// on a bad connection, e.g. one left stale by a DB failover. Reads inside a
+// transaction are not retried since the transaction is bound to its connection.
+func (u *UsersPsqlStorage) retryBadConn(ctx context.Context, fn func() error) error {
+	err := fn()
+	if err == nil || u.tx != nil || !isBadConn(err) {
+		return err
+	}
+
+	u.Log.With("request_id", requestid.FromContext(ctx)).Warn("Retrying read on a new connection", sl.Err(err))
+	return fn()
+}
+
+// duplicateError refines a unique violation into the error for the duplicated field,
+// based on the violated constraint or unique index. Falls back to storageerrors.ErrAlreadyExists.
+func duplicateError(err error) error {
+	switch pqerr.ConstraintName(err) {
+	case primaryKeyName:
+		return storageerrors.ErrDuplicateId
+	case loginKeyName:
+		return storageerrors.ErrDuplicateLogin
+	default:
+		return storageerrors.ErrAlreadyExists
+	}
+}
+
+// queryUsers runs a query returning full user rows and scans them.
+func (u *UsersPsqlStorage) queryUsers(ctx context.Context, query string, args ...any) ([]models.User, error) {
+	rows, err := u.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bufUser models.User
+	users := make([]models.User, 0, 10)
+	for rows.Next() {
+		if err := rows.Scan(&bufUser.Id, &bufUser.Login, &bufUser.Password, &bufUser.Role); err != nil {
+			return nil, err
+		}
+
+		users = append(users, bufUser)
+	}
+
+	return users, nil
+}
+
 // GetUsers implements app.IUsersStorage.
 func (u *UsersPsqlStorage) GetUsers(ctx context.Context) ([]models.User, error) {
 	const op = "storage.users.psql.GetUsers"
-	log := u.Log.With("op", op)
+	log := u.Log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
@@ -61,32 +403,54 @@ func (u *UsersPsqlStorage) GetUsers(ctx context.Context) ([]models.User, error)
 	}
 
 	query := fmt.Sprintf("SELECT * FROM %s;", u.TableName)
-	rows, err := u.DB.QueryContext(ctx, query)
+	var users []models.User
+	err := u.retryBadConn(ctx, func() error {
+		var err error
+		users, err = u.queryUsers(ctx, query)
+		return err
+	})
 	if err != nil {
-		log.Error("Error getting rows", sl.Err(err))
+		log.Error("Error getting users", sl.Err(err))
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	defer rows.Close()
 
-	var bufUser models.User
-	users := make([]models.User, 0, 10)
-	for rows.Next() {
-		if err := rows.Scan(&bufUser.Id, &bufUser.Login, &bufUser.Password, &bufUser.Role); err != nil {
-			log.Warn("Error scanning row", sl.Err(err))
-			return nil, fmt.Errorf("%s: %w", op, err)
-		}
+	log.Info("Users fetched successfully", slog.Int("count", len(users)))
+	return users, nil
+}
 
-		users = append(users, bufUser)
+// GetUsersByRole implements app.IUsersStorage.
+// The lookup is served by the users role index.
+func (u *UsersPsqlStorage) GetUsersByRole(ctx context.Context, role models.Role) ([]models.User, error) {
+	const op = "storage.users.psql.GetUsersByRole"
+	log := u.Log.With("op", op, "request_id", requestid.FromContext(ctx))
+
+	select {
+	case <-ctx.Done():
+		log.Info("Context cancelled", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
 	}
 
-	log.Info("Users fetched successfully", slog.Int("count", len(users)))
+	query := fmt.Sprintf("SELECT id, login, password, role FROM %s WHERE role = $1;", u.TableName)
+	var users []models.User
+	err := u.retryBadConn(ctx, func() error {
+		var err error
+		users, err = u.queryUsers(ctx, query, role)
+		return err
+	})
+	if err != nil {
+		log.Error("Error getting users", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("Users fetched successfully", slog.String("role", role.String()), slog.Int("count", len(users)))
 	return users, nil
 }
 
 // GetUserById implements app.IUsersStorage.
 func (u *UsersPsqlStorage) GetUserById(ctx context.Context, uid uuid.UUID) (models.User, error) {
 	const op = "storage.users.psql.GetUserById"
-	log := u.Log.With("op", op)
+	log := u.Log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
@@ -97,7 +461,9 @@ func (u *UsersPsqlStorage) GetUserById(ctx context.Context, uid uuid.UUID) (mode
 
 	var user models.User
 	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1;", u.TableName)
-	err := u.DB.QueryRowContext(ctx, query, uid).Scan(&user.Id, &user.Login, &user.Password, &user.Role)
+	err := u.retryBadConn(ctx, func() error {
+		return u.conn().QueryRowContext(ctx, query, uid).Scan(&user.Id, &user.Login, &user.Password, &user.Role)
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			log.Warn("User doesn't exist", sl.Err(storageerrors.ErrNotFound), slog.String("user_id", uid.String()))
@@ -112,10 +478,42 @@ func (u *UsersPsqlStorage) GetUserById(ctx context.Context, uid uuid.UUID) (mode
 	return user, nil
 }
 
+// GetUserByLogin implements app.IUsersStorage.
+// Logins are compared case-insensitively, so the lookup is served by the unique login index.
+func (u *UsersPsqlStorage) GetUserByLogin(ctx context.Context, login string) (models.User, error) {
+	const op = "storage.users.psql.GetUserByLogin"
+	log := u.Log.With("op", op, "request_id", requestid.FromContext(ctx))
+
+	select {
+	case <-ctx.Done():
+		log.Info("Context cancelled", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	var user models.User
+	query := fmt.Sprintf("SELECT * FROM %s WHERE lower(login) = lower($1);", u.TableName)
+	err := u.retryBadConn(ctx, func() error {
+		return u.conn().QueryRowContext(ctx, query, login).Scan(&user.Id, &user.Login, &user.Password, &user.Role)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Warn("User doesn't exist", sl.Err(storageerrors.ErrNotFound))
+			return models.User{}, fmt.Errorf("%s: %w", op, storageerrors.ErrNotFound)
+		}
+
+		log.Error("Error scanning row", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("User fetched successfully", slog.String("user_id", user.Id.String()))
+	return user, nil
+}
+
 // Insert implements app.IUsersStorage.
 func (u *UsersPsqlStorage) Insert(ctx context.Context, user models.User) (models.User, error) {
 	const op = "storage.users.psql.Insert"
-	log := u.Log.With("op", op)
+	log := u.Log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
@@ -125,11 +523,20 @@ func (u *UsersPsqlStorage) Insert(ctx context.Context, user models.User) (models
 	}
 
 	query := fmt.Sprintf("INSERT INTO %s (id, login, password, role) VALUES ($1, $2, $3, $4);", u.TableName)
-	_, err := u.DB.ExecContext(ctx, query, user.Id, user.Login, user.Password, user.Role)
+	_, err := u.conn().ExecContext(ctx, query, user.Id, user.Login, user.Password, user.Role)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
-			log.Warn("User already exists", sl.Err(storageerrors.ErrAlreadyExists), slog.String("user_id", user.Id.String()))
-			return models.User{}, fmt.Errorf("%s: %w", op, storageerrors.ErrAlreadyExists)
+		if errors.Is(err, models.ErrUnknownRole) {
+			log.Warn("Unknown role inserting user", sl.Err(err), slog.String("user_id", user.Id.String()))
+			return models.User{}, fmt.Errorf("%s: %w", op, storageerrors.ErrInvalidArgument)
+		}
+
+		if sentinel, ok := pqerr.Classify(err); ok {
+			if sentinel == storageerrors.ErrAlreadyExists {
+				sentinel = duplicateError(err)
+			}
+
+			log.Warn("Constraint violation inserting user", sl.Err(err), slog.String("user_id", user.Id.String()))
+			return models.User{}, fmt.Errorf("%s: %w", op, sentinel)
 		}
 
 		log.Error("Error inserting user", sl.Err(err), slog.String("user_id", user.Id.String()))
@@ -140,10 +547,35 @@ func (u *UsersPsqlStorage) Insert(ctx context.Context, user models.User) (models
 	return user, nil
 }
 
+// InsertBatch inserts all users in one serializable transaction, retrying it on
+// serialization failures. Either every user is inserted or none is.
+// The error of a failed insert is wrapped in a storageerrors.BatchError naming the user.
+func (u *UsersPsqlStorage) InsertBatch(ctx context.Context, users []models.User) ([]models.User, error) {
+	const op = "storage.users.psql.InsertBatch"
+
+	inserted := make([]models.User, 0, len(users))
+	err := u.WithSerializableTx(ctx, func(txStorage IUsersStorage) error {
+		inserted = inserted[:0]
+		for i, user := range users {
+			insertedUser, err := txStorage.Insert(ctx, user)
+			if err != nil {
+				return &storageerrors.BatchError{Index: i, Err: err}
+			}
+			inserted = append(inserted, insertedUser)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return inserted, nil
+}
+
 // Update implements app.IUsersStorage.
 func (u *UsersPsqlStorage) Update(ctx context.Context, uid uuid.UUID, user models.User) (models.User, error) {
 	const op = "storage.users.psql.Update"
-	log := u.Log.With("op", op)
+	log := u.Log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
@@ -153,8 +585,22 @@ func (u *UsersPsqlStorage) Update(ctx context.Context, uid uuid.UUID, user model
 	}
 
 	query := fmt.Sprintf("UPDATE %s SET login = $1, password = $2, role = $3 WHERE id = $4;", u.TableName)
-	result, err := u.DB.ExecContext(ctx, query, user.Login, user.Password, user.Role, uid)
+	result, err := u.conn().ExecContext(ctx, query, user.Login, user.Password, user.Role, uid)
 	if err != nil {
+		if errors.Is(err, models.ErrUnknownRole) {
+			log.Warn("Unknown role updating user", sl.Err(err), slog.String("user_id", uid.String()))
+			return models.User{}, fmt.Errorf("%s: %w", op, storageerrors.ErrInvalidArgument)
+		}
+
+		if sentinel, ok := pqerr.Classify(err); ok {
+			if sentinel == storageerrors.ErrAlreadyExists {
+				sentinel = duplicateError(err)
+			}
+
+			log.Warn("Constraint violation updating user", sl.Err(err), slog.String("user_id", uid.String()))
+			return models.User{}, fmt.Errorf("%s: %w", op, sentinel)
+		}
+
 		log.Error("Error updating user", sl.Err(err), slog.String("user_id", uid.String()))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
@@ -172,7 +618,7 @@ func (u *UsersPsqlStorage) Update(ctx context.Context, uid uuid.UUID, user model
 // Delete implements app.IUsersStorage.
 func (u *UsersPsqlStorage) Delete(ctx context.Context, uid uuid.UUID) (models.User, error) {
 	const op = "storage.users.psql.Delete"
-	log := u.Log.With("op", op)
+	log := u.Log.With("op", op, "request_id", requestid.FromContext(ctx))
 
 	select {
 	case <-ctx.Done():
@@ -193,7 +639,7 @@ func (u *UsersPsqlStorage) Delete(ctx context.Context, uid uuid.UUID) (models.Us
 	}
 
 	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1;", u.TableName)
-	if _, err := u.DB.ExecContext(ctx, query, uid); err != nil {
+	if _, err := u.conn().ExecContext(ctx, query, uid); err != nil {
 		log.Error("Error deleting user", sl.Err(err), slog.String("user_id", uid.String()))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
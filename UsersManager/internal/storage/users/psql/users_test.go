@@ -4,14 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"os"
 	"regexp"
 	"testing"
 	"usersmanager/internal/domain/models"
+	storageerrors "usersmanager/internal/storage"
 	userspsqlstorage "usersmanager/internal/storage/users/psql"
 	"usersmanager/pkg/lib/logger/handler/slogdiscard"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 func newTestStorage(t *testing.T) (*userspsqlstorage.UsersPsqlStorage, sqlmock.Sqlmock, func()) {
@@ -47,6 +50,8 @@ func TestGetUsers_QueryError(t *testing.T) {
 	storage, mock, cleanup := newTestStorage(t)
 	defer cleanup()
 
+	// A bad connection is retried once, then the error is returned.
+	mock.ExpectQuery("SELECT \\* FROM users;").WillReturnError(sql.ErrConnDone)
 	mock.ExpectQuery("SELECT \\* FROM users;").WillReturnError(sql.ErrConnDone)
 	_, err := storage.GetUsers(context.Background())
 	if err == nil || !errors.Is(err, sql.ErrConnDone) {
@@ -57,6 +62,43 @@ func TestGetUsers_QueryError(t *testing.T) {
 	}
 }
 
+func TestGetUsers_BadConnRetried(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+
+	id := uuid.New()
+	rows := sqlmock.NewRows([]string{"id", "login", "password", "role"}).
+		AddRow(id, "user1", "pass", "user")
+	mock.ExpectQuery("SELECT \\* FROM users;").WillReturnError(sql.ErrConnDone)
+	mock.ExpectQuery("SELECT \\* FROM users;").WillReturnRows(rows)
+
+	users, err := storage.GetUsers(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(users) != 1 || users[0].Id != id {
+		t.Fatalf("unexpected users: %v", users)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetUsers_OtherErrorNotRetried(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+
+	queryErr := errors.New("syntax error")
+	mock.ExpectQuery("SELECT \\* FROM users;").WillReturnError(queryErr)
+	_, err := storage.GetUsers(context.Background())
+	if !errors.Is(err, queryErr) {
+		t.Fatalf("expected query error, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestGetUsers_ScanError(t *testing.T) {
 	storage, mock, cleanup := newTestStorage(t)
 	defer cleanup()
@@ -102,11 +144,46 @@ func TestGetUserById_ScanError(t *testing.T) {
 	}
 }
 
+func TestGetUserByLogin(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+
+	const query = "SELECT * FROM users WHERE lower(login) = lower($1);"
+	id := uuid.New()
+	rows := sqlmock.NewRows([]string{"id", "login", "password", "role"}).
+		AddRow(id, "Alice", "pass", "user")
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("alice").WillReturnRows(rows)
+
+	user, err := storage.GetUserByLogin(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.Id != id || user.Login != "Alice" {
+		t.Fatalf("unexpected user: %v", user)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestGetUserByLogin_NotFound(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM users WHERE lower(login) = lower($1);")).
+		WithArgs("ghost").WillReturnError(sql.ErrNoRows)
+
+	_, err := storage.GetUserByLogin(context.Background(), "ghost")
+	if !errors.Is(err, storageerrors.ErrNotFound) {
+		t.Fatalf("expected storageerrors.ErrNotFound, got %v", err)
+	}
+}
+
 func TestInsert_OtherDBError(t *testing.T) {
 	storage, mock, cleanup := newTestStorage(t)
 	defer cleanup()
 
-	user := models.User{Id: uuid.New(), Login: "user", Password: "pass", Role: "role"}
+	user := models.User{Id: uuid.New(), Login: "user", Password: "pass", Role: models.RoleUser}
 	mock.ExpectExec("INSERT INTO users").
 		WithArgs(user.Id, user.Login, user.Password, user.Role).
 		WillReturnError(sql.ErrConnDone)
@@ -116,10 +193,40 @@ func TestInsert_OtherDBError(t *testing.T) {
 	}
 }
 
+func TestInsert_UnknownRole(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+
+	user := models.User{Id: uuid.New(), Login: "user", Password: "pass", Role: "superuser"}
+	_, err := storage.Insert(context.Background(), user)
+	if !errors.Is(err, storageerrors.ErrInvalidArgument) {
+		t.Fatalf("expected storageerrors.ErrInvalidArgument, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetUserById_UnknownRoleInDB(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+
+	id := uuid.New()
+	rows := sqlmock.NewRows([]string{"id", "login", "password", "role"}).
+		AddRow(id, "user", "pass", "superuser")
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM users WHERE id = $1;")).
+		WithArgs(id).WillReturnRows(rows)
+
+	_, err := storage.GetUserById(context.Background(), id)
+	if !errors.Is(err, models.ErrUnknownRole) {
+		t.Fatalf("expected models.ErrUnknownRole, got %v", err)
+	}
+}
+
 func TestUpdate_DBError(t *testing.T) {
 	storage, mock, cleanup := newTestStorage(t)
 	defer cleanup()
-	user := models.User{Id: uuid.New(), Login: "user", Password: "pass", Role: "role"}
+	user := models.User{Id: uuid.New(), Login: "user", Password: "pass", Role: models.RoleUser}
 	mock.ExpectExec("UPDATE users").
 		WithArgs(user.Login, user.Password, user.Role, user.Id).
 		WillReturnError(sql.ErrConnDone)
@@ -129,10 +236,86 @@ func TestUpdate_DBError(t *testing.T) {
 	}
 }
 
+func TestUpdate_UniqueViolation(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+	user := models.User{Id: uuid.New(), Login: "user", Password: "pass", Role: models.RoleUser}
+	mock.ExpectExec("UPDATE users").
+		WithArgs(user.Login, user.Password, user.Role, user.Id).
+		WillReturnError(&pq.Error{Code: "23505"})
+	_, err := storage.Update(context.Background(), user.Id, user)
+	if !errors.Is(err, storageerrors.ErrAlreadyExists) {
+		t.Fatalf("expected storageerrors.ErrAlreadyExists, got %v", err)
+	}
+}
+
+func TestInsert_DuplicateField(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       error
+	}{
+		{"users_pkey", storageerrors.ErrDuplicateId},
+		{"users_login_key", storageerrors.ErrDuplicateLogin},
+		{"users_other_key", storageerrors.ErrAlreadyExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			storage, mock, cleanup := newTestStorage(t)
+			defer cleanup()
+
+			user := models.User{Id: uuid.New(), Login: "user", Password: "pass", Role: models.RoleUser}
+			mock.ExpectExec("INSERT INTO users").
+				WithArgs(user.Id, user.Login, user.Password, user.Role).
+				WillReturnError(&pq.Error{Code: "23505", Constraint: tt.constraint})
+
+			_, err := storage.Insert(context.Background(), user)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+			if !errors.Is(err, storageerrors.ErrAlreadyExists) {
+				t.Fatalf("expected error to match storageerrors.ErrAlreadyExists, got %v", err)
+			}
+		})
+	}
+}
+
+func TestInsert_DuplicateLogin_OtherTableName(t *testing.T) {
+	// The constraint names come from the migrations, not from TableName.
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+	storage.TableName = "accounts"
+
+	user := models.User{Id: uuid.New(), Login: "taken", Password: "pass", Role: models.RoleUser}
+	mock.ExpectExec("INSERT INTO accounts").
+		WithArgs(user.Id, user.Login, user.Password, user.Role).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "users_login_key"})
+
+	_, err := storage.Insert(context.Background(), user)
+	if !errors.Is(err, storageerrors.ErrDuplicateLogin) {
+		t.Fatalf("expected storageerrors.ErrDuplicateLogin, got %v", err)
+	}
+}
+
+func TestUpdate_DuplicateLogin(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+	user := models.User{Id: uuid.New(), Login: "taken", Password: "pass", Role: models.RoleUser}
+	mock.ExpectExec("UPDATE users").
+		WithArgs(user.Login, user.Password, user.Role, user.Id).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "users_login_key"})
+	_, err := storage.Update(context.Background(), user.Id, user)
+	if !errors.Is(err, storageerrors.ErrDuplicateLogin) {
+		t.Fatalf("expected storageerrors.ErrDuplicateLogin, got %v", err)
+	}
+}
+
 func TestDelete_GetByIdError(t *testing.T) {
 	storage, mock, cleanup := newTestStorage(t)
 	defer cleanup()
 	id := uuid.New()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM users WHERE id = $1;")).
+		WithArgs(id).WillReturnError(sql.ErrConnDone)
 	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM users WHERE id = $1;")).
 		WithArgs(id).WillReturnError(sql.ErrConnDone)
 	_, err := storage.Delete(context.Background(), id)
@@ -157,3 +340,372 @@ func TestDelete_ExecError(t *testing.T) {
 		t.Fatalf("expected delete error, got %v", err)
 	}
 }
+
+func TestWithTx_Commit(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+	user := models.User{Id: uuid.New(), Login: "user", Password: "pass", Role: models.RoleUser}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users").
+		WithArgs(user.Id, user.Login, user.Password, user.Role).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users").
+		WithArgs(user.Login, user.Password, user.Role, user.Id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := storage.WithTx(context.Background(), func(tx userspsqlstorage.IUsersStorage) error {
+		if _, err := tx.Insert(context.Background(), user); err != nil {
+			return err
+		}
+		_, err := tx.Update(context.Background(), user.Id, user)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWithTx_ErrorRollsBack(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+	user := models.User{Id: uuid.New(), Login: "user", Password: "pass", Role: models.RoleUser}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users").
+		WithArgs(user.Id, user.Login, user.Password, user.Role).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users").
+		WithArgs(user.Login, user.Password, user.Role, user.Id).
+		WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+
+	err := storage.WithTx(context.Background(), func(tx userspsqlstorage.IUsersStorage) error {
+		if _, err := tx.Insert(context.Background(), user); err != nil {
+			return err
+		}
+		_, err := tx.Update(context.Background(), user.Id, user)
+		return err
+	})
+	if err == nil || !errors.Is(err, sql.ErrConnDone) {
+		t.Fatalf("expected sql.ErrConnDone, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWithTx_PanicRollsBack(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+	user := models.User{Id: uuid.New(), Login: "user", Password: "pass", Role: models.RoleUser}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users").
+		WithArgs(user.Id, user.Login, user.Password, user.Role).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	func() {
+		defer func() {
+			if p := recover(); p == nil {
+				t.Fatal("expected panic to be propagated")
+			}
+		}()
+
+		_ = storage.WithTx(context.Background(), func(tx userspsqlstorage.IUsersStorage) error {
+			if _, err := tx.Insert(context.Background(), user); err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	}()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWithTx_BeginError(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+
+	mock.ExpectBegin().WillReturnError(sql.ErrConnDone)
+
+	called := false
+	err := storage.WithTx(context.Background(), func(tx userspsqlstorage.IUsersStorage) error {
+		called = true
+		return nil
+	})
+	if err == nil || !errors.Is(err, sql.ErrConnDone) {
+		t.Fatalf("expected sql.ErrConnDone, got %v", err)
+	}
+	if called {
+		t.Error("callback must not run when the transaction cannot be started")
+	}
+}
+
+// expectVacuumLock expects VacuumAnalyze to try its advisory lock, which is granted or not.
+func expectVacuumLock(mock sqlmock.Sqlmock, granted bool) {
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_try_advisory_lock($1);")).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(granted))
+}
+
+func expectVacuumUnlock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1);")).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestVacuumAnalyze(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+
+	expectVacuumLock(mock, true)
+	mock.ExpectExec(regexp.QuoteMeta("VACUUM ANALYZE users;")).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectVacuumUnlock(mock)
+
+	if err := storage.VacuumAnalyze(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestVacuumAnalyze_PermissionDenied(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+
+	expectVacuumLock(mock, true)
+	mock.ExpectExec(regexp.QuoteMeta("VACUUM ANALYZE users;")).WillReturnError(&pq.Error{Code: "42501"})
+	expectVacuumUnlock(mock)
+
+	err := storage.VacuumAnalyze(context.Background())
+	if !errors.Is(err, storageerrors.ErrPermissionDenied) {
+		t.Fatalf("expected storageerrors.ErrPermissionDenied, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestVacuumAnalyze_Locked(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+
+	// Another replica holds the lock, so no VACUUM is sent.
+	expectVacuumLock(mock, false)
+
+	err := storage.VacuumAnalyze(context.Background())
+	if !errors.Is(err, storageerrors.ErrLocked) {
+		t.Fatalf("expected storageerrors.ErrLocked, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestVacuumAnalyze_InsideTx(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	err := storage.WithTx(context.Background(), func(txStorage userspsqlstorage.IUsersStorage) error {
+		return txStorage.(*userspsqlstorage.UsersPsqlStorage).VacuumAnalyze(context.Background())
+	})
+	if !errors.Is(err, storageerrors.ErrInvalidArgument) {
+		t.Fatalf("expected storageerrors.ErrInvalidArgument, got %v", err)
+	}
+}
+
+func TestInsertBatch_RetriesSerializationFailure(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+	storage.TxRetries = 2
+
+	user := models.User{Id: uuid.New(), Login: "user", Password: "pass", Role: models.RoleUser}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users").
+		WithArgs(user.Id, user.Login, user.Password, user.Role).
+		WillReturnError(&pq.Error{Code: "40001"})
+	mock.ExpectRollback()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users").
+		WithArgs(user.Id, user.Login, user.Password, user.Role).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	inserted, err := storage.InsertBatch(context.Background(), []models.User{user})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(inserted) != 1 || inserted[0].Id != user.Id {
+		t.Fatalf("unexpected result: %v", inserted)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestInsertBatch_GivesUpAfterRetries(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+	storage.TxRetries = 1
+
+	user := models.User{Id: uuid.New(), Login: "user", Password: "pass", Role: models.RoleUser}
+
+	for range 2 {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO users").
+			WithArgs(user.Id, user.Login, user.Password, user.Role).
+			WillReturnError(&pq.Error{Code: "40P01"})
+		mock.ExpectRollback()
+	}
+
+	_, err := storage.InsertBatch(context.Background(), []models.User{user})
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "40P01" {
+		t.Fatalf("expected deadlock error, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestInsertBatch_RollsBackOnFailedUser(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+
+	first := models.User{Id: uuid.New(), Login: "first", Password: "pass", Role: models.RoleUser}
+	second := models.User{Id: uuid.New(), Login: "first", Password: "pass", Role: models.RoleUser}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users").
+		WithArgs(first.Id, first.Login, first.Password, first.Role).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO users").
+		WithArgs(second.Id, second.Login, second.Password, second.Role).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "users_login_key"})
+	mock.ExpectRollback()
+
+	_, err := storage.InsertBatch(context.Background(), []models.User{first, second})
+	var batchErr *storageerrors.BatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 1 {
+		t.Fatalf("expected a batch error for user 1, got %v", err)
+	}
+	if !errors.Is(err, storageerrors.ErrDuplicateLogin) {
+		t.Fatalf("expected storageerrors.ErrDuplicateLogin, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestGetUsersByRole(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+
+	const query = "SELECT id, login, password, role FROM users WHERE role = $1;"
+	id := uuid.New()
+	rows := sqlmock.NewRows([]string{"id", "login", "password", "role"}).
+		AddRow(id, "admin1", "pass", "admin")
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("admin").WillReturnRows(rows)
+
+	users, err := storage.GetUsersByRole(context.Background(), "admin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(users) != 1 || users[0].Id != id || users[0].Role != "admin" {
+		t.Fatalf("unexpected users: %v", users)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestGetUsersByRole_Empty(t *testing.T) {
+	storage, mock, cleanup := newTestStorage(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"id", "login", "password", "role"})
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, login, password, role FROM users WHERE role = $1;")).
+		WithArgs("user").WillReturnRows(rows)
+
+	users, err := storage.GetUsersByRole(context.Background(), "user")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if users == nil || len(users) != 0 {
+		t.Fatalf("expected empty non-nil slice, got %v", users)
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []string
+		wantErr error
+	}{
+		{"expected columns", []string{"id", "login", "password", "role"}, nil},
+		{"missing column", []string{"id", "login", "role"}, storageerrors.ErrSchemaMismatch},
+		{"extra column", []string{"id", "login", "password", "role", "email"}, storageerrors.ErrSchemaMismatch},
+		{"reordered columns", []string{"id", "password", "login", "role"}, storageerrors.ErrSchemaMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, mock, cleanup := newTestStorage(t)
+			defer cleanup()
+
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM users LIMIT 0;")).WillReturnRows(sqlmock.NewRows(tt.columns))
+
+			err := storage.CheckSchema(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// TestCheckSchema_Postgres runs against a real database named by
+// USERSMANAGER_TEST_PSQL_CONN_STR and is skipped without one.
+func TestCheckSchema_Postgres(t *testing.T) {
+	connStr := os.Getenv("USERSMANAGER_TEST_PSQL_CONN_STR")
+	if connStr == "" {
+		t.Skip("USERSMANAGER_TEST_PSQL_CONN_STR is not set")
+	}
+
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	// A temporary table lives on one session only, so pin a single connection.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("CREATE TEMP TABLE drifted_users (id UUID, login TEXT, role TEXT, password TEXT);"); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	storage := &userspsqlstorage.UsersPsqlStorage{
+		Log:       slogdiscard.NewDiscardLogger(),
+		DB:        db,
+		TableName: "drifted_users",
+	}
+	if err := storage.CheckSchema(context.Background()); !errors.Is(err, storageerrors.ErrSchemaMismatch) {
+		t.Fatalf("expected ErrSchemaMismatch, got %v", err)
+	}
+}
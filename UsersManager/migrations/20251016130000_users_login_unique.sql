@@ -0,0 +1,24 @@
+-- +goose Up
+-- Описание: Эта миграция делает логин пользователя уникальным
+-- Если в таблице уже есть повторяющиеся логины, миграция останавливается с их списком.
+-- Их нужно исправить вручную, например переименовать или удалить лишние записи:
+--   SELECT login, count(*) FROM users GROUP BY login HAVING count(*) > 1;
+-- +goose StatementBegin
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(login, ', ') INTO duplicates
+    FROM (SELECT login FROM users GROUP BY login HAVING count(*) > 1) AS d;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'users has duplicate logins, resolve them before migrating: %', duplicates;
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+ALTER TABLE users ADD CONSTRAINT users_login_key UNIQUE (login);
+
+-- +goose Down
+-- Описание: Эта миграция снимает уникальность логина
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_login_key;
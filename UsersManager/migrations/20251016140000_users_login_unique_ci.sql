@@ -0,0 +1,29 @@
+-- +goose Up
+-- Описание: Эта миграция делает уникальность логина нечувствительной к регистру
+-- Если в таблице есть логины, отличающиеся только регистром, миграция останавливается
+-- с их списком. Их нужно исправить вручную:
+--   SELECT lower(login), array_agg(login) FROM users GROUP BY lower(login) HAVING count(*) > 1;
+-- +goose StatementBegin
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(logins, '; ') INTO duplicates
+    FROM (
+        SELECT string_agg(login, ', ') AS logins
+        FROM users GROUP BY lower(login) HAVING count(*) > 1
+    ) AS d;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'users has logins differing only in case, resolve them before migrating: %', duplicates;
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_login_key;
+CREATE UNIQUE INDEX IF NOT EXISTS users_login_key ON users (lower(login));
+
+-- +goose Down
+-- Описание: Эта миграция возвращает уникальность логина с учетом регистра
+DROP INDEX IF EXISTS users_login_key;
+ALTER TABLE users ADD CONSTRAINT users_login_key UNIQUE (login);
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Описание: Эта миграция приводит роли к допустимым значениям и ограничивает их
+-- Роли, отличающиеся только регистром или пробелами, приводятся к нормальному виду,
+-- а неизвестные роли заменяются на 'user', чтобы не выдать лишних прав.
+UPDATE users SET role = lower(btrim(role)) WHERE role <> lower(btrim(role));
+UPDATE users SET role = 'user' WHERE role NOT IN ('user', 'admin');
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('user', 'admin'));
+
+-- +goose Down
+-- Описание: Эта миграция снимает ограничение на роли
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Описание: Эта миграция создает индекс по роли пользователей
+CREATE INDEX IF NOT EXISTS idx_users_role ON users (role);
+
+-- +goose Down
+-- Описание: Эта миграция удаляет индекс по роли пользователей
+DROP INDEX IF EXISTS idx_users_role;
@@ -1,9 +1,17 @@
 package config
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"log"
+	"log/slog"
+	"net/url"
 	"os"
+	"regexp"
+	"strings"
+	"time"
+	"usersmanager/pkg/lib/mtls"
 
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/joho/godotenv"
@@ -13,8 +21,271 @@ type Config struct {
 	Env  string `yaml:"env" env-default:"local"`
 	Port int    `yaml:"port" env:"PORT" env-default:"8080"`
 
+	// GRPCMaxConcurrentStreams caps concurrent calls on a single client connection.
+	GRPCMaxConcurrentStreams uint32 `yaml:"grpc_max_concurrent_streams" env:"GRPC_MAX_CONCURRENT_STREAMS" env-default:"100"`
+	// GRPCMethodLimits caps calls per peer and window by method name, e.g. "GetUsers:60".
+	// Off by default: the gateway is usually the only peer, so a limit applies to all its users at once.
+	GRPCMethodLimits map[string]int `yaml:"grpc_method_limits" env:"GRPC_METHOD_LIMITS"`
+	// GRPCRateLimitWindow is the window GRPCMethodLimits are counted in.
+	GRPCRateLimitWindow time.Duration `yaml:"grpc_rate_limit_window" env:"GRPC_RATE_LIMIT_WINDOW" env-default:"1m"`
+	// GRPCMaxConnectionIdle closes client connections that had no calls for this long.
+	GRPCMaxConnectionIdle time.Duration `yaml:"grpc_max_connection_idle" env:"GRPC_MAX_CONNECTION_IDLE" env-default:"5m"`
+	// GRPCMaxConnectionAge closes client connections this old so clients rebalance.
+	GRPCMaxConnectionAge time.Duration `yaml:"grpc_max_connection_age" env:"GRPC_MAX_CONNECTION_AGE" env-default:"30m"`
+	// GRPCKeepaliveMinTime is the shortest client ping interval tolerated; pinging more often closes the connection.
+	GRPCKeepaliveMinTime time.Duration `yaml:"grpc_keepalive_min_time" env:"GRPC_KEEPALIVE_MIN_TIME" env-default:"30s"`
+
 	PsqlConnStr        string `yaml:"psql_conn_str" env:"PSQL_CONN_STR"`
 	PsqlUsersTableName string `yaml:"psql_users_table_name" env:"PSQL_USERS_TABLE_NAME"`
+	// DBPasswordFile is a file, e.g. a mounted secret, holding the DB password.
+	// When set its content replaces any password in PsqlConnStr.
+	DBPasswordFile string `yaml:"db_password_file" env:"DB_PASSWORD_FILE"`
+	// PsqlTxRetries is how many times a serializable transaction is retried after a serialization failure.
+	PsqlTxRetries int `yaml:"psql_tx_retries" env:"PSQL_TX_RETRIES" env-default:"3"`
+	// PsqlConnMaxLifetime recycles pooled connections so stale ones do not outlive a failover.
+	PsqlConnMaxLifetime time.Duration `yaml:"psql_conn_max_lifetime" env:"PSQL_CONN_MAX_LIFETIME" env-default:"5m"`
+	// PsqlStatementTimeout is the Postgres statement_timeout of every session, so the server
+	// cancels runaway queries even if the Go-side deadline is missed. Zero keeps the server default.
+	PsqlStatementTimeout time.Duration `yaml:"psql_statement_timeout" env:"PSQL_STATEMENT_TIMEOUT" env-default:"30s"`
+	// PsqlApplicationName names the service's connections in pg_stat_activity. Empty uses
+	// "usersmanager-<hostname>". An application_name already set in PsqlConnStr is kept.
+	PsqlApplicationName string `yaml:"psql_application_name" env:"PSQL_APPLICATION_NAME"`
+	// PsqlWarmupConns is how many connections are opened at startup so the first requests
+	// find them ready. Zero disables the warmup.
+	PsqlWarmupConns int `yaml:"psql_warmup_conns" env:"PSQL_WARMUP_CONNS" env-default:"0"`
+
+	// PanicReportURL receives a JSON record for every recovered panic. Empty disables reporting.
+	PanicReportURL     string        `yaml:"panic_report_url" env:"PANIC_REPORT_URL"`
+	PanicReportTimeout time.Duration `yaml:"panic_report_timeout" env:"PANIC_REPORT_TIMEOUT" env-default:"2s"`
+
+	// ShutdownDelay is how long the gRPC health service reports NOT_SERVING before the
+	// server stops, so load balancers can drain the instance.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" env:"SHUTDOWN_DELAY" env-default:"0s"`
+
+	// DBHealthInterval is how often the DB is pinged to report health transitions.
+	DBHealthInterval time.Duration `yaml:"db_health_interval" env:"DB_HEALTH_INTERVAL" env-default:"10s"`
+
+	// AllowInsecureDB lets prod start with sslmode=disable. Only for special cases.
+	AllowInsecureDB bool `yaml:"allow_insecure_db" env:"ALLOW_INSECURE_DB" env-default:"false"`
+
+	// LogPayloads logs gRPC request and response payloads at debug level with passwords
+	// cleared. Only for debugging.
+	LogPayloads bool `yaml:"log_payloads" env:"LOG_PAYLOADS" env-default:"false"`
+
+	// TLSCertFile and TLSKeyFile enable TLS on the gRPC server. With TLSClientCAFile set,
+	// clients must present a certificate signed by that CA (mutual TLS).
+	TLSCertFile     string `yaml:"tls_cert_file" env:"TLS_CERT_FILE"`
+	TLSKeyFile      string `yaml:"tls_key_file" env:"TLS_KEY_FILE"`
+	TLSClientCAFile string `yaml:"tls_client_ca_file" env:"TLS_CLIENT_CA_FILE"`
+	// TLSMinVersion is the lowest TLS version the gRPC server accepts, "1.2" or "1.3".
+	TLSMinVersion string `yaml:"tls_min_version" env:"TLS_MIN_VERSION" env-default:"1.2"`
+	// TLSCipherSuites restricts TLS 1.2 to these cipher suites, by Go name. Empty keeps the Go defaults.
+	TLSCipherSuites []string `yaml:"tls_cipher_suites" env:"TLS_CIPHER_SUITES" env-separator:","`
+	// AllowedClientCNs restricts mTLS clients to these certificate common names. Empty allows any verified client.
+	AllowedClientCNs []string `yaml:"allowed_client_cns" env:"ALLOWED_CLIENT_CNS" env-separator:","`
+}
+
+var (
+	ErrInsecureDB                 = errors.New("sslmode=disable is not allowed in prod, set ALLOW_INSECURE_DB to override")
+	ErrClientAllowlistWithoutMTLS = errors.New("ALLOWED_CLIENT_CNS requires TLS_CERT_FILE, TLS_KEY_FILE and TLS_CLIENT_CA_FILE")
+)
+
+// Validate checks the loaded config for unsafe combinations.
+func (c *Config) Validate() error {
+	if c.Env == EnvProd && !c.AllowInsecureDB && sslMode(c.PsqlConnStr) == "disable" {
+		return ErrInsecureDB
+	}
+
+	if len(c.AllowedClientCNs) > 0 && !c.MTLSEnabled() {
+		return ErrClientAllowlistWithoutMTLS
+	}
+
+	if _, err := c.TLSPolicy(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// TLSPolicy returns the protocol version and cipher suite policy of the gRPC server.
+func (c *Config) TLSPolicy() (mtls.Policy, error) {
+	return mtls.ParsePolicy(c.TLSMinVersion, c.TLSCipherSuites)
+}
+
+// TLSEnabled reports whether the gRPC server serves TLS.
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// MTLSEnabled reports whether the gRPC server requires verified client certificates.
+func (c *Config) MTLSEnabled() bool {
+	return c.TLSEnabled() && c.TLSClientCAFile != ""
+}
+
+// sslMode extracts the sslmode parameter from a postgres URL or key=value DSN.
+// Returns an empty string if it is not set.
+func sslMode(connStr string) string {
+	if strings.HasPrefix(connStr, "postgres://") || strings.HasPrefix(connStr, "postgresql://") {
+		u, err := url.Parse(connStr)
+		if err != nil {
+			return ""
+		}
+		return u.Query().Get("sslmode")
+	}
+
+	for _, field := range strings.Fields(connStr) {
+		key, value, ok := strings.Cut(field, "=")
+		if ok && key == "sslmode" {
+			return strings.Trim(value, "'")
+		}
+	}
+
+	return ""
+}
+
+// dsnPassword matches the password of a key=value DSN, quoted or not.
+var dsnPassword = regexp.MustCompile(`(^|\s)password\s*=\s*('(?:[^'\\]|\\.)*'|\S*)`)
+
+// LogValue logs the config with the DB password masked, whether it is inline in
+// PsqlConnStr or was injected there from DBPasswordFile.
+func (c Config) LogValue() slog.Value {
+	// plain has no LogValue method, so logging it does not recurse.
+	type plain Config
+	c.PsqlConnStr = redactPassword(c.PsqlConnStr)
+	return slog.AnyValue(plain(c))
+}
+
+// redactPassword masks the password of a postgres URL or key=value DSN.
+func redactPassword(connStr string) string {
+	if strings.HasPrefix(connStr, "postgres://") || strings.HasPrefix(connStr, "postgresql://") {
+		u, err := url.Parse(connStr)
+		if err != nil {
+			// Unparsable, so the password cannot be told apart; hide it all.
+			return "REDACTED"
+		}
+		return u.Redacted()
+	}
+
+	return dsnPassword.ReplaceAllString(connStr, "${1}password=REDACTED")
+}
+
+// LoadSecrets reads secrets configured as files and injects them into the config.
+func (c *Config) LoadSecrets() error {
+	if c.DBPasswordFile == "" {
+		return nil
+	}
+
+	password, err := readSecretFile(c.DBPasswordFile)
+	if err != nil {
+		return fmt.Errorf("db password file: %w", err)
+	}
+
+	connStr, err := withPassword(c.PsqlConnStr, password)
+	if err != nil {
+		return fmt.Errorf("db password file: %w", err)
+	}
+	c.PsqlConnStr = connStr
+
+	return nil
+}
+
+// SetApplicationName adds application_name to PsqlConnStr unless it already has one.
+func (c *Config) SetApplicationName() error {
+	name := c.PsqlApplicationName
+	if name == "" {
+		name = "usersmanager"
+		if hostname, err := os.Hostname(); err == nil && hostname != "" {
+			name += "-" + hostname
+		}
+	}
+
+	connStr, err := withApplicationName(c.PsqlConnStr, name)
+	if err != nil {
+		return fmt.Errorf("application name: %w", err)
+	}
+	c.PsqlConnStr = connStr
+
+	return nil
+}
+
+// readSecretFile returns the content of a secret file with surrounding whitespace trimmed.
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("%s is empty", path)
+	}
+
+	return secret, nil
+}
+
+// withPassword sets the password of a postgres URL or key=value DSN, replacing an inline one.
+func withPassword(connStr, password string) (string, error) {
+	if strings.HasPrefix(connStr, "postgres://") || strings.HasPrefix(connStr, "postgresql://") {
+		u, err := url.Parse(connStr)
+		if err != nil {
+			return "", fmt.Errorf("parse connection string: %w", err)
+		}
+		u.User = url.UserPassword(u.User.Username(), password)
+		return u.String(), nil
+	}
+
+	fields := make([]string, 0, len(strings.Fields(connStr))+1)
+	for _, field := range strings.Fields(connStr) {
+		if !strings.HasPrefix(field, "password=") {
+			fields = append(fields, field)
+		}
+	}
+
+	quoted := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(password)
+	fields = append(fields, "password='"+quoted+"'")
+
+	return strings.Join(fields, " "), nil
+}
+
+// withApplicationName sets application_name of a postgres URL or key=value DSN
+// that does not have one yet.
+func withApplicationName(connStr, name string) (string, error) {
+	if strings.HasPrefix(connStr, "postgres://") || strings.HasPrefix(connStr, "postgresql://") {
+		u, err := url.Parse(connStr)
+		if err != nil {
+			return "", fmt.Errorf("parse connection string: %w", err)
+		}
+		query := u.Query()
+		if query.Has("application_name") {
+			return connStr, nil
+		}
+		query.Set("application_name", name)
+		u.RawQuery = query.Encode()
+		return u.String(), nil
+	}
+
+	for _, field := range strings.Fields(connStr) {
+		if strings.HasPrefix(field, "application_name=") {
+			return connStr, nil
+		}
+	}
+
+	quoted := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(name)
+	return strings.TrimSpace(connStr + " application_name='" + quoted + "'"), nil
+}
+
+func mustPrepare(cfg *Config) {
+	if err := cfg.LoadSecrets(); err != nil {
+		panic(fmt.Sprintf("cannot load secrets: %s", err))
+	}
+
+	if err := cfg.SetApplicationName(); err != nil {
+		panic(fmt.Sprintf("cannot set application name: %s", err))
+	}
+
+	if err := cfg.Validate(); err != nil {
+		panic(fmt.Sprintf("invalid config: %s", err))
+	}
 }
 
 func MustLoad() *Config {
@@ -38,6 +309,8 @@ func MustLoadEnv() *Config {
 		panic("cannot read config from environment: " + err.Error())
 	}
 
+	mustPrepare(&cfg)
+
 	return &cfg
 }
 
@@ -53,6 +326,8 @@ func MustLoadPath(configPath string) *Config {
 		panic("cannot read config: " + err.Error())
 	}
 
+	mustPrepare(&cfg)
+
 	return &cfg
 }
 
@@ -0,0 +1,221 @@
+package config_test
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"usersmanager/pkg/config"
+	"usersmanager/pkg/lib/mtls"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		wantErr error
+	}{
+		{
+			name:    "prod with sslmode=disable is rejected",
+			cfg:     config.Config{Env: config.EnvProd, PsqlConnStr: "host=db user=u dbname=users sslmode=disable"},
+			wantErr: config.ErrInsecureDB,
+		},
+		{
+			name:    "prod url with sslmode=disable is rejected",
+			cfg:     config.Config{Env: config.EnvProd, PsqlConnStr: "postgres://u:p@db:5432/users?sslmode=disable"},
+			wantErr: config.ErrInsecureDB,
+		},
+		{
+			name: "prod with sslmode=require is accepted",
+			cfg:  config.Config{Env: config.EnvProd, PsqlConnStr: "host=db user=u dbname=users sslmode=require"},
+		},
+		{
+			name: "prod with sslmode=disable and override is accepted",
+			cfg:  config.Config{Env: config.EnvProd, PsqlConnStr: "host=db sslmode=disable", AllowInsecureDB: true},
+		},
+		{
+			name: "local with sslmode=disable is accepted",
+			cfg:  config.Config{Env: config.EnvLocal, PsqlConnStr: "postgres://u:p@localhost/users?sslmode=disable"},
+		},
+		{
+			name:    "client allowlist without mtls is rejected",
+			cfg:     config.Config{Env: config.EnvLocal, AllowedClientCNs: []string{"apigateway"}},
+			wantErr: config.ErrClientAllowlistWithoutMTLS,
+		},
+		{
+			name: "client allowlist with mtls is accepted",
+			cfg: config.Config{
+				Env:              config.EnvLocal,
+				TLSCertFile:      "server.crt",
+				TLSKeyFile:       "server.key",
+				TLSClientCAFile:  "ca.crt",
+				AllowedClientCNs: []string{"apigateway"},
+			},
+		},
+		{
+			name:    "tls 1.1 minimum is rejected",
+			cfg:     config.Config{Env: config.EnvLocal, TLSMinVersion: "1.1"},
+			wantErr: mtls.ErrInsecureTLSVersion,
+		},
+		{
+			name:    "unknown tls version is rejected",
+			cfg:     config.Config{Env: config.EnvLocal, TLSMinVersion: "2"},
+			wantErr: mtls.ErrUnknownTLSVersion,
+		},
+		{
+			name:    "insecure cipher suite is rejected",
+			cfg:     config.Config{Env: config.EnvLocal, TLSCipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+			wantErr: mtls.ErrInsecureCipherSuite,
+		},
+		{
+			name:    "unknown cipher suite is rejected",
+			cfg:     config.Config{Env: config.EnvLocal, TLSCipherSuites: []string{"TLS_NOPE"}},
+			wantErr: mtls.ErrUnknownCipherSuite,
+		},
+		{
+			name: "cipher suites with tls 1.3 minimum are rejected",
+			cfg: config.Config{
+				Env:             config.EnvLocal,
+				TLSMinVersion:   "1.3",
+				TLSCipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+			},
+			wantErr: mtls.ErrCipherSuitesWithTLS13,
+		},
+		{
+			name: "secure cipher suites are accepted",
+			cfg: config.Config{
+				Env:             config.EnvLocal,
+				TLSMinVersion:   "1.2",
+				TLSCipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.cfg.Validate(), tt.wantErr)
+		})
+	}
+}
+
+func TestConfig_LogValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		connStr string
+		want    string
+	}{
+		{"url dsn", "postgres://app:s3cret@db:5432/users?sslmode=require", "postgres://app:xxxxx@db:5432/users?sslmode=require"},
+		{"key value dsn", "host=db user=app password=s3cret dbname=users", "host=db user=app password=REDACTED dbname=users"},
+		{"quoted password", `host=db password='s3 \'cret' dbname=users`, "host=db password=REDACTED dbname=users"},
+		{"no password", "host=db user=app dbname=users", "host=db user=app dbname=users"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log := slog.New(slog.NewJSONHandler(&buf, nil))
+			cfg := &config.Config{Env: config.EnvLocal, PsqlConnStr: tt.connStr}
+
+			log.Info("application", slog.Any("config", cfg))
+
+			assert.NotContains(t, buf.String(), "s3")
+			assert.Contains(t, buf.String(), tt.want)
+			assert.Equal(t, tt.connStr, cfg.PsqlConnStr)
+		})
+	}
+}
+
+func writeSecret(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "db-password")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestConfig_LoadSecrets(t *testing.T) {
+	t.Run("url dsn", func(t *testing.T) {
+		cfg := config.Config{
+			PsqlConnStr:    "postgres://app:inline@db:5432/users?sslmode=require",
+			DBPasswordFile: writeSecret(t, "from-file\n"),
+		}
+
+		require.NoError(t, cfg.LoadSecrets())
+		assert.Equal(t, "postgres://app:from-file@db:5432/users?sslmode=require", cfg.PsqlConnStr)
+	})
+
+	t.Run("key value dsn", func(t *testing.T) {
+		cfg := config.Config{
+			PsqlConnStr:    "host=db user=app password=inline dbname=users",
+			DBPasswordFile: writeSecret(t, "  it's secret  "),
+		}
+
+		require.NoError(t, cfg.LoadSecrets())
+		assert.Equal(t, `host=db user=app dbname=users password='it\'s secret'`, cfg.PsqlConnStr)
+	})
+
+	t.Run("no file configured", func(t *testing.T) {
+		cfg := config.Config{PsqlConnStr: "host=db password=inline"}
+
+		require.NoError(t, cfg.LoadSecrets())
+		assert.Equal(t, "host=db password=inline", cfg.PsqlConnStr)
+	})
+
+	t.Run("unreadable file", func(t *testing.T) {
+		cfg := config.Config{DBPasswordFile: filepath.Join(t.TempDir(), "missing")}
+
+		assert.ErrorIs(t, cfg.LoadSecrets(), os.ErrNotExist)
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		cfg := config.Config{DBPasswordFile: writeSecret(t, " \n")}
+
+		assert.Error(t, cfg.LoadSecrets())
+	})
+}
+
+func TestConfig_SetApplicationName(t *testing.T) {
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		wantDSN string
+	}{
+		{
+			name:    "key value dsn",
+			cfg:     config.Config{PsqlConnStr: "host=db dbname=users", PsqlApplicationName: "usersmanager-a"},
+			wantDSN: "host=db dbname=users application_name='usersmanager-a'",
+		},
+		{
+			name:    "url dsn",
+			cfg:     config.Config{PsqlConnStr: "postgres://app:p@db:5432/users?sslmode=require", PsqlApplicationName: "usersmanager-a"},
+			wantDSN: "postgres://app:p@db:5432/users?application_name=usersmanager-a&sslmode=require",
+		},
+		{
+			name:    "defaults to the hostname",
+			cfg:     config.Config{PsqlConnStr: "host=db"},
+			wantDSN: "host=db application_name='usersmanager-" + hostname + "'",
+		},
+		{
+			name:    "dsn setting is kept",
+			cfg:     config.Config{PsqlConnStr: "host=db application_name=custom", PsqlApplicationName: "usersmanager-a"},
+			wantDSN: "host=db application_name=custom",
+		},
+		{
+			name:    "url setting is kept",
+			cfg:     config.Config{PsqlConnStr: "postgres://db/users?application_name=custom", PsqlApplicationName: "usersmanager-a"},
+			wantDSN: "postgres://db/users?application_name=custom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.cfg.SetApplicationName())
+			assert.Equal(t, tt.wantDSN, tt.cfg.PsqlConnStr)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package actor
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// gRPC metadata keys carrying the actor from the gateway.
+const (
+	MetadataKeyId   = "x-actor-id"
+	MetadataKeyRole = "x-actor-role"
+)
+
+var ErrInvalidActor = errors.New("invalid actor metadata")
+
+// Actor is the user the gateway authenticated and makes the call on behalf of.
+type Actor struct {
+	Id   uuid.UUID
+	Role string
+}
+
+type ctxKey struct{}
+
+// WithActor returns a copy of ctx carrying the actor.
+func WithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, ctxKey{}, a)
+}
+
+// FromContext returns the actor stored in ctx and whether one was set.
+func FromContext(ctx context.Context) (Actor, bool) {
+	a, ok := ctx.Value(ctxKey{}).(Actor)
+	return a, ok
+}
+
+// actorFromMetadata reads the actor forwarded in md. It reports false if md
+// carries no actor and returns ErrInvalidActor if the actor id is malformed.
+func actorFromMetadata(md metadata.MD) (Actor, bool, error) {
+	ids := md.Get(MetadataKeyId)
+	if len(ids) == 0 || ids[0] == "" {
+		return Actor{}, false, nil
+	}
+
+	id, err := uuid.Parse(ids[0])
+	if err != nil {
+		return Actor{}, false, ErrInvalidActor
+	}
+
+	var role string
+	if roles := md.Get(MetadataKeyRole); len(roles) > 0 {
+		role = roles[0]
+	}
+
+	return Actor{Id: id, Role: role}, true, nil
+}
+
+// UnaryServerInterceptor stores the actor received in incoming gRPC metadata in the
+// handler context. Calls with a malformed actor are rejected with codes.InvalidArgument.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			a, found, err := actorFromMetadata(md)
+			if err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+			if found {
+				ctx = WithActor(ctx, a)
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
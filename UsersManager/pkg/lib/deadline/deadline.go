@@ -0,0 +1,29 @@
+package deadline
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor rejects calls that are already over when the handler
+// is about to run, e.g. after waiting behind slow calls, so no database work is
+// spent on a response the client has given up on. It answers
+// codes.DeadlineExceeded once the deadline has passed, even if the client reset
+// the stream first, and codes.Canceled for a canceled call. Chain it last so it
+// checks right before the handler.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if dl, ok := ctx.Deadline(); ok && !time.Now().Before(dl) {
+			return nil, status.Error(codes.DeadlineExceeded, "deadline exceeded before the call was handled")
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, status.FromContextError(err).Err()
+		}
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,176 @@
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+var ErrNoCACerts = errors.New("no CA certificates found")
+
+var (
+	ErrInsecureTLSVersion    = errors.New("TLS versions below 1.2 are not allowed")
+	ErrUnknownTLSVersion     = errors.New("unknown TLS version")
+	ErrUnknownCipherSuite    = errors.New("unknown cipher suite")
+	ErrInsecureCipherSuite   = errors.New("insecure cipher suite")
+	ErrCipherSuitesWithTLS13 = errors.New("cipher suites cannot be configured when the minimum is TLS 1.3")
+)
+
+// tlsVersions maps configured version names to their protocol versions.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Policy restricts the protocol versions and cipher suites the server negotiates.
+type Policy struct {
+	MinVersion uint16
+	// CipherSuites is the allow-list for TLS 1.2; nil keeps the Go defaults.
+	// TLS 1.3 suites are not configurable and always enabled.
+	CipherSuites []uint16
+}
+
+// ParsePolicy builds a Policy from a minimum version such as "1.2" and cipher
+// suite names such as "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256". An empty version
+// means TLS 1.2. Versions below 1.2 and suites Go considers insecure are rejected,
+// as are suites combined with a TLS 1.3 minimum, where they would be ignored.
+func ParsePolicy(minVersion string, cipherSuites []string) (Policy, error) {
+	const op = "mtls.ParsePolicy"
+
+	policy := Policy{MinVersion: tls.VersionTLS12}
+	if minVersion != "" {
+		version, ok := tlsVersions[minVersion]
+		if !ok {
+			return Policy{}, fmt.Errorf("%s: %q: %w", op, minVersion, ErrUnknownTLSVersion)
+		}
+		if version < tls.VersionTLS12 {
+			return Policy{}, fmt.Errorf("%s: %q: %w", op, minVersion, ErrInsecureTLSVersion)
+		}
+		policy.MinVersion = version
+	}
+
+	if len(cipherSuites) == 0 {
+		return policy, nil
+	}
+	if policy.MinVersion >= tls.VersionTLS13 {
+		return Policy{}, fmt.Errorf("%s: %w", op, ErrCipherSuitesWithTLS13)
+	}
+
+	for _, name := range cipherSuites {
+		id, err := cipherSuiteID(name)
+		if err != nil {
+			return Policy{}, fmt.Errorf("%s: %q: %w", op, name, err)
+		}
+		policy.CipherSuites = append(policy.CipherSuites, id)
+	}
+
+	return policy, nil
+}
+
+// cipherSuiteID returns the ID of the named TLS 1.2 cipher suite.
+func cipherSuiteID(name string) (uint16, error) {
+	for _, suite := range tls.InsecureCipherSuites() {
+		if suite.Name == name {
+			return 0, ErrInsecureCipherSuite
+		}
+	}
+
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name && slices.Contains(suite.SupportedVersions, tls.VersionTLS12) {
+			return suite.ID, nil
+		}
+	}
+
+	return 0, ErrUnknownCipherSuite
+}
+
+// ServerCredentials loads the server key pair for gRPC TLS. If clientCAFile is
+// not empty, clients must present a certificate signed by one of its CAs.
+func ServerCredentials(certFile, keyFile, clientCAFile string, policy Policy) (credentials.TransportCredentials, error) {
+	cfg, err := ServerConfig(certFile, keyFile, clientCAFile, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials.NewTLS(cfg), nil
+}
+
+// ServerConfig builds the server TLS config used by ServerCredentials. A zero
+// policy minimum means TLS 1.2.
+func ServerConfig(certFile, keyFile, clientCAFile string, policy Policy) (*tls.Config, error) {
+	const op = "mtls.ServerConfig"
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   max(policy.MinVersion, tls.VersionTLS12),
+		CipherSuites: policy.CipherSuites,
+	}
+
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: %s: %w", op, clientCAFile, ErrNoCACerts)
+		}
+
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return cfg, nil
+}
+
+// UnaryServerInterceptor rejects calls with codes.PermissionDenied unless the
+// verified client certificate's common name is in allowed. An empty list
+// allows every client.
+func UnaryServerInterceptor(allowed []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if len(allowed) == 0 {
+			return handler(ctx, req)
+		}
+
+		cn, ok := clientCommonName(ctx)
+		if !ok || !slices.Contains(allowed, cn) {
+			return nil, status.Error(codes.PermissionDenied, "client is not allowed")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// clientCommonName returns the common name of the client certificate verified
+// during the TLS handshake.
+func clientCommonName(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+
+	return tlsInfo.State.VerifiedChains[0][0].Subject.CommonName, true
+}
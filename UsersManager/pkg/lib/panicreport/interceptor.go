@@ -0,0 +1,40 @@
+package panicreport
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+	"usersmanager/pkg/lib/logger/sl"
+	"usersmanager/pkg/lib/requestid"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor turns a panicking handler into a codes.Internal error.
+// The panic is logged with its stack and passed to reporter.
+func UnaryServerInterceptor(log *slog.Logger, reporter PanicReporter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+
+			panicErr := ToError(p)
+			stack := debug.Stack()
+			log.Error("Recovered from panic",
+				sl.Err(panicErr),
+				slog.String("method", info.FullMethod),
+				slog.String("request_id", requestid.FromContext(ctx)),
+				slog.String("stack", string(stack)),
+			)
+			reporter.Report(panicErr, stack)
+
+			resp, err = nil, status.Error(codes.Internal, "internal error")
+		}()
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,139 @@
+// Package panicreport sends recovered panics to an error tracker.
+//
+// API-Gateway and UsersManager are separate Go modules with no shared library
+// module, so this package exists in both under pkg/lib/panicreport.
+// panicreport.go is the same in both copies; UsersManager adds the gRPC interceptor.
+// Change the two copies together.
+package panicreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// PanicReporter sends recovered panics to an error tracker.
+// Report must be safe for concurrent use and should not block for long.
+type PanicReporter interface {
+	Report(err error, stack []byte)
+}
+
+// Nop is the PanicReporter used when no error tracker is configured.
+type Nop struct{}
+
+func (Nop) Report(error, []byte) {}
+
+// ReporterFunc adapts a function to PanicReporter.
+type ReporterFunc func(err error, stack []byte)
+
+func (f ReporterFunc) Report(err error, stack []byte) {
+	f(err, stack)
+}
+
+// Record is the JSON body HTTPReporter posts for each panic.
+type Record struct {
+	Service string    `json:"service"`
+	Error   string    `json:"error"`
+	Stack   string    `json:"stack"`
+	Time    time.Time `json:"time"`
+}
+
+const (
+	// DefaultTimeout bounds a report when New is given no positive timeout.
+	DefaultTimeout = 2 * time.Second
+	// MaxInFlight is the most reports HTTPReporter posts at once. Further panics are
+	// not reported until one of them is done.
+	MaxInFlight = 8
+)
+
+// HTTPReporter posts a Record for each panic to an endpoint.
+type HTTPReporter struct {
+	endpoint string
+	service  string
+	timeout  time.Duration
+	client   *http.Client
+	inFlight chan struct{}
+}
+
+// New returns an HTTPReporter posting to endpoint, or Nop if endpoint is empty.
+// Each report is bounded by timeout, or by DefaultTimeout if timeout is not positive.
+func New(endpoint, service string, timeout time.Duration) PanicReporter {
+	if endpoint == "" {
+		return Nop{}
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &HTTPReporter{
+		endpoint: endpoint,
+		service:  service,
+		timeout:  timeout,
+		client:   &http.Client{Timeout: timeout},
+		inFlight: make(chan struct{}, MaxInFlight),
+	}
+}
+
+// Report posts the panic in the background and returns at once, so a slow or
+// unreachable tracker never holds up the recovering request. Reports over
+// MaxInFlight and delivery failures are dropped, as there is nowhere left to
+// report them.
+func (h *HTTPReporter) Report(err error, stack []byte) {
+	record := Record{
+		Service: h.service,
+		Error:   err.Error(),
+		Stack:   string(stack),
+		Time:    time.Now().UTC(),
+	}
+
+	select {
+	case h.inFlight <- struct{}{}:
+	default:
+		return
+	}
+
+	go func() {
+		defer func() { <-h.inFlight }()
+		_ = h.send(record)
+	}()
+}
+
+func (h *HTTPReporter) send(record Record) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("panic report rejected with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// ToError turns a value recovered from a panic into an error.
+func ToError(recovered any) error {
+	if err, ok := recovered.(error); ok {
+		return fmt.Errorf("panic: %w", err)
+	}
+
+	return fmt.Errorf("panic: %v", recovered)
+}
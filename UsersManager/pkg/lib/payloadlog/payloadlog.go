@@ -0,0 +1,86 @@
+package payloadlog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"usersmanager/pkg/lib/requestid"
+
+	umv1 "github.com/chas3air/protos/gen/go/usersManager"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var (
+	userDescriptor = (&umv1.User{}).ProtoReflect().Descriptor()
+	passwordField  = userDescriptor.Fields().ByName("password")
+)
+
+// UnaryServerInterceptor logs the request and response payload of every call at
+// debug level, for debugging. Passwords of users are cleared on a copy of the
+// payload first, so the logs never hold credentials.
+func UnaryServerInterceptor(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !log.Enabled(ctx, slog.LevelDebug) {
+			return handler(ctx, req)
+		}
+
+		log := log.With(
+			slog.String("method", info.FullMethod),
+			slog.String("request_id", requestid.FromContext(ctx)),
+		)
+		log.Debug("Request payload", slog.String("payload", Masked(req)))
+
+		resp, err := handler(ctx, req)
+		if err == nil {
+			log.Debug("Response payload", slog.String("payload", Masked(resp)))
+		}
+
+		return resp, err
+	}
+}
+
+// Masked renders payload as JSON with the password of every user in it cleared.
+// payload itself is left untouched. Payloads that are not protobuf messages are
+// rendered as their type only, since their fields cannot be masked.
+func Masked(payload any) string {
+	msg, ok := payload.(proto.Message)
+	if !ok {
+		return fmt.Sprintf("%T", payload)
+	}
+
+	masked := proto.Clone(msg)
+	clearPasswords(masked.ProtoReflect())
+
+	return protojson.Format(masked)
+}
+
+// clearPasswords clears the password of m and of every user nested in it.
+func clearPasswords(m protoreflect.Message) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					clearPasswords(mv.Message())
+					return true
+				})
+			}
+		case fd.IsList():
+			if fd.Message() != nil {
+				for i := 0; i < v.List().Len(); i++ {
+					clearPasswords(v.List().Get(i).Message())
+				}
+			}
+		case fd.Message() != nil:
+			clearPasswords(v.Message())
+		}
+		return true
+	})
+
+	if m.Descriptor().FullName() == userDescriptor.FullName() {
+		m.Clear(passwordField)
+	}
+}
@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Limiter limits how many calls each peer may make to a gRPC method per window.
+// Calls are counted per method and peer host in fixed windows kept in memory.
+type Limiter struct {
+	mu        sync.Mutex
+	limits    map[string]int
+	window    time.Duration
+	counters  map[key]*counter
+	nextSweep time.Time
+}
+
+type key struct {
+	method string
+	peer   string
+}
+
+type counter struct {
+	start time.Time
+	count int
+}
+
+// New creates a limiter allowing limits[method] calls per window for each peer.
+// Methods are given by their short name, e.g. "GetUsers". Methods without a
+// positive limit are not limited.
+func New(limits map[string]int, window time.Duration) *Limiter {
+	return &Limiter{
+		limits:   limits,
+		window:   window,
+		counters: make(map[key]*counter),
+	}
+}
+
+// ReasonRateLimited is the google.rpc.ErrorInfo reason of calls rejected by the limiter.
+const ReasonRateLimited = "RATE_LIMITED"
+
+// UnaryServerInterceptor rejects calls with codes.ResourceExhausted once the
+// peer has used up the limit of the called method. The status carries an
+// ErrorInfo with ReasonRateLimited in domain and a RetryInfo with the time left
+// in the window, so clients can tell it apart from oversized responses.
+func (l *Limiter) UnaryServerInterceptor(domain string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		method := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+
+		limit := l.limits[method]
+		if limit <= 0 || l.window <= 0 {
+			return handler(ctx, req)
+		}
+
+		if retryAfter, ok := l.allow(key{method: method, peer: peerHost(ctx)}, limit, time.Now()); !ok {
+			return nil, rateLimitedError(method, domain, retryAfter)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitedError builds the status of a call rejected by the limiter.
+func rateLimitedError(method, domain string, retryAfter time.Duration) error {
+	st := status.Newf(codes.ResourceExhausted, "rate limit exceeded for %s", method)
+	detailed, err := st.WithDetails(
+		&errdetails.ErrorInfo{Reason: ReasonRateLimited, Domain: domain},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)},
+	)
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
+
+// allow counts a call for k at now and reports whether it is within limit. If it is
+// not, it also returns the time left until the window resets.
+func (l *Limiter) allow(k key, limit int, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	start := now.Truncate(l.window)
+	c, ok := l.counters[k]
+	if !ok || start.After(c.start) {
+		c = &counter{start: start}
+		l.counters[k] = c
+	}
+
+	if c.count >= limit {
+		return c.start.Add(l.window).Sub(now), false
+	}
+
+	c.count++
+	return 0, true
+}
+
+// sweep drops counters of past windows, at most once per window.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Before(l.nextSweep) {
+		return
+	}
+
+	for k, c := range l.counters {
+		if now.Sub(c.start) >= l.window {
+			delete(l.counters, k)
+		}
+	}
+	l.nextSweep = now.Add(l.window)
+}
+
+// peerHost returns the host of the calling peer without its port, so all
+// connections from one client share a counter.
+func peerHost(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+
+	return addr
+}
@@ -0,0 +1,37 @@
+package requestid
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// MetadataKey is the gRPC metadata key carrying the request id from the gateway.
+const MetadataKey = "x-request-id"
+
+type ctxKey struct{}
+
+// WithID returns a copy of ctx carrying the request id.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the request id stored in ctx, or an empty string.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// UnaryServerInterceptor stores the request id received in incoming gRPC metadata in the handler context.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if ids := md.Get(MetadataKey); len(ids) > 0 && ids[0] != "" {
+				ctx = WithID(ctx, ids[0])
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,50 @@
+package serverinfo
+
+import (
+	"context"
+	"runtime/debug"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// gRPC response header keys describing the running server.
+const (
+	MetadataKeyVersion       = "x-server-version"
+	MetadataKeySchemaVersion = "x-schema-version"
+)
+
+// Info describes what the server runs: its build and the applied database migration.
+type Info struct {
+	Version       string
+	SchemaVersion int64
+}
+
+// BuildVersion returns the VCS revision the binary was built from, or "devel"
+// if the build carries no revision.
+func BuildVersion() string {
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			if setting.Key == "vcs.revision" && setting.Value != "" {
+				return setting.Value
+			}
+		}
+	}
+
+	return "devel"
+}
+
+// UnaryServerInterceptor sends info as response header metadata with every call,
+// including health checks, so clients can report which version they talk to.
+func UnaryServerInterceptor(info Info) grpc.UnaryServerInterceptor {
+	md := metadata.Pairs(
+		MetadataKeyVersion, info.Version,
+		MetadataKeySchemaVersion, strconv.FormatInt(info.SchemaVersion, 10),
+	)
+
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		_ = grpc.SetHeader(ctx, md)
+		return handler(ctx, req)
+	}
+}
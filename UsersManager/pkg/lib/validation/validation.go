@@ -0,0 +1,42 @@
+package validation
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Validator is implemented by request messages that can check their own fields.
+type Validator interface {
+	Validate() error
+}
+
+// Func validates the request of a single method. It is used for messages that
+// cannot implement Validator themselves, e.g. ones generated in another module.
+type Func func(req any) error
+
+// UnaryServerInterceptor validates requests before they reach the handler. Requests
+// implementing Validator are checked with Validate, others with the Func registered
+// for their full method name, if any. A failed check is answered with the returned
+// status error, or codes.InvalidArgument if the error carries no status.
+func UnaryServerInterceptor(methods map[string]Func) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var err error
+		if v, ok := req.(Validator); ok {
+			err = v.Validate()
+		} else if validate, ok := methods[info.FullMethod]; ok {
+			err = validate(req)
+		}
+
+		if err != nil {
+			if _, ok := status.FromError(err); ok {
+				return nil, err
+			}
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		return handler(ctx, req)
+	}
+}